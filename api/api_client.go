@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/andreasf/spotify-weekly-releases/cache"
@@ -11,53 +12,87 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
+	"math/rand"
 	"net/http"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 const TRACKS_PER_REQUEST int = 100
+const MAX_ERROR_BODY_SIZE int64 = 64 * 1024
+
+// Artist album listings change whenever an artist releases something, so they
+// are only cached for a short time. Albums themselves rarely change.
+const ARTIST_ALBUMS_TTL time.Duration = 24 * time.Hour
+const ALBUM_TTL time.Duration = 30 * 24 * time.Hour
+
+// Cache keys start with a namespace, so that entries of one kind can be
+// inspected and cleared separately (see cache.Namespace and KeyBuilder).
+const ARTIST_ALBUMS_CACHE_PREFIX string = "artist-albums:"
+const ALBUM_CACHE_PREFIX string = "album:"
 
 //go:generate counterfeiter . SpotifyConnector
 type SpotifyConnector interface {
-	AddTracksToPlaylist(accessToken, userId, playlistId string, tracks []model.Track) error
-	CreatePlaylist(accessToken, userId, name string) (string, error)
-	GetAlbumInfo(accessToken string, albumIds []string) ([]model.Album, error)
-	GetArtistAlbums(accessToken, artistId string, market string) ([]model.Album, error)
-	GetFollowedArtists(accessToken string) ([]model.Artist, error)
-	GetSavedAlbums(accessToken string) ([]model.Album, error)
-	GetUserProfile(accessToken string) (model.UserProfile, error)
+	AddTracksToPlaylist(ctx context.Context, userId, playlistId string, tracks []model.Track) error
+	ChangePlaylistDetails(ctx context.Context, playlistId, name, description string) error
+	CreatePlaylist(ctx context.Context, userId, name string) (string, error)
+	GetAlbumInfo(ctx context.Context, albumIds []string) ([]model.Album, error)
+	GetArtistAlbums(ctx context.Context, artistId string, market string, albumGroups []string) ([]model.Album, error)
+	GetFollowedArtists(ctx context.Context) ([]model.Artist, error)
+	GetSavedAlbums(ctx context.Context) ([]model.Album, error)
+	GetUserPlaylists(ctx context.Context) ([]model.Playlist, error)
+	GetUserProfile(ctx context.Context) (model.UserProfile, error)
+	ReplacePlaylistTracks(ctx context.Context, playlistId string, tracks []model.Track) error
+	Stats() Stats
 }
 
+// SpotifyApiClient is safe for concurrent use. A 429 response pauses all
+// requests of the client until the Retry-After period has passed. Failed GET
+// requests are retried according to the RetryPolicy.
 type SpotifyApiClient struct {
-	urlPrefix   string
-	timeWrapper platform.Time
-	cache       cache.Cache
+	urlPrefix    string
+	tokens       TokenSource
+	timeWrapper  platform.Time
+	cache        cache.Cache
+	keys         KeyBuilder
+	httpClient   *http.Client
+	limiter      *RateLimiter
+	retry        RetryPolicy
+	backoffMutex sync.Mutex
+	backoffUntil time.Time
+	pausedFor    time.Duration
+	statsMutex   sync.Mutex
+	stats        Stats
 }
 
-func NewSpotifyApiClient(apiUrlPrefix string, timeWrapper platform.Time, cache cache.Cache) *SpotifyApiClient {
+// NewSpotifyApiClient creates a client, see ClientOptions for the defaults.
+func NewSpotifyApiClient(apiUrlPrefix string, tokens TokenSource, timeWrapper platform.Time, cache cache.Cache, options ClientOptions) *SpotifyApiClient {
 	return &SpotifyApiClient{
 		urlPrefix:   apiUrlPrefix,
+		tokens:      tokens,
 		timeWrapper: timeWrapper,
 		cache:       cache,
+		httpClient:  newHttpClient(options),
+		limiter:     options.Limiter,
+		retry:       retryPolicy(options),
 	}
 }
 
-func (self *SpotifyApiClient) GetFollowedArtists(accessToken string) ([]model.Artist, error) {
+func (self *SpotifyApiClient) GetFollowedArtists(ctx context.Context) ([]model.Artist, error) {
 	artists := []model.Artist{}
 	nextUrl := self.urlPrefix + "/v1/me/following?type=artist&limit=50"
 
 	for nextUrl != "" {
-		contents, err := self.getWithRateLimiting(accessToken, nextUrl)
+		contents, err := self.getWithRateLimiting(ctx, nextUrl)
 		if err != nil {
-			return nil, fmt.Errorf("GetFollowedArtists: request error: %v", err)
+			return nil, fmt.Errorf("GetFollowedArtists: request error: %w", err)
 		}
 
 		followedArtists := json2.FollowedArtists{}
 		err = json.Unmarshal(contents, &followedArtists)
 		if err != nil {
-			return nil, fmt.Errorf("GetFollowedArtists: error deserializing JSON: %v", err)
+			return nil, fmt.Errorf("GetFollowedArtists: error deserializing JSON: %w", err)
 		}
 
 		nextUrl = followedArtists.Artists.Next
@@ -70,20 +105,22 @@ func (self *SpotifyApiClient) GetFollowedArtists(accessToken string) ([]model.Ar
 	return artists, nil
 }
 
-func (self *SpotifyApiClient) GetArtistAlbums(accessToken, artistId string, market string) ([]model.Album, error) {
+// GetArtistAlbums returns the albums of an artist in the given album groups
+// (see model.ALBUM_GROUPS).
+func (self *SpotifyApiClient) GetArtistAlbums(ctx context.Context, artistId string, market string, albumGroups []string) ([]model.Album, error) {
 	albums := []model.Album{}
-	nextUrl := self.urlPrefix + "/v1/artists/" + artistId + "/albums?album_type=album&limit=50&market=" + market
+	nextUrl := self.urlPrefix + "/v1/artists/" + artistId + "/albums?include_groups=" + strings.Join(albumGroups, ",") + "&limit=50&market=" + market
 
 	for nextUrl != "" {
-		contents, err := self.getWithRateLimitingAndCache(accessToken, nextUrl)
+		contents, err := self.getWithRateLimitingAndCache(ctx, nextUrl, ARTIST_ALBUMS_TTL)
 		if err != nil {
-			return nil, fmt.Errorf("GetArtistAlbums: request error: %v", err)
+			return nil, fmt.Errorf("GetArtistAlbums: request error: %w", err)
 		}
 
 		artistAlbums := json2.ArtistAlbums{}
 		err = json.Unmarshal(contents, &artistAlbums)
 		if err != nil {
-			return nil, fmt.Errorf("GetArtistAlbums: error deserializing JSON: %v", err)
+			return nil, fmt.Errorf("GetArtistAlbums: error deserializing JSON: %w", err)
 		}
 
 		nextUrl = artistAlbums.Next
@@ -96,48 +133,128 @@ func (self *SpotifyApiClient) GetArtistAlbums(accessToken, artistId string, mark
 	return albums, nil
 }
 
-func (self *SpotifyApiClient) getWithRateLimitingAndCache(accessToken string, url string) ([]byte, error) {
-	cached, err := self.cache.Get(url)
-	if err == nil {
-		return cached, nil
+type apiResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+// getWithRateLimitingAndCache returns fresh responses from the cache. Expired
+// responses with an ETag are revalidated with If-None-Match, a 304 Not
+// Modified response renews them for another ttl. Responses that must not be
+// shared between users are never cached, see KeyBuilder.
+func (self *SpotifyApiClient) getWithRateLimitingAndCache(ctx context.Context, url string, ttl time.Duration) ([]byte, error) {
+	key, cacheable := self.keys.Key(url)
+	if !cacheable {
+		return self.getWithRateLimiting(ctx, url)
+	}
+
+	now := self.timeWrapper.Now()
+
+	cached, err := self.cache.GetEntry(key)
+	if err == nil && cached.Fresh(now) {
+		self.count(func(stats *Stats) { stats.CacheHits++ })
+		return cached.Data, nil
+	}
+	self.count(func(stats *Stats) { stats.CacheMisses++ })
+
+	header := http.Header{}
+	if err == nil && cached.ETag != "" {
+		header.Set("If-None-Match", cached.ETag)
 	}
 
-	fromApi, err := self.getWithRateLimiting(accessToken, url)
-	if err == nil {
-		cacheErr := self.cache.Set(url, fromApi)
-		if cacheErr != nil {
-			log.Printf("SpotifyApiClient: error caching response: %v", cacheErr)
+	response, err := self.requestWithRateLimiting(ctx, "GET", url, "", nil, header)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := cached
+	if response.statusCode == http.StatusNotModified {
+		self.count(func(stats *Stats) { stats.Revalidated++ })
+	} else {
+		entry = cache.Entry{
+			Data: response.body,
+			ETag: response.header.Get("ETag"),
 		}
 	}
-	return fromApi, err
+
+	entry.ExpiresAt = time.Time{}
+	if ttl > 0 {
+		entry.ExpiresAt = now.Add(ttl)
+	}
+
+	cacheErr := self.cache.SetEntry(key, entry)
+	if cacheErr != nil {
+		log.Printf("SpotifyApiClient: error caching response: %v", cacheErr)
+	}
+
+	return entry.Data, nil
 }
 
-func (self *SpotifyApiClient) getWithRateLimiting(accessToken string, url string) ([]byte, error) {
-	return self.requestWithRateLimiting(accessToken, "GET", url, "", nil)
+func (self *SpotifyApiClient) getWithRateLimiting(ctx context.Context, url string) ([]byte, error) {
+	response, err := self.requestWithRateLimiting(ctx, "GET", url, "", nil, nil)
+	return response.body, err
 }
 
-func (self *SpotifyApiClient) postWithRateLimiting(accessToken string, url string, contentType string, body []byte) ([]byte, error) {
-	return self.requestWithRateLimiting(accessToken, "POST", url, contentType, bytes.NewBuffer(body))
+func (self *SpotifyApiClient) postWithRateLimiting(ctx context.Context, url string, contentType string, body []byte) ([]byte, error) {
+	response, err := self.requestWithRateLimiting(ctx, "POST", url, contentType, body, nil)
+	return response.body, err
 }
 
-func (self *SpotifyApiClient) requestWithRateLimiting(accessToken string, method string, url string, contentType string, body io.Reader) ([]byte, error) {
-	client := &http.Client{}
+func (self *SpotifyApiClient) putWithRateLimiting(ctx context.Context, url string, contentType string, body []byte) ([]byte, error) {
+	response, err := self.requestWithRateLimiting(ctx, "PUT", url, contentType, body, nil)
+	return response.body, err
+}
 
-	req, err := http.NewRequest(method, url, body)
+// requestWithRateLimiting performs the request, adding the given header, and
+// returns successful and 304 Not Modified responses.
+func (self *SpotifyApiClient) requestWithRateLimiting(ctx context.Context, method string, url string, contentType string, body []byte, header http.Header) (apiResponse, error) {
+	accessToken, err := self.tokens.AccessToken(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("requestWithRateLimiting: error creating request: %v", err)
+		return apiResponse{}, fmt.Errorf("requestWithRateLimiting: error retrieving access token: %w", err)
 	}
+	refreshed := false
+	retries := 0
+	startedAt := self.timeWrapper.Now()
+	var rateLimitedFor time.Duration
+	rateLimitedRetries := 0
 
-	req.Header.Add("Authorization", "Bearer "+accessToken)
+	for {
+		err = self.waitForBackoff(ctx)
+		if err != nil {
+			return apiResponse{}, err
+		}
 
-	if contentType != "" {
-		req.Header.Add("Content-Type", "application/json")
-	}
+		err = self.limiter.Wait(ctx)
+		if err != nil {
+			return apiResponse{}, err
+		}
 
-	for {
-		resp, err := client.Do(req)
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+		if err != nil {
+			return apiResponse{}, fmt.Errorf("requestWithRateLimiting: error creating request: %w", err)
+		}
+
+		for key, values := range header {
+			for _, value := range values {
+				req.Header.Add(key, value)
+			}
+		}
+		req.Header.Add("Authorization", "Bearer "+accessToken)
+
+		if contentType != "" {
+			req.Header.Add("Content-Type", contentType)
+		}
+
+		self.count(func(stats *Stats) { stats.Requests++ })
+		resp, err := self.httpClient.Do(req)
 		if err != nil {
-			return nil, fmt.Errorf("requestWithRateLimiting: error performing request: %v", err)
+			log.Printf("requestWithRateLimiting: %s %s: %v", method, url, err)
+			if self.waitToRetry(ctx, method, url, retries, startedAt) {
+				retries++
+				continue
+			}
+			return apiResponse{}, fmt.Errorf("requestWithRateLimiting: error performing request: %w", err)
 		}
 
 		switch resp.StatusCode {
@@ -146,43 +263,178 @@ func (self *SpotifyApiClient) requestWithRateLimiting(accessToken string, method
 		case 201:
 			log.Printf("requestWithRateLimiting: %s %d %s", method, resp.StatusCode, url)
 			contents, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
 			if err != nil {
-				return nil, fmt.Errorf("requestWithRateLimiting: error reading response: %v", err)
+				return apiResponse{}, fmt.Errorf("requestWithRateLimiting: error reading response: %w", err)
 			}
 
-			return contents, nil
+			return apiResponse{
+				statusCode: resp.StatusCode,
+				header:     resp.Header,
+				body:       contents,
+			}, nil
+
+		case 304:
+			resp.Body.Close()
+			log.Printf("requestWithRateLimiting: %s %d %s", method, resp.StatusCode, url)
+
+			return apiResponse{
+				statusCode: resp.StatusCode,
+				header:     resp.Header,
+			}, nil
+
+		case 401:
+			errorBody := readErrorBody(resp)
+			if refreshed {
+				return apiResponse{}, fmt.Errorf("requestWithRateLimiting: access token rejected after refreshing it: %w", newError(method, url, resp.StatusCode, errorBody, false))
+			}
+
+			log.Printf("requestWithRateLimiting: 401 %s, refreshing access token", url)
+			accessToken, err = self.tokens.Refresh(ctx)
+			if err != nil {
+				return apiResponse{}, fmt.Errorf("requestWithRateLimiting: error refreshing access token: %w", err)
+			}
+			refreshed = true
 
 		case 429:
-			retryAfter := resp.Header.Get("Retry-After")
-			sleepSeconds, err := strconv.Atoi(retryAfter)
+			resp.Body.Close()
+			self.count(func(stats *Stats) { stats.RateLimited++ })
+			rateLimitedRetries++
+			retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), self.timeWrapper.Now())
+			rateLimitedFor += retryAfter
+			err = self.retry.checkRateLimitedRetries(url, rateLimitedRetries)
+			if err == nil {
+				err = self.backOff(url, retryAfter, rateLimitedFor)
+			}
 			if err != nil {
-				sleepSeconds = 1
+				log.Printf("requestWithRateLimiting: 429 %s, giving up: %v", url, err)
+				return apiResponse{}, err
 			}
-			log.Printf("requestWithRateLimiting: 429 %s, retrying in %d seconds", url, sleepSeconds)
-			self.timeWrapper.Sleep(time.Second * time.Duration(sleepSeconds))
+			log.Printf("requestWithRateLimiting: 429 %s, retrying in %s", url, retryAfter)
 
 		default:
+			errorBody := readErrorBody(resp)
 			log.Printf("requestWithRateLimiting: %d %s", resp.StatusCode, url)
-			return nil, fmt.Errorf("requestWithRateLimiting: received %d for GET %s", resp.StatusCode, url)
+			retryable := self.retry.IsRetryableStatus(resp.StatusCode)
+			if retryable && self.waitToRetry(ctx, method, url, retries, startedAt) {
+				retries++
+				continue
+			}
+			return apiResponse{}, fmt.Errorf("requestWithRateLimiting: %w", newError(method, url, resp.StatusCode, errorBody, retryable))
 		}
 	}
 }
 
-func (self *SpotifyApiClient) GetAlbumInfo(accessToken string, albumIds []string) ([]model.Album, error) {
+// readErrorBody reads and closes the body of an error response. Error
+// responses are small, anything beyond MAX_ERROR_BODY_SIZE is ignored.
+func readErrorBody(resp *http.Response) []byte {
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, MAX_ERROR_BODY_SIZE))
+	if err != nil {
+		return nil
+	}
+
+	return body
+}
+
+// waitToRetry sleeps before the given retry of a failed request. It returns
+// false without sleeping if the request must not be retried: it is not a GET,
+// or the attempts or the time budget of the retry policy are used up. It also
+// returns false if ctx is done before the retry.
+func (self *SpotifyApiClient) waitToRetry(ctx context.Context, method string, url string, retry int, startedAt time.Time) bool {
+	if method != "GET" || retry+1 >= self.retry.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+
+	d := self.retry.Backoff(retry, rand.Float64())
+	if self.retry.MaxElapsed > 0 && self.timeWrapper.Now().Add(d).Sub(startedAt) > self.retry.MaxElapsed {
+		log.Printf("requestWithRateLimiting: not retrying %s, retry time budget used up", url)
+		return false
+	}
+
+	log.Printf("requestWithRateLimiting: retrying %s in %s", url, d)
+	self.count(func(stats *Stats) { stats.Retries++ })
+	return self.timeWrapper.SleepContext(ctx, d) == nil
+}
+
+// backOff pauses all requests for the given duration, unless they are already
+// paused for longer. It returns a *RetryBudgetError instead if the duration,
+// the total the request has been rate limited for, or the total pause of the
+// client would exceed the limits of the retry policy.
+func (self *SpotifyApiClient) backOff(url string, d time.Duration, requestTotal time.Duration) error {
+	self.backoffMutex.Lock()
+	defer self.backoffMutex.Unlock()
+
+	now := self.timeWrapper.Now()
+	until := now.Add(d)
+
+	// only the time the pause is extended by counts towards the client's total
+	var extension time.Duration
+	if until.After(self.backoffUntil) {
+		extension = until.Sub(now)
+		if self.backoffUntil.After(now) {
+			extension = until.Sub(self.backoffUntil)
+		}
+	}
+
+	err := self.retry.checkRetryAfter(url, d, requestTotal, self.pausedFor+extension)
+	if err != nil {
+		return err
+	}
+
+	if until.After(self.backoffUntil) {
+		self.backoffUntil = until
+		self.pausedFor += extension
+	}
+	return nil
+}
+
+// waitForBackoff sleeps until the current back-off period has passed. The
+// period is cleared afterwards, unless another 429 has extended it meanwhile.
+// It returns ctx.Err() if ctx is done before.
+func (self *SpotifyApiClient) waitForBackoff(ctx context.Context) error {
+	self.backoffMutex.Lock()
+	until := self.backoffUntil
+	self.backoffMutex.Unlock()
+
+	if until.IsZero() {
+		return nil
+	}
+
+	d := until.Sub(self.timeWrapper.Now())
+	if d > 0 {
+		err := self.timeWrapper.SleepContext(ctx, d)
+		if err != nil {
+			return err
+		}
+	}
+
+	self.backoffMutex.Lock()
+	if self.backoffUntil.Equal(until) {
+		self.backoffUntil = time.Time{}
+	}
+	self.backoffMutex.Unlock()
+	return nil
+}
+
+// GetAlbumInfo requests albums without a market, so that they can be cached
+// for all users (see KeyBuilder).
+func (self *SpotifyApiClient) GetAlbumInfo(ctx context.Context, albumIds []string) ([]model.Album, error) {
 	cachedAlbums, uncachedIds := self.getAlbumsFromCache(albumIds)
 
 	apiAlbums := json2.MultipleAlbums{}
 	if len(uncachedIds) > 0 {
 		url := self.urlPrefix + "/v1/albums?ids=" + strings.Join(uncachedIds, ",")
 
-		response, err := self.getWithRateLimiting(accessToken, url)
+		response, err := self.getWithRateLimiting(ctx, url)
 		if err != nil {
-			return nil, fmt.Errorf("GetAlbumInfo: request error: %v", err)
+			return nil, fmt.Errorf("GetAlbumInfo: request error: %w", err)
 		}
 
 		err = json.Unmarshal(response, &apiAlbums)
 		if err != nil {
-			return nil, fmt.Errorf("GetAlbumInfo: error deserializing JSON: %v", err)
+			return nil, fmt.Errorf("GetAlbumInfo: error deserializing JSON: %w", err)
 		}
 
 		self.cacheAlbums(apiAlbums)
@@ -198,7 +450,7 @@ func (self *SpotifyApiClient) getAlbumsFromCache(albumIds []string) (json2.Artis
 	var cachedAlbums json2.ArtistAlbumList = []json2.ArtistAlbum{}
 	uncachedIds := make([]string, 0, len(albumIds))
 	for _, albumId := range albumIds {
-		albumBytes, err := self.cache.Get("album:" + albumId)
+		albumBytes, err := self.cache.Get(self.keys.Album(albumId))
 		if err != nil {
 			uncachedIds = append(uncachedIds, albumId)
 			continue
@@ -213,6 +465,11 @@ func (self *SpotifyApiClient) getAlbumsFromCache(albumIds []string) (json2.Artis
 		}
 		cachedAlbums = append(cachedAlbums, album)
 	}
+
+	self.count(func(stats *Stats) {
+		stats.CacheHits += len(cachedAlbums)
+		stats.CacheMisses += len(uncachedIds)
+	})
 	return cachedAlbums, uncachedIds
 }
 
@@ -224,28 +481,31 @@ func (self *SpotifyApiClient) cacheAlbums(apiAlbums json2.MultipleAlbums) {
 			continue
 		}
 
-		self.cache.Set("album:"+album.Id, albumJson)
+		err = self.cache.Set(self.keys.Album(album.Id), albumJson, ALBUM_TTL)
+		if err != nil {
+			log.Printf("GetAlbumInfo: error caching album: %v", err)
+		}
 	}
 }
 
-func (self *SpotifyApiClient) GetUserProfile(accessToken string) (model.UserProfile, error) {
+func (self *SpotifyApiClient) GetUserProfile(ctx context.Context) (model.UserProfile, error) {
 	url := self.urlPrefix + "/v1/me"
 
-	response, err := self.getWithRateLimiting(accessToken, url)
+	response, err := self.getWithRateLimiting(ctx, url)
 	if err != nil {
-		return model.UserProfile{}, fmt.Errorf("GetUserProfile: request error: %v", err)
+		return model.UserProfile{}, fmt.Errorf("GetUserProfile: request error: %w", err)
 	}
 
 	jsonProfile := &json2.UserProfile{}
 	err = json.Unmarshal(response, jsonProfile)
 	if err != nil {
-		return model.UserProfile{}, fmt.Errorf("GetUserProfile: error deserializing JSON: %v", err)
+		return model.UserProfile{}, fmt.Errorf("GetUserProfile: error deserializing JSON: %w", err)
 	}
 
 	return jsonProfile.ToModel(), nil
 }
 
-func (self *SpotifyApiClient) CreatePlaylist(accessToken, userId, name string) (string, error) {
+func (self *SpotifyApiClient) CreatePlaylist(ctx context.Context, userId, name string) (string, error) {
 	url := fmt.Sprintf("%s/v1/users/%s/playlists", self.urlPrefix, userId)
 
 	request := json2.CreatePlaylistRequest{
@@ -255,24 +515,24 @@ func (self *SpotifyApiClient) CreatePlaylist(accessToken, userId, name string) (
 
 	body, err := json.Marshal(&request)
 	if err != nil {
-		return "", fmt.Errorf("CreatePlaylist: error serializing JSON: %v", err)
+		return "", fmt.Errorf("CreatePlaylist: error serializing JSON: %w", err)
 	}
 
-	responseBytes, err := self.postWithRateLimiting(accessToken, url, "application/json", body)
+	responseBytes, err := self.postWithRateLimiting(ctx, url, "application/json", body)
 	if err != nil {
-		return "", fmt.Errorf("CreatePlaylist: request error: %v", err)
+		return "", fmt.Errorf("CreatePlaylist: request error: %w", err)
 	}
 
 	responseJson := json2.CreatePlaylistResponse{}
 	err = json.Unmarshal(responseBytes, &responseJson)
 	if err != nil {
-		return "", fmt.Errorf("CreatePlaylist: error deserializing JSON: %v", err)
+		return "", fmt.Errorf("CreatePlaylist: error deserializing JSON: %w", err)
 	}
 
 	return responseJson.Id, nil
 }
 
-func (self *SpotifyApiClient) AddTracksToPlaylist(accessToken, userId, playlistId string, tracks []model.Track) error {
+func (self *SpotifyApiClient) AddTracksToPlaylist(ctx context.Context, userId, playlistId string, tracks []model.Track) error {
 	url := fmt.Sprintf("%s/v1/users/%s/playlists/%s/tracks", self.urlPrefix, userId, playlistId)
 
 	numberOfRequests := len(tracks) / TRACKS_PER_REQUEST
@@ -290,32 +550,118 @@ func (self *SpotifyApiClient) AddTracksToPlaylist(accessToken, userId, playlistI
 		}
 		body, err := json.Marshal(&request)
 		if err != nil {
-			return fmt.Errorf("AddTracksToPlaylist: error serializing JSON: %v", err)
+			return fmt.Errorf("AddTracksToPlaylist: error serializing JSON: %w", err)
 		}
 
-		_, err = self.postWithRateLimiting(accessToken, url, "application/json", body)
+		_, err = self.postWithRateLimiting(ctx, url, "application/json", body)
 		if err != nil {
-			return fmt.Errorf("AddTracksToPlaylist: request error: %v", err)
+			return fmt.Errorf("AddTracksToPlaylist: request error: %w", err)
 		}
 	}
 
 	return nil
 }
 
-func (self *SpotifyApiClient) GetSavedAlbums(accessToken string) ([]model.Album, error) {
+// ReplacePlaylistTracks replaces all tracks of the playlist. The replace
+// endpoint takes at most TRACKS_PER_REQUEST tracks, the rest is appended.
+func (self *SpotifyApiClient) ReplacePlaylistTracks(ctx context.Context, playlistId string, tracks []model.Track) error {
+	url := fmt.Sprintf("%s/v1/playlists/%s/tracks", self.urlPrefix, playlistId)
+
+	var firstSlice model.TrackList = tracks[0:min(TRACKS_PER_REQUEST, len(tracks))]
+	request := json2.AddTracksRequest{
+		Uris: firstSlice.GetUris(),
+	}
+	body, err := json.Marshal(&request)
+	if err != nil {
+		return fmt.Errorf("ReplacePlaylistTracks: error serializing JSON: %w", err)
+	}
+
+	_, err = self.putWithRateLimiting(ctx, url, "application/json", body)
+	if err != nil {
+		return fmt.Errorf("ReplacePlaylistTracks: request error: %w", err)
+	}
+
+	for from := TRACKS_PER_REQUEST; from < len(tracks); from += TRACKS_PER_REQUEST {
+		var trackSlice model.TrackList = tracks[from:min(from+TRACKS_PER_REQUEST, len(tracks))]
+
+		request := json2.AddTracksRequest{
+			Uris: trackSlice.GetUris(),
+		}
+		body, err := json.Marshal(&request)
+		if err != nil {
+			return fmt.Errorf("ReplacePlaylistTracks: error serializing JSON: %w", err)
+		}
+
+		_, err = self.postWithRateLimiting(ctx, url, "application/json", body)
+		if err != nil {
+			return fmt.Errorf("ReplacePlaylistTracks: request error: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (self *SpotifyApiClient) ChangePlaylistDetails(ctx context.Context, playlistId, name, description string) error {
+	url := fmt.Sprintf("%s/v1/playlists/%s", self.urlPrefix, playlistId)
+
+	request := json2.ChangePlaylistDetailsRequest{
+		Name:        name,
+		Description: description,
+	}
+
+	body, err := json.Marshal(&request)
+	if err != nil {
+		return fmt.Errorf("ChangePlaylistDetails: error serializing JSON: %w", err)
+	}
+
+	_, err = self.putWithRateLimiting(ctx, url, "application/json", body)
+	if err != nil {
+		return fmt.Errorf("ChangePlaylistDetails: request error: %w", err)
+	}
+
+	return nil
+}
+
+func (self *SpotifyApiClient) GetUserPlaylists(ctx context.Context) ([]model.Playlist, error) {
+	playlists := []model.Playlist{}
+	nextUrl := self.urlPrefix + "/v1/me/playlists?limit=50"
+
+	for nextUrl != "" {
+		contents, err := self.getWithRateLimiting(ctx, nextUrl)
+		if err != nil {
+			return nil, fmt.Errorf("GetUserPlaylists: request error: %w", err)
+		}
+
+		userPlaylists := json2.PaginatedPlaylists{}
+		err = json.Unmarshal(contents, &userPlaylists)
+		if err != nil {
+			return nil, fmt.Errorf("GetUserPlaylists: error deserializing JSON: %w", err)
+		}
+
+		nextUrl = userPlaylists.Next
+
+		for _, playlist := range userPlaylists.Items {
+			playlists = append(playlists, playlist.ToModel())
+		}
+	}
+
+	return playlists, nil
+}
+
+func (self *SpotifyApiClient) GetSavedAlbums(ctx context.Context) ([]model.Album, error) {
 	albums := []model.Album{}
 	nextUrl := self.urlPrefix + "/v1/me/albums?limit=50"
 
 	for nextUrl != "" {
-		contents, err := self.getWithRateLimiting(accessToken, nextUrl)
+		contents, err := self.getWithRateLimiting(ctx, nextUrl)
 		if err != nil {
-			return nil, fmt.Errorf("GetSavedAlbums: request error: %v", err)
+			return nil, fmt.Errorf("GetSavedAlbums: request error: %w", err)
 		}
 
 		savedAlbums := json2.PaginatedSavedAlbums{}
 		err = json.Unmarshal(contents, &savedAlbums)
 		if err != nil {
-			return nil, fmt.Errorf("GetSavedAlbums: error deserializing JSON: %v", err)
+			return nil, fmt.Errorf("GetSavedAlbums: error deserializing JSON: %w", err)
 		}
 
 		nextUrl = savedAlbums.Next
@@ -3,8 +3,13 @@ package api_test
 import (
 	. "github.com/andreasf/spotify-weekly-releases/api"
 
+	"context"
 	json2 "encoding/json"
 	"errors"
+	"fmt"
+	"github.com/andreasf/spotify-weekly-releases/api/apifakes"
+	"github.com/andreasf/spotify-weekly-releases/auth"
+	cache2 "github.com/andreasf/spotify-weekly-releases/cache"
 	"github.com/andreasf/spotify-weekly-releases/cache/cachefakes"
 	"github.com/andreasf/spotify-weekly-releases/json"
 	"github.com/andreasf/spotify-weekly-releases/model"
@@ -20,6 +25,15 @@ import (
 )
 
 var _ = Describe("SpotifyApiClient", func() {
+	var ctx context.Context
+	var tokens *apifakes.FakeTokenSource
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		tokens = &apifakes.FakeTokenSource{}
+		tokens.AccessTokenReturns("access-token", nil)
+	})
+
 	Describe("GetFollowedArtists", func() {
 		It("Makes a GET request to the endpoint", func() {
 			expectedArtists := []model.Artist{
@@ -60,8 +74,8 @@ var _ = Describe("SpotifyApiClient", func() {
 
 			cache := &cachefakes.FakeCache{}
 			timeWrapper := &platformfakes.FakeTime{}
-			client := NewSpotifyApiClient(server.URL(), timeWrapper, cache)
-			artists, err := client.GetFollowedArtists("access-token")
+			client := NewSpotifyApiClient(server.URL(), tokens, timeWrapper, cache, ClientOptions{})
+			artists, err := client.GetFollowedArtists(ctx)
 
 			Expect(err).To(BeNil())
 			Expect(artists).To(Equal(expectedArtists))
@@ -79,25 +93,28 @@ var _ = Describe("SpotifyApiClient", func() {
 		BeforeEach(func() {
 			expectedAlbums = []model.Album{
 				{
-					Name:      "Foo, The Album",
-					Id:        "foo-album",
-					ArtistIds: []string{"foo-id"},
-					Tracks:    []model.Track{},
-					Markets:   []string{"SG"},
+					Name:       "Foo, The Album",
+					Id:         "foo-album",
+					ArtistIds:  []string{"foo-id"},
+					Tracks:     []model.Track{},
+					Markets:    []string{"SG"},
+					AlbumGroup: "album",
 				},
 				{
-					Name:      "Bar, The Album",
-					Id:        "bar-album",
-					ArtistIds: []string{"foo-id"},
-					Tracks:    []model.Track{},
-					Markets:   []string{"CA", "MX", "US"},
+					Name:       "Bar, The Album",
+					Id:         "bar-album",
+					ArtistIds:  []string{"foo-id"},
+					Tracks:     []model.Track{},
+					Markets:    []string{"CA", "MX", "US"},
+					AlbumGroup: "single",
 				},
 				{
-					Name:      "Baz, The Album",
-					Id:        "baz-album",
-					ArtistIds: []string{"foo-id"},
-					Tracks:    []model.Track{},
-					Markets:   []string{"SG"},
+					Name:       "Baz, The Album",
+					Id:         "baz-album",
+					ArtistIds:  []string{"foo-id"},
+					Tracks:     []model.Track{},
+					Markets:    []string{"SG"},
+					AlbumGroup: "appears_on",
 				},
 			}
 
@@ -111,25 +128,25 @@ var _ = Describe("SpotifyApiClient", func() {
 
 			timeWrapper = &platformfakes.FakeTime{}
 			cache = &cachefakes.FakeCache{}
-			cache.GetReturns(nil, errors.New("not found"))
+			cache.GetEntryReturns(cache2.Entry{}, errors.New("not found"))
 		})
 
 		It("Makes a GET request to the endpoint", func() {
 			server.AppendHandlers(
 				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("GET", "/v1/artists/foo-id/albums", "album_type=album&limit=50&market=market-id"),
+					ghttp.VerifyRequest("GET", "/v1/artists/foo-id/albums", "include_groups=album,single,appears_on&limit=50&market=market-id"),
 					ghttp.VerifyHeaderKV("Authorization", "Bearer access-token"),
 					ghttp.RespondWith(200, page1),
 				),
 				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("GET", "/v1/artists/foo-id/albums", "offset=2&limit=2&album_type=single,album,compilation,appears_on,ep"),
+					ghttp.VerifyRequest("GET", "/v1/artists/foo-id/albums", "offset=2&limit=2&include_groups=album,single,appears_on"),
 					ghttp.VerifyHeaderKV("Authorization", "Bearer access-token"),
 					ghttp.RespondWith(200, page2),
 				),
 			)
 
-			client := NewSpotifyApiClient(server.URL(), timeWrapper, cache)
-			albums, err := client.GetArtistAlbums("access-token", "foo-id", "market-id")
+			client := NewSpotifyApiClient(server.URL(), tokens, timeWrapper, cache, ClientOptions{})
+			albums, err := client.GetArtistAlbums(ctx, "foo-id", "market-id", []string{"album", "single", "appears_on"})
 
 			Expect(err).To(BeNil())
 			Expect(albums).To(Equal(expectedAlbums))
@@ -143,76 +160,213 @@ var _ = Describe("SpotifyApiClient", func() {
 
 			server.AppendHandlers(
 				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("GET", "/v1/artists/foo-id/albums", "album_type=album&limit=50&market=market-id"),
+					ghttp.VerifyRequest("GET", "/v1/artists/foo-id/albums", "include_groups=album,single,appears_on&limit=50&market=market-id"),
 					ghttp.VerifyHeaderKV("Authorization", "Bearer access-token"),
 					ghttp.RespondWith(200, page1),
 				),
 				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("GET", "/v1/artists/foo-id/albums", "offset=2&limit=2&album_type=single,album,compilation,appears_on,ep"),
+					ghttp.VerifyRequest("GET", "/v1/artists/foo-id/albums", "offset=2&limit=2&include_groups=album,single,appears_on"),
 					ghttp.VerifyHeaderKV("Authorization", "Bearer access-token"),
 					ghttp.RespondWith(429, []byte{}, retryHeader),
 				),
 				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("GET", "/v1/artists/foo-id/albums", "offset=2&limit=2&album_type=single,album,compilation,appears_on,ep"),
+					ghttp.VerifyRequest("GET", "/v1/artists/foo-id/albums", "offset=2&limit=2&include_groups=album,single,appears_on"),
 					ghttp.VerifyHeaderKV("Authorization", "Bearer access-token"),
 					ghttp.RespondWith(200, page2),
 				),
 			)
 
-			client := NewSpotifyApiClient(server.URL(), timeWrapper, cache)
-			albums, err := client.GetArtistAlbums("access-token", "foo-id", "market-id")
+			client := NewSpotifyApiClient(server.URL(), tokens, timeWrapper, cache, ClientOptions{})
+			albums, err := client.GetArtistAlbums(ctx, "foo-id", "market-id", []string{"album", "single", "appears_on"})
 
 			Expect(err).To(BeNil())
 			Expect(albums).To(Equal(expectedAlbums))
 			Expect(server.ReceivedRequests()).Should(HaveLen(3))
-			Expect(timeWrapper.SleepCallCount()).To(Equal(1))
-			Expect(timeWrapper.SleepArgsForCall(0)).To(Equal(2 * time.Second))
+			Expect(timeWrapper.SleepContextCallCount()).To(Equal(1))
+			Expect(sleptFor(timeWrapper, 0)).To(Equal(2 * time.Second))
+			Expect(client.Stats().Requests).To(Equal(3))
+			Expect(client.Stats().RateLimited).To(Equal(1))
+		})
+
+		It("Stops waiting for the Retry-After period when the context is cancelled", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(429, []byte{}, http.Header{"Retry-After": []string{"2"}}),
+			)
+
+			ctx, cancel := context.WithCancel(ctx)
+			timeWrapper.SleepContextStub = func(ctx context.Context, d time.Duration) error {
+				cancel()
+				return ctx.Err()
+			}
+
+			client := NewSpotifyApiClient(server.URL(), tokens, timeWrapper, cache, ClientOptions{})
+			_, err := client.GetArtistAlbums(ctx, "foo-id", "market-id", []string{"album"})
+
+			Expect(err).To(MatchError(ContainSubstring("context canceled")))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("Does not make requests once the context is cancelled", func() {
+			ctx, cancel := context.WithCancel(ctx)
+			cancel()
+
+			client := NewSpotifyApiClient(server.URL(), tokens, timeWrapper, cache, ClientOptions{})
+			_, err := client.GetArtistAlbums(ctx, "foo-id", "market-id", []string{"album"})
+
+			Expect(err).To(MatchError(ContainSubstring("context canceled")))
+			Expect(server.ReceivedRequests()).To(HaveLen(0))
+		})
+
+		It("Pauses concurrent requests until the Retry-After period has passed", func() {
+			artistAlbumsRequests := 0
+			server.RouteToHandler("GET", "/v1/artists/foo-id/albums", func(w http.ResponseWriter, r *http.Request) {
+				artistAlbumsRequests++
+				if artistAlbumsRequests == 1 {
+					w.Header().Set("Retry-After", "2")
+					w.WriteHeader(429)
+					return
+				}
+				w.Write(page2)
+			})
+			server.RouteToHandler("GET", "/v1/me", ghttp.RespondWith(200, test_resources.LoadResource("../test_resources/user_profile.json")))
+
+			client := NewSpotifyApiClient(server.URL(), tokens, timeWrapper, cache, ClientOptions{})
+
+			var profileErr error
+			profileDone := make(chan struct{})
+			secondSleep := make(chan struct{})
+			timeWrapper.SleepContextStub = func(_ context.Context, d time.Duration) error {
+				if timeWrapper.SleepContextCallCount() == 1 {
+					go func() {
+						_, profileErr = client.GetUserProfile(ctx)
+						close(profileDone)
+					}()
+					<-secondSleep
+				} else {
+					close(secondSleep)
+				}
+				return nil
+			}
+
+			_, err := client.GetArtistAlbums(ctx, "foo-id", "market-id", []string{"album"})
+			Expect(err).To(BeNil())
+			Eventually(profileDone).Should(BeClosed())
+			Expect(profileErr).To(BeNil())
+
+			Expect(timeWrapper.SleepContextCallCount()).To(Equal(2))
+			Expect(sleptFor(timeWrapper, 0)).To(Equal(2 * time.Second))
+			Expect(sleptFor(timeWrapper, 1)).To(Equal(2 * time.Second))
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
 		})
 
 		It("Checks the cache before making HTTP requests", func() {
-			cache.GetReturns(page2, nil)
+			cache.GetEntryReturns(cache2.Entry{Data: page2}, nil)
 			page2Albums := []model.Album{
 				expectedAlbums[2],
 			}
 
-			client := NewSpotifyApiClient(server.URL(), timeWrapper, cache)
-			albums, err := client.GetArtistAlbums("access-token", "foo-id", "market-id")
+			client := NewSpotifyApiClient(server.URL(), tokens, timeWrapper, cache, ClientOptions{})
+			albums, err := client.GetArtistAlbums(ctx, "foo-id", "market-id", []string{"album", "single", "appears_on"})
 
 			Expect(err).To(BeNil())
 			Expect(albums).To(Equal(page2Albums))
 			Expect(server.ReceivedRequests()).Should(HaveLen(0))
-			Expect(cache.GetCallCount()).To(Equal(1))
-			Expect(cache.GetArgsForCall(0)).To(Equal(server.URL() + "/v1/artists/foo-id/albums?album_type=album&limit=50&market=market-id"))
+			Expect(client.Stats()).To(Equal(Stats{CacheHits: 1}))
+			Expect(cache.GetEntryCallCount()).To(Equal(1))
+			Expect(cache.GetEntryArgsForCall(0)).To(Equal("artist-albums:/v1/artists/foo-id/albums?include_groups=album%2Csingle%2Cappears_on&limit=50&market=market-id"))
+		})
+
+		It("Revalidates expired responses with their ETag", func() {
+			now := time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+			timeWrapper.NowReturns(now)
+			cache.GetEntryReturns(cache2.Entry{
+				Data:      page2,
+				ETag:      `"page-etag"`,
+				ExpiresAt: now.Add(-time.Minute),
+			}, nil)
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/artists/foo-id/albums", "include_groups=album&limit=50&market=market-id"),
+					ghttp.VerifyHeaderKV("If-None-Match", `"page-etag"`),
+					ghttp.RespondWith(304, nil),
+				),
+			)
+
+			client := NewSpotifyApiClient(server.URL(), tokens, timeWrapper, cache, ClientOptions{})
+			albums, err := client.GetArtistAlbums(ctx, "foo-id", "market-id", []string{"album"})
+
+			Expect(err).To(BeNil())
+			Expect(albums).To(Equal([]model.Album{expectedAlbums[2]}))
+			Expect(server.ReceivedRequests()).Should(HaveLen(1))
+			Expect(client.Stats()).To(Equal(Stats{Requests: 1, CacheMisses: 1, Revalidated: 1}))
+
+			Expect(cache.SetEntryCallCount()).To(Equal(1))
+			_, entry := cache.SetEntryArgsForCall(0)
+			Expect(entry).To(Equal(cache2.Entry{
+				Data:      page2,
+				ETag:      `"page-etag"`,
+				ExpiresAt: now.Add(ARTIST_ALBUMS_TTL),
+			}))
+		})
+
+		It("Replaces expired responses that have changed", func() {
+			timeWrapper.NowReturns(time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC))
+			cache.GetEntryReturns(cache2.Entry{
+				Data:      page1,
+				ETag:      `"old-etag"`,
+				ExpiresAt: time.Date(2016, 12, 31, 0, 0, 0, 0, time.UTC),
+			}, nil)
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/artists/foo-id/albums", "include_groups=album&limit=50&market=market-id"),
+					ghttp.VerifyHeaderKV("If-None-Match", `"old-etag"`),
+					ghttp.RespondWith(200, page2, http.Header{"ETag": {`"new-etag"`}}),
+				),
+			)
+
+			client := NewSpotifyApiClient(server.URL(), tokens, timeWrapper, cache, ClientOptions{})
+			albums, err := client.GetArtistAlbums(ctx, "foo-id", "market-id", []string{"album"})
+
+			Expect(err).To(BeNil())
+			Expect(albums).To(Equal([]model.Album{expectedAlbums[2]}))
+
+			_, entry := cache.SetEntryArgsForCall(0)
+			Expect(entry.Data).To(Equal(page2))
+			Expect(entry.ETag).To(Equal(`"new-etag"`))
 		})
 
 		It("Stores responses in the cache", func() {
 			server.AppendHandlers(
 				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("GET", "/v1/artists/foo-id/albums", "album_type=album&limit=50&market=market-id"),
+					ghttp.VerifyRequest("GET", "/v1/artists/foo-id/albums", "include_groups=album,single,appears_on&limit=50&market=market-id"),
 					ghttp.VerifyHeaderKV("Authorization", "Bearer access-token"),
 					ghttp.RespondWith(200, page1),
 				),
 				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("GET", "/v1/artists/foo-id/albums", "offset=2&limit=2&album_type=single,album,compilation,appears_on,ep"),
+					ghttp.VerifyRequest("GET", "/v1/artists/foo-id/albums", "offset=2&limit=2&include_groups=album,single,appears_on"),
 					ghttp.VerifyHeaderKV("Authorization", "Bearer access-token"),
 					ghttp.RespondWith(200, page2),
 				),
 			)
 
-			client := NewSpotifyApiClient(server.URL(), timeWrapper, cache)
-			albums, err := client.GetArtistAlbums("access-token", "foo-id", "market-id")
+			client := NewSpotifyApiClient(server.URL(), tokens, timeWrapper, cache, ClientOptions{})
+			albums, err := client.GetArtistAlbums(ctx, "foo-id", "market-id", []string{"album", "single", "appears_on"})
 
 			Expect(err).To(BeNil())
 			Expect(albums).ToNot(BeNil())
 			Expect(server.ReceivedRequests()).Should(HaveLen(2))
-			Expect(cache.SetCallCount()).To(Equal(2))
-
-			key1, data1 := cache.SetArgsForCall(0)
-			key2, data2 := cache.SetArgsForCall(1)
-			Expect(key1).To(Equal(server.URL() + "/v1/artists/foo-id/albums?album_type=album&limit=50&market=market-id"))
-			Expect(key2).To(Equal(server.URL() + "/v1/artists/foo-id/albums?offset=2&limit=2&album_type=single,album,compilation,appears_on,ep"))
-			Expect(data1).To(Equal(page1))
-			Expect(data2).To(Equal(page2))
+			Expect(cache.SetEntryCallCount()).To(Equal(2))
+
+			key1, entry1 := cache.SetEntryArgsForCall(0)
+			key2, entry2 := cache.SetEntryArgsForCall(1)
+			Expect(key1).To(Equal("artist-albums:/v1/artists/foo-id/albums?include_groups=album%2Csingle%2Cappears_on&limit=50&market=market-id"))
+			Expect(key2).To(Equal("artist-albums:/v1/artists/foo-id/albums?include_groups=album%2Csingle%2Cappears_on&limit=2&offset=2"))
+			Expect(entry1.Data).To(Equal(page1))
+			Expect(entry2.Data).To(Equal(page2))
+			Expect(entry1.ExpiresAt).To(Equal(time.Time{}.Add(ARTIST_ALBUMS_TTL)))
+			Expect(entry2.ExpiresAt).To(Equal(time.Time{}.Add(ARTIST_ALBUMS_TTL)))
 		})
 	})
 
@@ -238,7 +392,7 @@ var _ = Describe("SpotifyApiClient", func() {
 
 			timeWrapper = &platformfakes.FakeTime{}
 			cache = &cachefakes.FakeCache{}
-			client = NewSpotifyApiClient(server.URL(), timeWrapper, cache)
+			client = NewSpotifyApiClient(server.URL(), tokens, timeWrapper, cache, ClientOptions{})
 
 			albumIds = []string{
 				"album-id-1",
@@ -248,14 +402,14 @@ var _ = Describe("SpotifyApiClient", func() {
 		})
 
 		It("Makes a single GET request", func() {
-			_, err := client.GetAlbumInfo("access-token", albumIds)
+			_, err := client.GetAlbumInfo(ctx, albumIds)
 
 			Expect(err).To(BeNil())
 			Expect(server.ReceivedRequests()).To(HaveLen(1))
 		})
 
 		It("Returns the matching []model.Album", func() {
-			albums, err := client.GetAlbumInfo("access-token", albumIds)
+			albums, err := client.GetAlbumInfo(ctx, albumIds)
 
 			Expect(err).To(BeNil())
 			Expect(albums).To(HaveLen(3))
@@ -284,7 +438,7 @@ var _ = Describe("SpotifyApiClient", func() {
 			})
 
 			It("Checks if individual albums are cached", func() {
-				_, err := client.GetAlbumInfo("access-token", albumIds)
+				_, err := client.GetAlbumInfo(ctx, albumIds)
 				Expect(err).To(BeNil())
 
 				Expect(cache.GetCallCount()).To(Equal(3))
@@ -294,21 +448,22 @@ var _ = Describe("SpotifyApiClient", func() {
 			})
 
 			It("Does not query the Spotify API for cached albums", func() {
-				_, err := client.GetAlbumInfo("access-token", albumIds)
+				_, err := client.GetAlbumInfo(ctx, albumIds)
 
 				Expect(err).To(BeNil())
 				Expect(server.ReceivedRequests()).To(HaveLen(1))
 			})
 
 			It("Returns album data from both the cache and the HTTP API", func() {
-				albums, err := client.GetAlbumInfo("access-token", albumIds)
+				albums, err := client.GetAlbumInfo(ctx, albumIds)
 
 				Expect(err).To(BeNil())
 				Expect(albums).To(HaveLen(4))
+				Expect(client.Stats()).To(Equal(Stats{Requests: 1, CacheHits: 1, CacheMisses: 2}))
 			})
 
 			It("Stores individual albums in the cache", func() {
-				_, err := client.GetAlbumInfo("access-token", albumIds)
+				_, err := client.GetAlbumInfo(ctx, albumIds)
 
 				Expect(err).To(BeNil())
 				Expect(cache.SetCallCount()).To(Equal(3))
@@ -325,7 +480,7 @@ var _ = Describe("SpotifyApiClient", func() {
 					return test_resources.LoadResource("../test_resources/cached_album.json"), nil
 				}
 
-				_, err := client.GetAlbumInfo("access-token", albumIds)
+				_, err := client.GetAlbumInfo(ctx, albumIds)
 
 				Expect(err).To(BeNil())
 				Expect(cache.SetCallCount()).To(Equal(0))
@@ -354,11 +509,11 @@ var _ = Describe("SpotifyApiClient", func() {
 				),
 			)
 
-			client = NewSpotifyApiClient(server.URL(), timeWrapper, cache)
+			client = NewSpotifyApiClient(server.URL(), tokens, timeWrapper, cache, ClientOptions{})
 		})
 
 		It("Calls the HTTP API", func() {
-			profile, err := client.GetUserProfile("access-token")
+			profile, err := client.GetUserProfile(ctx)
 
 			Expect(err).To(BeNil())
 			Expect(profile).To(Equal(model.UserProfile{
@@ -368,6 +523,71 @@ var _ = Describe("SpotifyApiClient", func() {
 
 			Expect(server.ReceivedRequests()).To(HaveLen(1))
 		})
+
+		It("Waits for the rate limiter before each request", func() {
+			server.AppendHandlers(ghttp.RespondWith(200, test_resources.LoadResource("../test_resources/user_profile.json")))
+			client = NewSpotifyApiClient(server.URL(), tokens, timeWrapper, cache, ClientOptions{Limiter: NewRateLimiter(1, 1, timeWrapper)})
+
+			_, err := client.GetUserProfile(ctx)
+			Expect(err).To(BeNil())
+			_, err = client.GetUserProfile(ctx)
+			Expect(err).To(BeNil())
+
+			Expect(timeWrapper.SleepContextCallCount()).To(Equal(1))
+			Expect(sleptFor(timeWrapper, 0)).To(Equal(time.Second))
+		})
+
+		It("Refreshes the access token and retries once after a 401", func() {
+			server.Reset()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/me", ""),
+					ghttp.VerifyHeaderKV("Authorization", "Bearer access-token"),
+					ghttp.RespondWith(401, nil),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/me", ""),
+					ghttp.VerifyHeaderKV("Authorization", "Bearer refreshed-token"),
+					ghttp.RespondWith(200, test_resources.LoadResource("../test_resources/user_profile.json")),
+				),
+			)
+			tokens.RefreshReturns("refreshed-token", nil)
+
+			profile, err := client.GetUserProfile(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(profile.Id).To(Equal("user-id"))
+			Expect(tokens.RefreshCallCount()).To(Equal(1))
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("Gives up if the refreshed token is rejected as well", func() {
+			server.Reset()
+			server.AppendHandlers(
+				ghttp.RespondWith(401, nil),
+				ghttp.RespondWith(401, nil),
+			)
+			tokens.RefreshReturns("refreshed-token", nil)
+
+			_, err := client.GetUserProfile(ctx)
+
+			Expect(err).ToNot(BeNil())
+			Expect(tokens.RefreshCallCount()).To(Equal(1))
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("Returns an error if the token cannot be refreshed", func() {
+			server.Reset()
+			server.AppendHandlers(ghttp.RespondWith(401, nil))
+			tokens.RefreshReturns("", fmt.Errorf("Refresh: %w", &auth.Error{StatusCode: 400, Code: auth.INVALID_GRANT}))
+
+			_, err := client.GetUserProfile(ctx)
+
+			var authErr *auth.Error
+			Expect(errors.As(err, &authErr)).To(BeTrue())
+			Expect(authErr.IsInvalidGrant()).To(BeTrue())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
 	})
 
 	Describe("CreatePlaylist", func() {
@@ -392,11 +612,11 @@ var _ = Describe("SpotifyApiClient", func() {
 				),
 			)
 
-			client = NewSpotifyApiClient(server.URL(), timeWrapper, cache)
+			client = NewSpotifyApiClient(server.URL(), tokens, timeWrapper, cache, ClientOptions{})
 		})
 
 		It("POSTs to the HTTP API", func() {
-			playlistId, err := client.CreatePlaylist("access-token", "user-id", "playlist name")
+			playlistId, err := client.CreatePlaylist(ctx, "user-id", "playlist name")
 
 			Expect(err).To(BeNil())
 			Expect(playlistId).To(Equal("playlist-id"))
@@ -434,7 +654,7 @@ var _ = Describe("SpotifyApiClient", func() {
 				),
 			)
 
-			client = NewSpotifyApiClient(server.URL(), timeWrapper, cache)
+			client = NewSpotifyApiClient(server.URL(), tokens, timeWrapper, cache, ClientOptions{})
 
 			tracks = make([]model.Track, 0, 123)
 			for i := 1; i < 124; i++ {
@@ -445,11 +665,114 @@ var _ = Describe("SpotifyApiClient", func() {
 		})
 
 		It("POSTs to the HTTP API, 100 tracks at a time", func() {
-			err := client.AddTracksToPlaylist("access-token", "user-id", "playlist-id", tracks)
+			err := client.AddTracksToPlaylist(ctx, "user-id", "playlist-id", tracks)
+
+			Expect(err).To(BeNil())
+
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+	})
+
+	Describe("ReplacePlaylistTracks", func() {
+		var server *ghttp.Server
+		var client *SpotifyApiClient
+		var tracks []model.Track
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+
+			expectedBody1 := test_resources.LoadResource("../test_resources/add_tracks_request_1.json")
+			expectedBody2 := test_resources.LoadResource("../test_resources/add_tracks_request_2.json")
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/v1/playlists/playlist-id/tracks", ""),
+					ghttp.VerifyHeaderKV("Authorization", "Bearer access-token"),
+					ghttp.VerifyJSON(string(expectedBody1)),
+					ghttp.RespondWith(201, nil),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/playlists/playlist-id/tracks", ""),
+					ghttp.VerifyHeaderKV("Authorization", "Bearer access-token"),
+					ghttp.VerifyJSON(string(expectedBody2)),
+					ghttp.RespondWith(201, nil),
+				),
+			)
+
+			client = NewSpotifyApiClient(server.URL(), tokens, &platformfakes.FakeTime{}, &cachefakes.FakeCache{}, ClientOptions{})
+
+			tracks = make([]model.Track, 0, 123)
+			for i := 1; i < 124; i++ {
+				tracks = append(tracks, model.Track{
+					Id: "track-" + strconv.Itoa(i),
+				})
+			}
+		})
+
+		It("PUTs the first 100 tracks and POSTs the rest", func() {
+			err := client.ReplacePlaylistTracks(ctx, "playlist-id", tracks)
+
+			Expect(err).To(BeNil())
+
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+	})
+
+	Describe("ChangePlaylistDetails", func() {
+		It("PUTs the new details to the HTTP API", func() {
+			server := ghttp.NewServer()
+
+			expectedBody := test_resources.LoadResource("../test_resources/change_playlist_details_request.json")
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/v1/playlists/playlist-id", ""),
+					ghttp.VerifyHeaderKV("Authorization", "Bearer access-token"),
+					ghttp.VerifyJSON(string(expectedBody)),
+					ghttp.RespondWith(200, nil),
+				),
+			)
+
+			client := NewSpotifyApiClient(server.URL(), tokens, &platformfakes.FakeTime{}, &cachefakes.FakeCache{}, ClientOptions{})
+			err := client.ChangePlaylistDetails(ctx, "playlist-id", "Weekly Releases", "Updated on 2017-01-01")
 
 			Expect(err).To(BeNil())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+
+	Describe("GetUserPlaylists", func() {
+		It("GETs all pages from the HTTP API", func() {
+			server := ghttp.NewServer()
+
+			page1 := test_resources.LoadResource("../test_resources/user_playlists_page1.json")
+			page1 = replaceApiPrefix(page1, server.URL())
+			page2 := test_resources.LoadResource("../test_resources/user_playlists_page2.json")
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/me/playlists", "limit=50"),
+					ghttp.VerifyHeaderKV("Authorization", "Bearer access-token"),
+					ghttp.RespondWith(200, page1),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/me/playlists", "offset=1&limit=1"),
+					ghttp.VerifyHeaderKV("Authorization", "Bearer access-token"),
+					ghttp.RespondWith(200, page2),
+				),
+			)
 
+			client := NewSpotifyApiClient(server.URL(), tokens, &platformfakes.FakeTime{}, &cachefakes.FakeCache{}, ClientOptions{})
+			playlists, err := client.GetUserPlaylists(ctx)
+
+			Expect(err).To(BeNil())
 			Expect(server.ReceivedRequests()).To(HaveLen(2))
+			Expect(playlists).To(HaveLen(2))
+			Expect(playlists[0].OwnerId).NotTo(Equal("user-id"))
+			Expect(playlists[1]).To(Equal(model.Playlist{
+				Id:         "playlist-id",
+				Name:       "Weekly Releases",
+				OwnerId:    "user-id",
+				TrackCount: 42,
+			}))
 		})
 	})
 
@@ -470,7 +793,7 @@ var _ = Describe("SpotifyApiClient", func() {
 						"2GWMZZQNuU0VZra0suXVph",
 						"1eLFONDpKa9ArYaoVjDrKE",
 					},
-					ReleaseDate: "2016-04-15",
+					ReleaseDate: releaseDate("2016-04-15"),
 
 					Tracks: []model.Track{
 						{
@@ -483,10 +806,10 @@ var _ = Describe("SpotifyApiClient", func() {
 					Markets: []string{"AD", "AR"},
 				},
 				{
-					Name:      "Invisible Cinema",
-					Id:        "3xfueIrMUw57owAiYVKt8S",
-					ArtistIds: []string{"22KzEvCtrTGf9l6k7zFcdv"},
-					ReleaseDate: "2008-08-19",
+					Name:        "Invisible Cinema",
+					Id:          "3xfueIrMUw57owAiYVKt8S",
+					ArtistIds:   []string{"22KzEvCtrTGf9l6k7zFcdv"},
+					ReleaseDate: releaseDate("2008-08-19"),
 					Tracks: []model.Track{
 						{
 							Name:       "Travelers",
@@ -498,10 +821,10 @@ var _ = Describe("SpotifyApiClient", func() {
 					Markets: []string{"AD", "AR"},
 				},
 				{
-					Name:      "Senzo",
-					Id:        "2I3odMRAs5aHC69TMt9qAj",
-					ArtistIds: []string{"39mb0I6tdTcCXkeigvzxOJ"},
-					ReleaseDate: "2008-09-26",
+					Name:        "Senzo",
+					Id:          "2I3odMRAs5aHC69TMt9qAj",
+					ArtistIds:   []string{"39mb0I6tdTcCXkeigvzxOJ"},
+					ReleaseDate: releaseDate("2008-09-26"),
 					Tracks: []model.Track{
 						{
 							Name:       "Ocean & The River",
@@ -537,11 +860,11 @@ var _ = Describe("SpotifyApiClient", func() {
 				),
 			)
 
-			client = NewSpotifyApiClient(server.URL(), timeWrapper, cache)
+			client = NewSpotifyApiClient(server.URL(), tokens, timeWrapper, cache, ClientOptions{})
 		})
 
 		It("GETs from the HTTP API", func() {
-			albums, err := client.GetSavedAlbums("access-token")
+			albums, err := client.GetSavedAlbums(ctx)
 
 			Expect(err).To(BeNil())
 			Expect(albums).To(Equal(expectedAlbums))
@@ -549,18 +872,412 @@ var _ = Describe("SpotifyApiClient", func() {
 			Expect(server.ReceivedRequests()).To(HaveLen(2))
 		})
 	})
+
+	Describe("Retries", func() {
+		var server *ghttp.Server
+		var timeWrapper *platformfakes.FakeTime
+		var now time.Time
+		var policy RetryPolicy
+		var newClient func() *SpotifyApiClient
+		var profile []byte
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			now = time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+			timeWrapper = &platformfakes.FakeTime{}
+			timeWrapper.NowStub = func() time.Time {
+				return now
+			}
+			timeWrapper.SleepContextStub = func(_ context.Context, d time.Duration) error {
+				now = now.Add(d)
+				return nil
+			}
+
+			policy = RetryPolicy{
+				MaxAttempts:     4,
+				InitialBackoff:  time.Second,
+				MaxBackoff:      10 * time.Second,
+				Multiplier:      2,
+				RetryableStatus: []int{500, 502, 503},
+			}
+			newClient = func() *SpotifyApiClient {
+				return NewSpotifyApiClient(server.URL(), tokens, timeWrapper, &cachefakes.FakeCache{}, ClientOptions{Retry: &policy})
+			}
+			profile = test_resources.LoadResource("../test_resources/user_profile.json")
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("Retries GETs after 5xx responses with exponential backoff", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(500, ""),
+				ghttp.RespondWith(503, ""),
+				ghttp.RespondWith(200, profile),
+			)
+
+			client := newClient()
+			result, err := client.GetUserProfile(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(result.Id).To(Equal("user-id"))
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+			Expect(client.Stats()).To(Equal(Stats{Requests: 3, Retries: 2}))
+			Expect(timeWrapper.SleepContextCallCount()).To(Equal(2))
+			Expect(sleptFor(timeWrapper, 0)).To(Equal(time.Second))
+			Expect(sleptFor(timeWrapper, 1)).To(Equal(2 * time.Second))
+		})
+
+		It("Retries GETs after network errors", func() {
+			server.AppendHandlers(
+				func(w http.ResponseWriter, r *http.Request) {
+					conn, _, err := w.(http.Hijacker).Hijack()
+					Expect(err).To(BeNil())
+					conn.Close()
+				},
+				ghttp.RespondWith(200, profile),
+			)
+
+			_, err := newClient().GetUserProfile(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+			Expect(timeWrapper.SleepContextCallCount()).To(Equal(1))
+		})
+
+		It("Gives up after the maximum number of attempts", func() {
+			policy.MaxAttempts = 3
+			server.AppendHandlers(
+				ghttp.RespondWith(502, ""),
+				ghttp.RespondWith(502, ""),
+				ghttp.RespondWith(502, ""),
+			)
+
+			_, err := newClient().GetUserProfile(ctx)
+
+			Expect(err).To(MatchError(ContainSubstring("502")))
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+			Expect(timeWrapper.SleepContextCallCount()).To(Equal(2))
+		})
+
+		It("Gives up once the time budget is used up", func() {
+			policy.MaxElapsed = 2 * time.Second
+			server.AppendHandlers(
+				ghttp.RespondWith(503, ""),
+				ghttp.RespondWith(503, ""),
+			)
+
+			_, err := newClient().GetUserProfile(ctx)
+
+			Expect(err).To(MatchError(ContainSubstring("503")))
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+			Expect(timeWrapper.SleepContextCallCount()).To(Equal(1))
+		})
+
+		It("Does not retry other status codes", func() {
+			server.AppendHandlers(ghttp.RespondWith(404, ""))
+
+			_, err := newClient().GetUserProfile(ctx)
+
+			Expect(err).To(MatchError(ContainSubstring("404")))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+			Expect(timeWrapper.SleepContextCallCount()).To(Equal(0))
+		})
+
+		It("Waits until the date given in Retry-After", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(429, "", http.Header{"Retry-After": []string{"Sun, 01 Jan 2017 12:00:05 GMT"}}),
+				ghttp.RespondWith(200, profile),
+			)
+
+			_, err := newClient().GetUserProfile(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+			Expect(timeWrapper.SleepContextCallCount()).To(Equal(1))
+			Expect(sleptFor(timeWrapper, 0)).To(Equal(5 * time.Second))
+		})
+
+		It("Waits at least a second if Retry-After is 0", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(429, "", http.Header{"Retry-After": []string{"0"}}),
+				ghttp.RespondWith(200, profile),
+			)
+
+			_, err := newClient().GetUserProfile(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(timeWrapper.SleepContextCallCount()).To(Equal(1))
+			Expect(sleptFor(timeWrapper, 0)).To(Equal(time.Second))
+		})
+
+		It("Waits at least a second if the date given in Retry-After has passed", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(429, "", http.Header{"Retry-After": []string{"Sun, 01 Jan 2017 11:00:00 GMT"}}),
+				ghttp.RespondWith(200, profile),
+			)
+
+			_, err := newClient().GetUserProfile(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(timeWrapper.SleepContextCallCount()).To(Equal(1))
+			Expect(sleptFor(timeWrapper, 0)).To(Equal(time.Second))
+		})
+
+		It("Limits the number of retries of a request after 429 responses", func() {
+			policy.MaxRateLimitedRetries = 3
+			server.RouteToHandler("GET", "/v1/me", ghttp.RespondWith(429, "", http.Header{"Retry-After": []string{"0"}}))
+
+			_, err := newClient().GetUserProfile(ctx)
+
+			var budgetErr *RetryBudgetError
+			Expect(errors.As(err, &budgetErr)).To(BeTrue())
+			Expect(budgetErr.Limit).To(Equal("MaxRateLimitedRetries"))
+			Expect(budgetErr.Retries).To(Equal(4))
+			Expect(budgetErr.MaxRetries).To(Equal(3))
+			Expect(server.ReceivedRequests()).To(HaveLen(4))
+			Expect(timeWrapper.SleepContextCallCount()).To(Equal(3))
+		})
+
+		It("Does not wait for a Retry-After period longer than the maximum", func() {
+			policy.MaxRetryAfter = 10 * time.Second
+			server.AppendHandlers(
+				ghttp.RespondWith(429, "", http.Header{"Retry-After": []string{"60"}}),
+			)
+
+			_, err := newClient().GetUserProfile(ctx)
+
+			var budgetErr *RetryBudgetError
+			Expect(errors.As(err, &budgetErr)).To(BeTrue())
+			Expect(budgetErr.Limit).To(Equal("MaxRetryAfter"))
+			Expect(budgetErr.Duration).To(Equal(time.Minute))
+			Expect(timeWrapper.SleepContextCallCount()).To(Equal(0))
+		})
+
+		It("Limits the total Retry-After periods of a request", func() {
+			policy.MaxRetryAfterPerRequest = 5 * time.Second
+			server.AppendHandlers(
+				ghttp.RespondWith(429, "", http.Header{"Retry-After": []string{"3"}}),
+				ghttp.RespondWith(429, "", http.Header{"Retry-After": []string{"3"}}),
+			)
+
+			_, err := newClient().GetUserProfile(ctx)
+
+			var budgetErr *RetryBudgetError
+			Expect(errors.As(err, &budgetErr)).To(BeTrue())
+			Expect(budgetErr.Limit).To(Equal("MaxRetryAfterPerRequest"))
+			Expect(budgetErr.Duration).To(Equal(6 * time.Second))
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+			Expect(timeWrapper.SleepContextCallCount()).To(Equal(1))
+		})
+
+		It("Limits the total time the requests of a client are paused", func() {
+			policy.MaxRetryAfterPerClient = 5 * time.Second
+			server.AppendHandlers(
+				ghttp.RespondWith(429, "", http.Header{"Retry-After": []string{"3"}}),
+				ghttp.RespondWith(200, profile),
+				ghttp.RespondWith(429, "", http.Header{"Retry-After": []string{"3"}}),
+			)
+			client := newClient()
+
+			_, err := client.GetUserProfile(ctx)
+			Expect(err).To(BeNil())
+
+			_, err = client.GetUserProfile(ctx)
+			var budgetErr *RetryBudgetError
+			Expect(errors.As(err, &budgetErr)).To(BeTrue())
+			Expect(budgetErr.Limit).To(Equal("MaxRetryAfterPerClient"))
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+		})
+
+		It("Does not retry requests other than GET", func() {
+			server.AppendHandlers(ghttp.RespondWith(503, ""))
+
+			_, err := newClient().CreatePlaylist(ctx, "user-id", "playlist name")
+
+			Expect(err).To(MatchError(ContainSubstring("503")))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+			Expect(timeWrapper.SleepContextCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("Errors", func() {
+		var server *ghttp.Server
+		var client *SpotifyApiClient
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			cache := &cachefakes.FakeCache{}
+			cache.GetEntryReturns(cache2.Entry{}, cache2.ErrNotFound)
+			client = NewSpotifyApiClient(server.URL(), tokens, &platformfakes.FakeTime{}, cache, ClientOptions{
+				Retry: &RetryPolicy{MaxAttempts: 1, RetryableStatus: []int{503}},
+			})
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("Returns the status code and Spotify's error message", func() {
+			server.AppendHandlers(ghttp.RespondWith(404, test_resources.LoadResource("../test_resources/error_response.json")))
+
+			_, err := client.GetArtistAlbums(ctx, "unknown-id", "market-id", []string{"album"})
+
+			var apiErr *Error
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.Method).To(Equal("GET"))
+			Expect(apiErr.Url).To(Equal(server.URL() + "/v1/artists/unknown-id/albums?include_groups=album&limit=50&market=market-id"))
+			Expect(apiErr.StatusCode).To(Equal(404))
+			Expect(apiErr.Message).To(Equal("Non existing id: 'unknown-id'"))
+			Expect(apiErr.Retryable).To(BeFalse())
+			Expect(apiErr.IsNotFound()).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("received 404 for GET"))
+		})
+
+		It("Returns the method of the failed request", func() {
+			server.AppendHandlers(ghttp.RespondWith(403, "Forbidden"))
+
+			_, err := client.CreatePlaylist(ctx, "user-id", "playlist name")
+
+			var apiErr *Error
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.Method).To(Equal("POST"))
+			Expect(apiErr.IsForbidden()).To(BeTrue())
+			Expect(apiErr.Message).To(BeEmpty())
+		})
+
+		It("Marks errors that may go away as retryable", func() {
+			server.AppendHandlers(ghttp.RespondWith(503, ""))
+
+			_, err := client.GetUserProfile(ctx)
+
+			var apiErr *Error
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.Retryable).To(BeTrue())
+		})
+
+		It("Reports an access token that is rejected after refreshing it", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(401, `{"error": {"status": 401, "message": "Invalid access token"}}`),
+				ghttp.RespondWith(401, `{"error": {"status": 401, "message": "Invalid access token"}}`),
+			)
+			tokens.RefreshReturns("refreshed-token", nil)
+
+			_, err := client.GetUserProfile(ctx)
+
+			var apiErr *Error
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.IsUnauthorized()).To(BeTrue())
+			Expect(apiErr.Message).To(Equal("Invalid access token"))
+		})
+	})
+
+	Describe("Sharing a cache between users", func() {
+		var server *ghttp.Server
+		var timeWrapper *platformfakes.FakeTime
+		var sharedCache *cache2.MemoryCache
+		var alice, bob *SpotifyApiClient
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			timeWrapper = &platformfakes.FakeTime{}
+			timeWrapper.NowReturns(time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC))
+			sharedCache = cache2.NewMemoryCache(100, timeWrapper)
+
+			aliceTokens := &apifakes.FakeTokenSource{}
+			aliceTokens.AccessTokenReturns("alice-token", nil)
+			bobTokens := &apifakes.FakeTokenSource{}
+			bobTokens.AccessTokenReturns("bob-token", nil)
+
+			alice = NewSpotifyApiClient(server.URL(), aliceTokens, timeWrapper, sharedCache, ClientOptions{})
+			bob = NewSpotifyApiClient(server.URL(), bobTokens, timeWrapper, sharedCache, ClientOptions{})
+		})
+
+		It("Does not cache user profiles", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("Authorization", "Bearer alice-token"),
+					ghttp.RespondWith(200, `{"id": "alice", "country": "DE"}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("Authorization", "Bearer bob-token"),
+					ghttp.RespondWith(200, `{"id": "bob", "country": "US"}`),
+				),
+			)
+
+			aliceProfile, err := alice.GetUserProfile(ctx)
+			Expect(err).To(BeNil())
+			bobProfile, err := bob.GetUserProfile(ctx)
+			Expect(err).To(BeNil())
+
+			Expect(aliceProfile.Id).To(Equal("alice"))
+			Expect(bobProfile.Id).To(Equal("bob"))
+			Expect(sharedCache.Len()).To(Equal(0))
+		})
+
+		It("Keeps artist albums of different markets apart and shares them within a market", func() {
+			page := replaceApiPrefix(test_resources.LoadResource("../test_resources/artist_albums_page2.json"), server.URL())
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/artists/foo-id/albums", "include_groups=album&limit=50&market=DE"),
+					ghttp.RespondWith(200, page),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/artists/foo-id/albums", "include_groups=album&limit=50&market=US"),
+					ghttp.RespondWith(200, page),
+				),
+			)
+
+			_, err := alice.GetArtistAlbums(ctx, "foo-id", "DE", []string{"album"})
+			Expect(err).To(BeNil())
+			_, err = bob.GetArtistAlbums(ctx, "foo-id", "US", []string{"album"})
+			Expect(err).To(BeNil())
+			_, err = bob.GetArtistAlbums(ctx, "foo-id", "DE", []string{"album"})
+			Expect(err).To(BeNil())
+
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("Shares albums between users", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV("Authorization", "Bearer alice-token"),
+				ghttp.RespondWith(200, test_resources.LoadResource("../test_resources/multiple_albums.json")),
+			))
+
+			aliceAlbums, err := alice.GetAlbumInfo(ctx, []string{"album-id-1"})
+			Expect(err).To(BeNil())
+			bobAlbums, err := bob.GetAlbumInfo(ctx, []string{aliceAlbums[0].Id})
+			Expect(err).To(BeNil())
+
+			Expect(bobAlbums[0].Markets).To(Equal(aliceAlbums[0].Markets))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
 })
 
+func sleptFor(timeWrapper *platformfakes.FakeTime, call int) time.Duration {
+	_, d := timeWrapper.SleepContextArgsForCall(call)
+	return d
+}
+
 func replaceApiPrefix(jsonBytes []byte, apiPrefix string) []byte {
 	return []byte(strings.Replace(string(jsonBytes), "${API_PREFIX}", apiPrefix, 1))
 }
 
 func assertAlbumCached(cache *cachefakes.FakeCache, call int, albumId string) {
-	key1, data1 := cache.SetArgsForCall(call)
+	key1, data1, ttl1 := cache.SetArgsForCall(call)
 	Expect(key1).To(Equal("album:" + albumId))
+	Expect(ttl1).To(Equal(ALBUM_TTL))
 
 	album1 := json.ArtistAlbum{}
 	err := json2.Unmarshal(data1, &album1)
 	Expect(err).To(BeNil())
 	Expect(album1.Id).To(Equal(albumId))
 }
+
+func releaseDate(date string) model.ReleaseDate {
+	parsed, err := model.ParseReleaseDate(date, "")
+	Expect(err).To(BeNil())
+	return parsed
+}
@@ -2,6 +2,7 @@
 package apifakes
 
 import (
+	"context"
 	"sync"
 
 	"github.com/andreasf/spotify-weekly-releases/api"
@@ -9,81 +10,118 @@ import (
 )
 
 type FakeSpotifyConnector struct {
-	AddTracksToPlaylistStub        func(accessToken, userId, playlistId string, tracks []model.Track) error
+	AddTracksToPlaylistStub        func(ctx context.Context, userId, playlistId string, tracks []model.Track) error
 	addTracksToPlaylistMutex       sync.RWMutex
 	addTracksToPlaylistArgsForCall []struct {
-		accessToken string
-		userId      string
-		playlistId  string
-		tracks      []model.Track
+		ctx        context.Context
+		userId     string
+		playlistId string
+		tracks     []model.Track
 	}
 	addTracksToPlaylistReturns struct {
 		result1 error
 	}
-	CreatePlaylistStub        func(accessToken, userId, name string) (string, error)
+	ChangePlaylistDetailsStub        func(ctx context.Context, playlistId, name, description string) error
+	changePlaylistDetailsMutex       sync.RWMutex
+	changePlaylistDetailsArgsForCall []struct {
+		ctx         context.Context
+		playlistId  string
+		name        string
+		description string
+	}
+	changePlaylistDetailsReturns struct {
+		result1 error
+	}
+	CreatePlaylistStub        func(ctx context.Context, userId, name string) (string, error)
 	createPlaylistMutex       sync.RWMutex
 	createPlaylistArgsForCall []struct {
-		accessToken string
-		userId      string
-		name        string
+		ctx    context.Context
+		userId string
+		name   string
 	}
 	createPlaylistReturns struct {
 		result1 string
 		result2 error
 	}
-	GetAlbumInfoStub        func(accessToken string, albumIds []string) ([]model.Album, error)
+	GetAlbumInfoStub        func(ctx context.Context, albumIds []string) ([]model.Album, error)
 	getAlbumInfoMutex       sync.RWMutex
 	getAlbumInfoArgsForCall []struct {
-		accessToken string
-		albumIds    []string
+		ctx      context.Context
+		albumIds []string
 	}
 	getAlbumInfoReturns struct {
 		result1 []model.Album
 		result2 error
 	}
-	GetArtistAlbumsStub        func(accessToken, artistId string, market string) ([]model.Album, error)
+	GetArtistAlbumsStub        func(ctx context.Context, artistId string, market string, albumGroups []string) ([]model.Album, error)
 	getArtistAlbumsMutex       sync.RWMutex
 	getArtistAlbumsArgsForCall []struct {
-		accessToken string
+		ctx         context.Context
 		artistId    string
 		market      string
+		albumGroups []string
 	}
 	getArtistAlbumsReturns struct {
 		result1 []model.Album
 		result2 error
 	}
-	GetFollowedArtistsStub        func(accessToken string) ([]model.Artist, error)
+	GetFollowedArtistsStub        func(ctx context.Context) ([]model.Artist, error)
 	getFollowedArtistsMutex       sync.RWMutex
 	getFollowedArtistsArgsForCall []struct {
-		accessToken string
+		ctx context.Context
 	}
 	getFollowedArtistsReturns struct {
 		result1 []model.Artist
 		result2 error
 	}
-	GetSavedAlbumsStub        func(accessToken string) ([]model.Album, error)
+	GetSavedAlbumsStub        func(ctx context.Context) ([]model.Album, error)
 	getSavedAlbumsMutex       sync.RWMutex
 	getSavedAlbumsArgsForCall []struct {
-		accessToken string
+		ctx context.Context
 	}
 	getSavedAlbumsReturns struct {
 		result1 []model.Album
 		result2 error
 	}
-	GetUserProfileStub        func(accessToken string) (model.UserProfile, error)
+	GetUserPlaylistsStub        func(ctx context.Context) ([]model.Playlist, error)
+	getUserPlaylistsMutex       sync.RWMutex
+	getUserPlaylistsArgsForCall []struct {
+		ctx context.Context
+	}
+	getUserPlaylistsReturns struct {
+		result1 []model.Playlist
+		result2 error
+	}
+	GetUserProfileStub        func(ctx context.Context) (model.UserProfile, error)
 	getUserProfileMutex       sync.RWMutex
 	getUserProfileArgsForCall []struct {
-		accessToken string
+		ctx context.Context
 	}
 	getUserProfileReturns struct {
 		result1 model.UserProfile
 		result2 error
 	}
+	ReplacePlaylistTracksStub        func(ctx context.Context, playlistId string, tracks []model.Track) error
+	replacePlaylistTracksMutex       sync.RWMutex
+	replacePlaylistTracksArgsForCall []struct {
+		ctx        context.Context
+		playlistId string
+		tracks     []model.Track
+	}
+	replacePlaylistTracksReturns struct {
+		result1 error
+	}
+	StatsStub        func() api.Stats
+	statsMutex       sync.RWMutex
+	statsArgsForCall []struct{}
+	statsReturns     struct {
+		result1 api.Stats
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeSpotifyConnector) AddTracksToPlaylist(accessToken string, userId string, playlistId string, tracks []model.Track) error {
+func (fake *FakeSpotifyConnector) AddTracksToPlaylist(ctx context.Context, userId string, playlistId string, tracks []model.Track) error {
 	var tracksCopy []model.Track
 	if tracks != nil {
 		tracksCopy = make([]model.Track, len(tracks))
@@ -91,15 +129,15 @@ func (fake *FakeSpotifyConnector) AddTracksToPlaylist(accessToken string, userId
 	}
 	fake.addTracksToPlaylistMutex.Lock()
 	fake.addTracksToPlaylistArgsForCall = append(fake.addTracksToPlaylistArgsForCall, struct {
-		accessToken string
-		userId      string
-		playlistId  string
-		tracks      []model.Track
-	}{accessToken, userId, playlistId, tracksCopy})
-	fake.recordInvocation("AddTracksToPlaylist", []interface{}{accessToken, userId, playlistId, tracksCopy})
+		ctx        context.Context
+		userId     string
+		playlistId string
+		tracks     []model.Track
+	}{ctx, userId, playlistId, tracksCopy})
+	fake.recordInvocation("AddTracksToPlaylist", []interface{}{ctx, userId, playlistId, tracksCopy})
 	fake.addTracksToPlaylistMutex.Unlock()
 	if fake.AddTracksToPlaylistStub != nil {
-		return fake.AddTracksToPlaylistStub(accessToken, userId, playlistId, tracks)
+		return fake.AddTracksToPlaylistStub(ctx, userId, playlistId, tracks)
 	}
 	return fake.addTracksToPlaylistReturns.result1
 }
@@ -110,10 +148,10 @@ func (fake *FakeSpotifyConnector) AddTracksToPlaylistCallCount() int {
 	return len(fake.addTracksToPlaylistArgsForCall)
 }
 
-func (fake *FakeSpotifyConnector) AddTracksToPlaylistArgsForCall(i int) (string, string, string, []model.Track) {
+func (fake *FakeSpotifyConnector) AddTracksToPlaylistArgsForCall(i int) (context.Context, string, string, []model.Track) {
 	fake.addTracksToPlaylistMutex.RLock()
 	defer fake.addTracksToPlaylistMutex.RUnlock()
-	return fake.addTracksToPlaylistArgsForCall[i].accessToken, fake.addTracksToPlaylistArgsForCall[i].userId, fake.addTracksToPlaylistArgsForCall[i].playlistId, fake.addTracksToPlaylistArgsForCall[i].tracks
+	return fake.addTracksToPlaylistArgsForCall[i].ctx, fake.addTracksToPlaylistArgsForCall[i].userId, fake.addTracksToPlaylistArgsForCall[i].playlistId, fake.addTracksToPlaylistArgsForCall[i].tracks
 }
 
 func (fake *FakeSpotifyConnector) AddTracksToPlaylistReturns(result1 error) {
@@ -123,17 +161,52 @@ func (fake *FakeSpotifyConnector) AddTracksToPlaylistReturns(result1 error) {
 	}{result1}
 }
 
-func (fake *FakeSpotifyConnector) CreatePlaylist(accessToken string, userId string, name string) (string, error) {
+func (fake *FakeSpotifyConnector) ChangePlaylistDetails(ctx context.Context, playlistId string, name string, description string) error {
+	fake.changePlaylistDetailsMutex.Lock()
+	fake.changePlaylistDetailsArgsForCall = append(fake.changePlaylistDetailsArgsForCall, struct {
+		ctx         context.Context
+		playlistId  string
+		name        string
+		description string
+	}{ctx, playlistId, name, description})
+	fake.recordInvocation("ChangePlaylistDetails", []interface{}{ctx, playlistId, name, description})
+	fake.changePlaylistDetailsMutex.Unlock()
+	if fake.ChangePlaylistDetailsStub != nil {
+		return fake.ChangePlaylistDetailsStub(ctx, playlistId, name, description)
+	}
+	return fake.changePlaylistDetailsReturns.result1
+}
+
+func (fake *FakeSpotifyConnector) ChangePlaylistDetailsCallCount() int {
+	fake.changePlaylistDetailsMutex.RLock()
+	defer fake.changePlaylistDetailsMutex.RUnlock()
+	return len(fake.changePlaylistDetailsArgsForCall)
+}
+
+func (fake *FakeSpotifyConnector) ChangePlaylistDetailsArgsForCall(i int) (context.Context, string, string, string) {
+	fake.changePlaylistDetailsMutex.RLock()
+	defer fake.changePlaylistDetailsMutex.RUnlock()
+	return fake.changePlaylistDetailsArgsForCall[i].ctx, fake.changePlaylistDetailsArgsForCall[i].playlistId, fake.changePlaylistDetailsArgsForCall[i].name, fake.changePlaylistDetailsArgsForCall[i].description
+}
+
+func (fake *FakeSpotifyConnector) ChangePlaylistDetailsReturns(result1 error) {
+	fake.ChangePlaylistDetailsStub = nil
+	fake.changePlaylistDetailsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSpotifyConnector) CreatePlaylist(ctx context.Context, userId string, name string) (string, error) {
 	fake.createPlaylistMutex.Lock()
 	fake.createPlaylistArgsForCall = append(fake.createPlaylistArgsForCall, struct {
-		accessToken string
-		userId      string
-		name        string
-	}{accessToken, userId, name})
-	fake.recordInvocation("CreatePlaylist", []interface{}{accessToken, userId, name})
+		ctx    context.Context
+		userId string
+		name   string
+	}{ctx, userId, name})
+	fake.recordInvocation("CreatePlaylist", []interface{}{ctx, userId, name})
 	fake.createPlaylistMutex.Unlock()
 	if fake.CreatePlaylistStub != nil {
-		return fake.CreatePlaylistStub(accessToken, userId, name)
+		return fake.CreatePlaylistStub(ctx, userId, name)
 	}
 	return fake.createPlaylistReturns.result1, fake.createPlaylistReturns.result2
 }
@@ -144,10 +217,10 @@ func (fake *FakeSpotifyConnector) CreatePlaylistCallCount() int {
 	return len(fake.createPlaylistArgsForCall)
 }
 
-func (fake *FakeSpotifyConnector) CreatePlaylistArgsForCall(i int) (string, string, string) {
+func (fake *FakeSpotifyConnector) CreatePlaylistArgsForCall(i int) (context.Context, string, string) {
 	fake.createPlaylistMutex.RLock()
 	defer fake.createPlaylistMutex.RUnlock()
-	return fake.createPlaylistArgsForCall[i].accessToken, fake.createPlaylistArgsForCall[i].userId, fake.createPlaylistArgsForCall[i].name
+	return fake.createPlaylistArgsForCall[i].ctx, fake.createPlaylistArgsForCall[i].userId, fake.createPlaylistArgsForCall[i].name
 }
 
 func (fake *FakeSpotifyConnector) CreatePlaylistReturns(result1 string, result2 error) {
@@ -158,7 +231,7 @@ func (fake *FakeSpotifyConnector) CreatePlaylistReturns(result1 string, result2
 	}{result1, result2}
 }
 
-func (fake *FakeSpotifyConnector) GetAlbumInfo(accessToken string, albumIds []string) ([]model.Album, error) {
+func (fake *FakeSpotifyConnector) GetAlbumInfo(ctx context.Context, albumIds []string) ([]model.Album, error) {
 	var albumIdsCopy []string
 	if albumIds != nil {
 		albumIdsCopy = make([]string, len(albumIds))
@@ -166,13 +239,13 @@ func (fake *FakeSpotifyConnector) GetAlbumInfo(accessToken string, albumIds []st
 	}
 	fake.getAlbumInfoMutex.Lock()
 	fake.getAlbumInfoArgsForCall = append(fake.getAlbumInfoArgsForCall, struct {
-		accessToken string
-		albumIds    []string
-	}{accessToken, albumIdsCopy})
-	fake.recordInvocation("GetAlbumInfo", []interface{}{accessToken, albumIdsCopy})
+		ctx      context.Context
+		albumIds []string
+	}{ctx, albumIdsCopy})
+	fake.recordInvocation("GetAlbumInfo", []interface{}{ctx, albumIdsCopy})
 	fake.getAlbumInfoMutex.Unlock()
 	if fake.GetAlbumInfoStub != nil {
-		return fake.GetAlbumInfoStub(accessToken, albumIds)
+		return fake.GetAlbumInfoStub(ctx, albumIds)
 	}
 	return fake.getAlbumInfoReturns.result1, fake.getAlbumInfoReturns.result2
 }
@@ -183,10 +256,10 @@ func (fake *FakeSpotifyConnector) GetAlbumInfoCallCount() int {
 	return len(fake.getAlbumInfoArgsForCall)
 }
 
-func (fake *FakeSpotifyConnector) GetAlbumInfoArgsForCall(i int) (string, []string) {
+func (fake *FakeSpotifyConnector) GetAlbumInfoArgsForCall(i int) (context.Context, []string) {
 	fake.getAlbumInfoMutex.RLock()
 	defer fake.getAlbumInfoMutex.RUnlock()
-	return fake.getAlbumInfoArgsForCall[i].accessToken, fake.getAlbumInfoArgsForCall[i].albumIds
+	return fake.getAlbumInfoArgsForCall[i].ctx, fake.getAlbumInfoArgsForCall[i].albumIds
 }
 
 func (fake *FakeSpotifyConnector) GetAlbumInfoReturns(result1 []model.Album, result2 error) {
@@ -197,17 +270,23 @@ func (fake *FakeSpotifyConnector) GetAlbumInfoReturns(result1 []model.Album, res
 	}{result1, result2}
 }
 
-func (fake *FakeSpotifyConnector) GetArtistAlbums(accessToken string, artistId string, market string) ([]model.Album, error) {
+func (fake *FakeSpotifyConnector) GetArtistAlbums(ctx context.Context, artistId string, market string, albumGroups []string) ([]model.Album, error) {
+	var albumGroupsCopy []string
+	if albumGroups != nil {
+		albumGroupsCopy = make([]string, len(albumGroups))
+		copy(albumGroupsCopy, albumGroups)
+	}
 	fake.getArtistAlbumsMutex.Lock()
 	fake.getArtistAlbumsArgsForCall = append(fake.getArtistAlbumsArgsForCall, struct {
-		accessToken string
+		ctx         context.Context
 		artistId    string
 		market      string
-	}{accessToken, artistId, market})
-	fake.recordInvocation("GetArtistAlbums", []interface{}{accessToken, artistId, market})
+		albumGroups []string
+	}{ctx, artistId, market, albumGroupsCopy})
+	fake.recordInvocation("GetArtistAlbums", []interface{}{ctx, artistId, market, albumGroupsCopy})
 	fake.getArtistAlbumsMutex.Unlock()
 	if fake.GetArtistAlbumsStub != nil {
-		return fake.GetArtistAlbumsStub(accessToken, artistId, market)
+		return fake.GetArtistAlbumsStub(ctx, artistId, market, albumGroups)
 	}
 	return fake.getArtistAlbumsReturns.result1, fake.getArtistAlbumsReturns.result2
 }
@@ -218,10 +297,10 @@ func (fake *FakeSpotifyConnector) GetArtistAlbumsCallCount() int {
 	return len(fake.getArtistAlbumsArgsForCall)
 }
 
-func (fake *FakeSpotifyConnector) GetArtistAlbumsArgsForCall(i int) (string, string, string) {
+func (fake *FakeSpotifyConnector) GetArtistAlbumsArgsForCall(i int) (context.Context, string, string, []string) {
 	fake.getArtistAlbumsMutex.RLock()
 	defer fake.getArtistAlbumsMutex.RUnlock()
-	return fake.getArtistAlbumsArgsForCall[i].accessToken, fake.getArtistAlbumsArgsForCall[i].artistId, fake.getArtistAlbumsArgsForCall[i].market
+	return fake.getArtistAlbumsArgsForCall[i].ctx, fake.getArtistAlbumsArgsForCall[i].artistId, fake.getArtistAlbumsArgsForCall[i].market, fake.getArtistAlbumsArgsForCall[i].albumGroups
 }
 
 func (fake *FakeSpotifyConnector) GetArtistAlbumsReturns(result1 []model.Album, result2 error) {
@@ -232,15 +311,15 @@ func (fake *FakeSpotifyConnector) GetArtistAlbumsReturns(result1 []model.Album,
 	}{result1, result2}
 }
 
-func (fake *FakeSpotifyConnector) GetFollowedArtists(accessToken string) ([]model.Artist, error) {
+func (fake *FakeSpotifyConnector) GetFollowedArtists(ctx context.Context) ([]model.Artist, error) {
 	fake.getFollowedArtistsMutex.Lock()
 	fake.getFollowedArtistsArgsForCall = append(fake.getFollowedArtistsArgsForCall, struct {
-		accessToken string
-	}{accessToken})
-	fake.recordInvocation("GetFollowedArtists", []interface{}{accessToken})
+		ctx context.Context
+	}{ctx})
+	fake.recordInvocation("GetFollowedArtists", []interface{}{ctx})
 	fake.getFollowedArtistsMutex.Unlock()
 	if fake.GetFollowedArtistsStub != nil {
-		return fake.GetFollowedArtistsStub(accessToken)
+		return fake.GetFollowedArtistsStub(ctx)
 	}
 	return fake.getFollowedArtistsReturns.result1, fake.getFollowedArtistsReturns.result2
 }
@@ -251,10 +330,10 @@ func (fake *FakeSpotifyConnector) GetFollowedArtistsCallCount() int {
 	return len(fake.getFollowedArtistsArgsForCall)
 }
 
-func (fake *FakeSpotifyConnector) GetFollowedArtistsArgsForCall(i int) string {
+func (fake *FakeSpotifyConnector) GetFollowedArtistsArgsForCall(i int) context.Context {
 	fake.getFollowedArtistsMutex.RLock()
 	defer fake.getFollowedArtistsMutex.RUnlock()
-	return fake.getFollowedArtistsArgsForCall[i].accessToken
+	return fake.getFollowedArtistsArgsForCall[i].ctx
 }
 
 func (fake *FakeSpotifyConnector) GetFollowedArtistsReturns(result1 []model.Artist, result2 error) {
@@ -265,15 +344,15 @@ func (fake *FakeSpotifyConnector) GetFollowedArtistsReturns(result1 []model.Arti
 	}{result1, result2}
 }
 
-func (fake *FakeSpotifyConnector) GetSavedAlbums(accessToken string) ([]model.Album, error) {
+func (fake *FakeSpotifyConnector) GetSavedAlbums(ctx context.Context) ([]model.Album, error) {
 	fake.getSavedAlbumsMutex.Lock()
 	fake.getSavedAlbumsArgsForCall = append(fake.getSavedAlbumsArgsForCall, struct {
-		accessToken string
-	}{accessToken})
-	fake.recordInvocation("GetSavedAlbums", []interface{}{accessToken})
+		ctx context.Context
+	}{ctx})
+	fake.recordInvocation("GetSavedAlbums", []interface{}{ctx})
 	fake.getSavedAlbumsMutex.Unlock()
 	if fake.GetSavedAlbumsStub != nil {
-		return fake.GetSavedAlbumsStub(accessToken)
+		return fake.GetSavedAlbumsStub(ctx)
 	}
 	return fake.getSavedAlbumsReturns.result1, fake.getSavedAlbumsReturns.result2
 }
@@ -284,10 +363,10 @@ func (fake *FakeSpotifyConnector) GetSavedAlbumsCallCount() int {
 	return len(fake.getSavedAlbumsArgsForCall)
 }
 
-func (fake *FakeSpotifyConnector) GetSavedAlbumsArgsForCall(i int) string {
+func (fake *FakeSpotifyConnector) GetSavedAlbumsArgsForCall(i int) context.Context {
 	fake.getSavedAlbumsMutex.RLock()
 	defer fake.getSavedAlbumsMutex.RUnlock()
-	return fake.getSavedAlbumsArgsForCall[i].accessToken
+	return fake.getSavedAlbumsArgsForCall[i].ctx
 }
 
 func (fake *FakeSpotifyConnector) GetSavedAlbumsReturns(result1 []model.Album, result2 error) {
@@ -298,15 +377,48 @@ func (fake *FakeSpotifyConnector) GetSavedAlbumsReturns(result1 []model.Album, r
 	}{result1, result2}
 }
 
-func (fake *FakeSpotifyConnector) GetUserProfile(accessToken string) (model.UserProfile, error) {
+func (fake *FakeSpotifyConnector) GetUserPlaylists(ctx context.Context) ([]model.Playlist, error) {
+	fake.getUserPlaylistsMutex.Lock()
+	fake.getUserPlaylistsArgsForCall = append(fake.getUserPlaylistsArgsForCall, struct {
+		ctx context.Context
+	}{ctx})
+	fake.recordInvocation("GetUserPlaylists", []interface{}{ctx})
+	fake.getUserPlaylistsMutex.Unlock()
+	if fake.GetUserPlaylistsStub != nil {
+		return fake.GetUserPlaylistsStub(ctx)
+	}
+	return fake.getUserPlaylistsReturns.result1, fake.getUserPlaylistsReturns.result2
+}
+
+func (fake *FakeSpotifyConnector) GetUserPlaylistsCallCount() int {
+	fake.getUserPlaylistsMutex.RLock()
+	defer fake.getUserPlaylistsMutex.RUnlock()
+	return len(fake.getUserPlaylistsArgsForCall)
+}
+
+func (fake *FakeSpotifyConnector) GetUserPlaylistsArgsForCall(i int) context.Context {
+	fake.getUserPlaylistsMutex.RLock()
+	defer fake.getUserPlaylistsMutex.RUnlock()
+	return fake.getUserPlaylistsArgsForCall[i].ctx
+}
+
+func (fake *FakeSpotifyConnector) GetUserPlaylistsReturns(result1 []model.Playlist, result2 error) {
+	fake.GetUserPlaylistsStub = nil
+	fake.getUserPlaylistsReturns = struct {
+		result1 []model.Playlist
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSpotifyConnector) GetUserProfile(ctx context.Context) (model.UserProfile, error) {
 	fake.getUserProfileMutex.Lock()
 	fake.getUserProfileArgsForCall = append(fake.getUserProfileArgsForCall, struct {
-		accessToken string
-	}{accessToken})
-	fake.recordInvocation("GetUserProfile", []interface{}{accessToken})
+		ctx context.Context
+	}{ctx})
+	fake.recordInvocation("GetUserProfile", []interface{}{ctx})
 	fake.getUserProfileMutex.Unlock()
 	if fake.GetUserProfileStub != nil {
-		return fake.GetUserProfileStub(accessToken)
+		return fake.GetUserProfileStub(ctx)
 	}
 	return fake.getUserProfileReturns.result1, fake.getUserProfileReturns.result2
 }
@@ -317,10 +429,10 @@ func (fake *FakeSpotifyConnector) GetUserProfileCallCount() int {
 	return len(fake.getUserProfileArgsForCall)
 }
 
-func (fake *FakeSpotifyConnector) GetUserProfileArgsForCall(i int) string {
+func (fake *FakeSpotifyConnector) GetUserProfileArgsForCall(i int) context.Context {
 	fake.getUserProfileMutex.RLock()
 	defer fake.getUserProfileMutex.RUnlock()
-	return fake.getUserProfileArgsForCall[i].accessToken
+	return fake.getUserProfileArgsForCall[i].ctx
 }
 
 func (fake *FakeSpotifyConnector) GetUserProfileReturns(result1 model.UserProfile, result2 error) {
@@ -331,11 +443,76 @@ func (fake *FakeSpotifyConnector) GetUserProfileReturns(result1 model.UserProfil
 	}{result1, result2}
 }
 
+func (fake *FakeSpotifyConnector) ReplacePlaylistTracks(ctx context.Context, playlistId string, tracks []model.Track) error {
+	var tracksCopy []model.Track
+	if tracks != nil {
+		tracksCopy = make([]model.Track, len(tracks))
+		copy(tracksCopy, tracks)
+	}
+	fake.replacePlaylistTracksMutex.Lock()
+	fake.replacePlaylistTracksArgsForCall = append(fake.replacePlaylistTracksArgsForCall, struct {
+		ctx        context.Context
+		playlistId string
+		tracks     []model.Track
+	}{ctx, playlistId, tracksCopy})
+	fake.recordInvocation("ReplacePlaylistTracks", []interface{}{ctx, playlistId, tracksCopy})
+	fake.replacePlaylistTracksMutex.Unlock()
+	if fake.ReplacePlaylistTracksStub != nil {
+		return fake.ReplacePlaylistTracksStub(ctx, playlistId, tracks)
+	}
+	return fake.replacePlaylistTracksReturns.result1
+}
+
+func (fake *FakeSpotifyConnector) ReplacePlaylistTracksCallCount() int {
+	fake.replacePlaylistTracksMutex.RLock()
+	defer fake.replacePlaylistTracksMutex.RUnlock()
+	return len(fake.replacePlaylistTracksArgsForCall)
+}
+
+func (fake *FakeSpotifyConnector) ReplacePlaylistTracksArgsForCall(i int) (context.Context, string, []model.Track) {
+	fake.replacePlaylistTracksMutex.RLock()
+	defer fake.replacePlaylistTracksMutex.RUnlock()
+	return fake.replacePlaylistTracksArgsForCall[i].ctx, fake.replacePlaylistTracksArgsForCall[i].playlistId, fake.replacePlaylistTracksArgsForCall[i].tracks
+}
+
+func (fake *FakeSpotifyConnector) ReplacePlaylistTracksReturns(result1 error) {
+	fake.ReplacePlaylistTracksStub = nil
+	fake.replacePlaylistTracksReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSpotifyConnector) Stats() api.Stats {
+	fake.statsMutex.Lock()
+	fake.statsArgsForCall = append(fake.statsArgsForCall, struct{}{})
+	fake.recordInvocation("Stats", []interface{}{})
+	fake.statsMutex.Unlock()
+	if fake.StatsStub != nil {
+		return fake.StatsStub()
+	}
+	return fake.statsReturns.result1
+}
+
+func (fake *FakeSpotifyConnector) StatsCallCount() int {
+	fake.statsMutex.RLock()
+	defer fake.statsMutex.RUnlock()
+	return len(fake.statsArgsForCall)
+}
+
+func (fake *FakeSpotifyConnector) StatsReturns(result1 api.Stats) {
+	fake.StatsStub = nil
+	fake.statsReturns = struct {
+		result1 api.Stats
+	}{result1}
+}
+
 func (fake *FakeSpotifyConnector) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.addTracksToPlaylistMutex.RLock()
 	defer fake.addTracksToPlaylistMutex.RUnlock()
+	fake.changePlaylistDetailsMutex.RLock()
+	defer fake.changePlaylistDetailsMutex.RUnlock()
 	fake.createPlaylistMutex.RLock()
 	defer fake.createPlaylistMutex.RUnlock()
 	fake.getAlbumInfoMutex.RLock()
@@ -346,8 +523,14 @@ func (fake *FakeSpotifyConnector) Invocations() map[string][][]interface{} {
 	defer fake.getFollowedArtistsMutex.RUnlock()
 	fake.getSavedAlbumsMutex.RLock()
 	defer fake.getSavedAlbumsMutex.RUnlock()
+	fake.getUserPlaylistsMutex.RLock()
+	defer fake.getUserPlaylistsMutex.RUnlock()
 	fake.getUserProfileMutex.RLock()
 	defer fake.getUserProfileMutex.RUnlock()
+	fake.replacePlaylistTracksMutex.RLock()
+	defer fake.replacePlaylistTracksMutex.RUnlock()
+	fake.statsMutex.RLock()
+	defer fake.statsMutex.RUnlock()
 	return fake.invocations
 }
 
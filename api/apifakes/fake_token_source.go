@@ -0,0 +1,122 @@
+// This file was generated by counterfeiter
+package apifakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/andreasf/spotify-weekly-releases/api"
+)
+
+type FakeTokenSource struct {
+	AccessTokenStub        func(ctx context.Context) (string, error)
+	accessTokenMutex       sync.RWMutex
+	accessTokenArgsForCall []struct {
+		ctx context.Context
+	}
+	accessTokenReturns struct {
+		result1 string
+		result2 error
+	}
+	RefreshStub        func(ctx context.Context) (string, error)
+	refreshMutex       sync.RWMutex
+	refreshArgsForCall []struct {
+		ctx context.Context
+	}
+	refreshReturns struct {
+		result1 string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeTokenSource) AccessToken(ctx context.Context) (string, error) {
+	fake.accessTokenMutex.Lock()
+	fake.accessTokenArgsForCall = append(fake.accessTokenArgsForCall, struct {
+		ctx context.Context
+	}{ctx})
+	fake.recordInvocation("AccessToken", []interface{}{ctx})
+	fake.accessTokenMutex.Unlock()
+	if fake.AccessTokenStub != nil {
+		return fake.AccessTokenStub(ctx)
+	}
+	return fake.accessTokenReturns.result1, fake.accessTokenReturns.result2
+}
+
+func (fake *FakeTokenSource) AccessTokenCallCount() int {
+	fake.accessTokenMutex.RLock()
+	defer fake.accessTokenMutex.RUnlock()
+	return len(fake.accessTokenArgsForCall)
+}
+
+func (fake *FakeTokenSource) AccessTokenArgsForCall(i int) context.Context {
+	fake.accessTokenMutex.RLock()
+	defer fake.accessTokenMutex.RUnlock()
+	return fake.accessTokenArgsForCall[i].ctx
+}
+
+func (fake *FakeTokenSource) AccessTokenReturns(result1 string, result2 error) {
+	fake.AccessTokenStub = nil
+	fake.accessTokenReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTokenSource) Refresh(ctx context.Context) (string, error) {
+	fake.refreshMutex.Lock()
+	fake.refreshArgsForCall = append(fake.refreshArgsForCall, struct {
+		ctx context.Context
+	}{ctx})
+	fake.recordInvocation("Refresh", []interface{}{ctx})
+	fake.refreshMutex.Unlock()
+	if fake.RefreshStub != nil {
+		return fake.RefreshStub(ctx)
+	}
+	return fake.refreshReturns.result1, fake.refreshReturns.result2
+}
+
+func (fake *FakeTokenSource) RefreshCallCount() int {
+	fake.refreshMutex.RLock()
+	defer fake.refreshMutex.RUnlock()
+	return len(fake.refreshArgsForCall)
+}
+
+func (fake *FakeTokenSource) RefreshArgsForCall(i int) context.Context {
+	fake.refreshMutex.RLock()
+	defer fake.refreshMutex.RUnlock()
+	return fake.refreshArgsForCall[i].ctx
+}
+
+func (fake *FakeTokenSource) RefreshReturns(result1 string, result2 error) {
+	fake.RefreshStub = nil
+	fake.refreshReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTokenSource) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.accessTokenMutex.RLock()
+	defer fake.accessTokenMutex.RUnlock()
+	fake.refreshMutex.RLock()
+	defer fake.refreshMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeTokenSource) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ api.TokenSource = new(FakeTokenSource)
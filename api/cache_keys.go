@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Endpoints below these paths return data of a user. Their responses must
+// never be cached, because the cache is shared between users.
+var userPaths = []string{"/v1/me", "/v1/users"}
+
+// KeyBuilder derives the cache keys of API responses. Only catalogue data,
+// which is the same for every user, is cached, so that a single cache can be
+// shared by all subscribers:
+//
+//   - Responses of per-user endpoints are never cached, neither are responses
+//     for market=from_token, which Spotify resolves to the user's country.
+//   - Keys contain the full query including the market, so that listings of
+//     different markets are kept apart.
+//   - Albums are requested without a market. They contain the markets they
+//     are available in and can be shared between all users.
+type KeyBuilder struct{}
+
+// Key returns the cache key for a GET request of requestUrl, or false if the
+// response must not be cached. Only endpoints known to return catalogue data
+// are cached.
+func (self KeyBuilder) Key(requestUrl string) (string, bool) {
+	parsed, err := url.Parse(requestUrl)
+	if err != nil {
+		return "", false
+	}
+
+	for _, userPath := range userPaths {
+		if parsed.Path == userPath || strings.HasPrefix(parsed.Path, userPath+"/") {
+			return "", false
+		}
+	}
+
+	query := parsed.Query()
+	if query.Get("market") == "from_token" {
+		return "", false
+	}
+
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(segments) == 4 && segments[0] == "v1" && segments[1] == "artists" && segments[3] == "albums" {
+		// Encode sorts the query by parameter name
+		return ARTIST_ALBUMS_CACHE_PREFIX + parsed.Path + "?" + query.Encode(), true
+	}
+
+	return "", false
+}
+
+// Album returns the cache key of an album retrieved without a market.
+func (self KeyBuilder) Album(albumId string) string {
+	return ALBUM_CACHE_PREFIX + albumId
+}
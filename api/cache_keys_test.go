@@ -0,0 +1,58 @@
+package api_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/api"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("KeyBuilder", func() {
+	var keys KeyBuilder
+
+	It("Never caches endpoints of a user", func() {
+		for _, url := range []string{
+			"https://api.spotify.com/v1/me",
+			"https://api.spotify.com/v1/me/following?type=artist&limit=50",
+			"https://api.spotify.com/v1/me/albums",
+			"https://api.spotify.com/v1/users/user-id/playlists",
+		} {
+			_, cacheable := keys.Key(url)
+			Expect(cacheable).To(BeFalse(), url)
+		}
+	})
+
+	It("Never caches responses for the market of the token", func() {
+		_, cacheable := keys.Key("https://api.spotify.com/v1/artists/foo-id/albums?market=from_token")
+
+		Expect(cacheable).To(BeFalse())
+	})
+
+	It("Only caches known catalogue endpoints", func() {
+		_, cacheable := keys.Key("https://api.spotify.com/v1/playlists/playlist-id/tracks")
+
+		Expect(cacheable).To(BeFalse())
+	})
+
+	It("Includes the market in keys of artist albums", func() {
+		de, cacheable := keys.Key("https://api.spotify.com/v1/artists/foo-id/albums?limit=50&market=DE")
+		Expect(cacheable).To(BeTrue())
+
+		us, cacheable := keys.Key("https://api.spotify.com/v1/artists/foo-id/albums?limit=50&market=US")
+		Expect(cacheable).To(BeTrue())
+
+		Expect(de).To(Equal("artist-albums:/v1/artists/foo-id/albums?limit=50&market=DE"))
+		Expect(us).ToNot(Equal(de))
+	})
+
+	It("Does not depend on the order of query parameters", func() {
+		key1, _ := keys.Key("https://api.spotify.com/v1/artists/foo-id/albums?market=DE&limit=50")
+		key2, _ := keys.Key("https://api.spotify.com/v1/artists/foo-id/albums?limit=50&market=DE")
+
+		Expect(key1).To(Equal(key2))
+	})
+
+	It("Uses the same album key for all users", func() {
+		Expect(keys.Album("album-id")).To(Equal("album:album-id"))
+	})
+})
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+const DEFAULT_TIMEOUT time.Duration = 30 * time.Second
+const DEFAULT_USER_AGENT string = "spotify-weekly-releases"
+
+// Middleware wraps the transport of API requests, e.g. to log them or to
+// collect metrics.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an http.RoundTripper implemented by a function, which
+// is handy for writing Middleware.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (self RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return self(req)
+}
+
+// ClientOptions configure a SpotifyApiClient. The zero value is a usable
+// default.
+type ClientOptions struct {
+	// HttpClient performs the requests. It is copied, so that the client can
+	// be shared. Defaults to a new client with DEFAULT_TIMEOUT and
+	// http.DefaultTransport.
+	HttpClient *http.Client
+	// Timeout overrides the timeout of HttpClient if it is not 0.
+	Timeout time.Duration
+	// UserAgent is sent with every request. Defaults to DEFAULT_USER_AGENT.
+	UserAgent string
+	// Middleware wraps the transport of HttpClient. The first middleware sees
+	// each request first.
+	Middleware []Middleware
+	// Limiter paces requests. It may be nil, or shared between clients.
+	Limiter *RateLimiter
+	// Retry is the retry policy of GET requests. Defaults to
+	// DefaultRetryPolicy().
+	Retry *RetryPolicy
+}
+
+func retryPolicy(options ClientOptions) RetryPolicy {
+	if options.Retry == nil {
+		return DefaultRetryPolicy()
+	}
+
+	return *options.Retry
+}
+
+func newHttpClient(options ClientOptions) *http.Client {
+	client := &http.Client{
+		Timeout: DEFAULT_TIMEOUT,
+	}
+	if options.HttpClient != nil {
+		copied := *options.HttpClient
+		client = &copied
+	}
+
+	if options.Timeout > 0 {
+		client.Timeout = options.Timeout
+	}
+
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	userAgent := options.UserAgent
+	if userAgent == "" {
+		userAgent = DEFAULT_USER_AGENT
+	}
+	transport = withUserAgent(userAgent)(transport)
+
+	for i := len(options.Middleware) - 1; i >= 0; i-- {
+		transport = options.Middleware[i](transport)
+	}
+
+	client.Transport = transport
+	return client
+}
+
+func withUserAgent(userAgent string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			// RoundTrippers must not modify the request
+			req = req.Clone(req.Context())
+			req.Header.Set("User-Agent", userAgent)
+			return next.RoundTrip(req)
+		})
+	}
+}
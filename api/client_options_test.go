@@ -0,0 +1,102 @@
+package api_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/api"
+
+	"context"
+	"github.com/andreasf/spotify-weekly-releases/api/apifakes"
+	"github.com/andreasf/spotify-weekly-releases/cache/cachefakes"
+	"github.com/andreasf/spotify-weekly-releases/platform/platformfakes"
+	"github.com/andreasf/spotify-weekly-releases/test_resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"net/http"
+	"time"
+)
+
+var _ = Describe("ClientOptions", func() {
+	var server *ghttp.Server
+	var tokens *apifakes.FakeTokenSource
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		tokens = &apifakes.FakeTokenSource{}
+		tokens.AccessTokenReturns("access-token", nil)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	newClient := func(options ClientOptions) *SpotifyApiClient {
+		return NewSpotifyApiClient(server.URL(), tokens, &platformfakes.FakeTime{}, &cachefakes.FakeCache{}, options)
+	}
+
+	It("Sends the default user agent", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyHeaderKV("User-Agent", DEFAULT_USER_AGENT),
+			ghttp.RespondWith(200, test_resources.LoadResource("../test_resources/user_profile.json")),
+		))
+
+		_, err := newClient(ClientOptions{}).GetUserProfile(context.Background())
+
+		Expect(err).To(BeNil())
+	})
+
+	It("Sends the given user agent", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyHeaderKV("User-Agent", "test-agent/1.0"),
+			ghttp.RespondWith(200, test_resources.LoadResource("../test_resources/user_profile.json")),
+		))
+
+		_, err := newClient(ClientOptions{UserAgent: "test-agent/1.0"}).GetUserProfile(context.Background())
+
+		Expect(err).To(BeNil())
+	})
+
+	It("Passes requests through the middleware in order", func() {
+		server.AppendHandlers(ghttp.RespondWith(200, test_resources.LoadResource("../test_resources/user_profile.json")))
+		calls := []string{}
+		middleware := func(name string) Middleware {
+			return func(next http.RoundTripper) http.RoundTripper {
+				return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					calls = append(calls, name+" "+req.URL.Path)
+					return next.RoundTrip(req)
+				})
+			}
+		}
+
+		_, err := newClient(ClientOptions{
+			Middleware: []Middleware{middleware("outer"), middleware("inner")},
+		}).GetUserProfile(context.Background())
+
+		Expect(err).To(BeNil())
+		Expect(calls).To(Equal([]string{"outer /v1/me", "inner /v1/me"}))
+	})
+
+	It("Uses the transport of the given HTTP client", func() {
+		var seen *http.Request
+		transport := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			seen = req
+			return nil, http.ErrHandlerTimeout
+		})
+
+		_, err := newClient(ClientOptions{HttpClient: &http.Client{Transport: transport}}).GetUserProfile(context.Background())
+
+		Expect(err).ToNot(BeNil())
+		Expect(seen).ToNot(BeNil())
+		Expect(seen.Header.Get("Authorization")).To(Equal("Bearer access-token"))
+		Expect(server.ReceivedRequests()).To(BeEmpty())
+	})
+
+	It("Times out slow requests", func() {
+		server.AppendHandlers(func(w http.ResponseWriter, req *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		})
+
+		_, err := newClient(ClientOptions{Timeout: 50 * time.Millisecond, Retry: &RetryPolicy{MaxAttempts: 1}}).GetUserProfile(context.Background())
+
+		Expect(err).ToNot(BeNil())
+	})
+})
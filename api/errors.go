@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	json2 "github.com/andreasf/spotify-weekly-releases/json"
+	"net/http"
+)
+
+// Error is returned for requests that Spotify answered with an error status.
+// It is wrapped by the errors of the client and of the services, so callers
+// can inspect it with errors.As, e.g. to tell an expired authorization from an
+// unknown artist.
+type Error struct {
+	Method     string
+	Url        string
+	StatusCode int
+	// Message is the message of Spotify's error response, or empty if the
+	// response did not contain one.
+	Message string
+	// Retryable is true for errors that may go away when the request is
+	// repeated later, like server errors and rate limiting.
+	Retryable bool
+}
+
+func (self *Error) Error() string {
+	message := fmt.Sprintf("received %d for %s %s", self.StatusCode, self.Method, self.Url)
+	if self.Message != "" {
+		message += ": " + self.Message
+	}
+
+	return message
+}
+
+// IsUnauthorized returns whether the access token was rejected even after
+// refreshing it, i.e. the user has to log in again.
+func (self *Error) IsUnauthorized() bool {
+	return self.StatusCode == http.StatusUnauthorized
+}
+
+// IsForbidden returns whether the request is not allowed, e.g. because a
+// scope is missing or the application's quota is exceeded.
+func (self *Error) IsForbidden() bool {
+	return self.StatusCode == http.StatusForbidden
+}
+
+func (self *Error) IsNotFound() bool {
+	return self.StatusCode == http.StatusNotFound
+}
+
+// newError creates an Error from a response and its body. Bodies that are
+// not Spotify error objects are ignored.
+func newError(method string, url string, statusCode int, body []byte, retryable bool) *Error {
+	errorResponse := json2.ErrorResponse{}
+	err := json.Unmarshal(body, &errorResponse)
+	if err != nil {
+		errorResponse = json2.ErrorResponse{}
+	}
+
+	return &Error{
+		Method:     method,
+		Url:        url,
+		StatusCode: statusCode,
+		Message:    errorResponse.Error.Message,
+		Retryable:  retryable,
+	}
+}
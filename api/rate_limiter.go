@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"github.com/andreasf/spotify-weekly-releases/platform"
+	"sync"
+	"time"
+)
+
+const DEFAULT_REQUESTS_PER_SECOND float64 = 5
+const DEFAULT_BURST int = 10
+
+// RateLimiter is a token bucket that paces requests to requestsPerSecond on
+// average, allowing up to burst requests at once. It can be shared between
+// clients, so that all of them together stay below the limit. A nil
+// *RateLimiter does not limit at all.
+type RateLimiter struct {
+	requestsPerSecond float64
+	burst             int
+	timeWrapper       platform.Time
+	mutex             sync.Mutex
+	initialized       bool
+	tokens            float64
+	last              time.Time
+}
+
+func NewRateLimiter(requestsPerSecond float64, burst int, timeWrapper platform.Time) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		requestsPerSecond: requestsPerSecond,
+		burst:             burst,
+		timeWrapper:       timeWrapper,
+	}
+}
+
+// Wait blocks until the next request may be made, or returns ctx.Err() if
+// ctx is done first.
+func (self *RateLimiter) Wait(ctx context.Context) error {
+	if self == nil {
+		return ctx.Err()
+	}
+
+	delay := self.reserve()
+	if delay > 0 {
+		return self.timeWrapper.SleepContext(ctx, delay)
+	}
+
+	return ctx.Err()
+}
+
+// reserve takes a token from the bucket and returns how long to wait until
+// the token is actually available. Tokens may be reserved ahead of time, so
+// that concurrent callers are spaced out instead of all waking up at once.
+func (self *RateLimiter) reserve() time.Duration {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if self.requestsPerSecond <= 0 {
+		return 0
+	}
+
+	now := self.timeWrapper.Now()
+	if !self.initialized {
+		self.tokens = float64(self.burst)
+		self.last = now
+		self.initialized = true
+	}
+
+	if now.After(self.last) {
+		self.tokens += now.Sub(self.last).Seconds() * self.requestsPerSecond
+		if self.tokens > float64(self.burst) {
+			self.tokens = float64(self.burst)
+		}
+		self.last = now
+	}
+
+	self.tokens--
+	if self.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-self.tokens / self.requestsPerSecond * float64(time.Second))
+}
@@ -0,0 +1,76 @@
+package api_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/api"
+
+	"context"
+	"github.com/andreasf/spotify-weekly-releases/platform/platformfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("RateLimiter", func() {
+	var timeWrapper *platformfakes.FakeTime
+	var now time.Time
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+		timeWrapper = &platformfakes.FakeTime{}
+		timeWrapper.NowStub = func() time.Time {
+			return now
+		}
+	})
+
+	It("Allows a burst of requests without waiting", func() {
+		limiter := NewRateLimiter(2, 3, timeWrapper)
+
+		Expect(limiter.Wait(ctx)).To(Succeed())
+		Expect(limiter.Wait(ctx)).To(Succeed())
+		Expect(limiter.Wait(ctx)).To(Succeed())
+
+		Expect(timeWrapper.SleepContextCallCount()).To(Equal(0))
+	})
+
+	It("Spaces out requests after the burst", func() {
+		limiter := NewRateLimiter(2, 1, timeWrapper)
+
+		Expect(limiter.Wait(ctx)).To(Succeed())
+		Expect(limiter.Wait(ctx)).To(Succeed())
+		Expect(limiter.Wait(ctx)).To(Succeed())
+
+		Expect(timeWrapper.SleepContextCallCount()).To(Equal(2))
+		Expect(sleptFor(timeWrapper, 0)).To(Equal(500 * time.Millisecond))
+		Expect(sleptFor(timeWrapper, 1)).To(Equal(time.Second))
+	})
+
+	It("Refills the bucket over time, up to the burst", func() {
+		limiter := NewRateLimiter(2, 2, timeWrapper)
+
+		Expect(limiter.Wait(ctx)).To(Succeed())
+		Expect(limiter.Wait(ctx)).To(Succeed())
+		now = now.Add(time.Hour)
+		Expect(limiter.Wait(ctx)).To(Succeed())
+		Expect(limiter.Wait(ctx)).To(Succeed())
+		Expect(timeWrapper.SleepContextCallCount()).To(Equal(0))
+
+		Expect(limiter.Wait(ctx)).To(Succeed())
+		Expect(timeWrapper.SleepContextCallCount()).To(Equal(1))
+		Expect(sleptFor(timeWrapper, 0)).To(Equal(500 * time.Millisecond))
+	})
+
+	It("Does not limit if nil", func() {
+		var limiter *RateLimiter
+		Expect(limiter.Wait(ctx)).To(Succeed())
+	})
+
+	It("Stops waiting when the context is cancelled", func() {
+		limiter := NewRateLimiter(2, 1, timeWrapper)
+		timeWrapper.SleepContextReturns(context.Canceled)
+
+		Expect(limiter.Wait(ctx)).To(Succeed())
+		Expect(limiter.Wait(ctx)).To(Equal(context.Canceled))
+	})
+})
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides how often and after which delay failed GET requests
+// are retried. A request fails if the response has one of RetryableStatus, or
+// if no response is received at all, e.g. because the connection was reset or
+// the request timed out. Other requests are not retried, because they may
+// have taken effect although the response was lost.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first one, so 1
+	// disables retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. It is multiplied by
+	// Multiplier for each further retry, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter randomizes each delay by up to this fraction in either
+	// direction, so that concurrent requests do not retry in lockstep.
+	Jitter float64
+	// RetryableStatus are the status codes that are retried.
+	RetryableStatus []int
+	// MaxElapsed is the time after the first attempt after which a request is
+	// no longer retried, 0 for no limit.
+	MaxElapsed time.Duration
+
+	// Requests answered with 429 Too Many Requests are retried after the
+	// Retry-After period, but at least after MIN_RETRY_AFTER, regardless of
+	// MaxAttempts. They are only retried while the following limits hold, 0
+	// for no limit. Otherwise the request fails with a *RetryBudgetError.
+	//
+	// MaxRateLimitedRetries limits the number of retries of one request after
+	// 429 responses.
+	MaxRateLimitedRetries int
+	// MaxRetryAfter limits a single Retry-After period.
+	MaxRetryAfter time.Duration
+	// MaxRetryAfterPerRequest limits the sum of the Retry-After periods of
+	// one request.
+	MaxRetryAfterPerRequest time.Duration
+	// MaxRetryAfterPerClient limits the time all requests of a client are
+	// paused in total. As a client is created for each run, this is the
+	// budget of a run.
+	MaxRetryAfterPerClient time.Duration
+}
+
+const DEFAULT_MAX_ATTEMPTS int = 4
+const DEFAULT_MAX_RATE_LIMITED_RETRIES int = 10
+
+// MIN_RETRY_AFTER is the wait after 429 responses without a Retry-After
+// period, or with one that is zero or in the past.
+const MIN_RETRY_AFTER time.Duration = time.Second
+
+// Names of the limits in RetryBudgetError.Limit
+const MAX_RATE_LIMITED_RETRIES string = "MaxRateLimitedRetries"
+const MAX_RETRY_AFTER string = "MaxRetryAfter"
+const MAX_RETRY_AFTER_PER_REQUEST string = "MaxRetryAfterPerRequest"
+const MAX_RETRY_AFTER_PER_CLIENT string = "MaxRetryAfterPerClient"
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     DEFAULT_MAX_ATTEMPTS,
+		InitialBackoff:  time.Second,
+		MaxBackoff:      30 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.2,
+		RetryableStatus: []int{500, 502, 503, 504},
+		MaxElapsed:      2 * time.Minute,
+
+		MaxRateLimitedRetries:   DEFAULT_MAX_RATE_LIMITED_RETRIES,
+		MaxRetryAfter:           2 * time.Minute,
+		MaxRetryAfterPerRequest: 5 * time.Minute,
+		MaxRetryAfterPerClient:  30 * time.Minute,
+	}
+}
+
+// Backoff returns the delay before the given retry, counting from 0. random
+// is a number in [0, 1) that selects the jitter.
+func (self RetryPolicy) Backoff(retry int, random float64) time.Duration {
+	multiplier := self.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	d := float64(self.InitialBackoff) * math.Pow(multiplier, float64(retry))
+	if self.MaxBackoff > 0 && d > float64(self.MaxBackoff) {
+		d = float64(self.MaxBackoff)
+	}
+
+	d = d * (1 + self.Jitter*(2*random-1))
+	if d < 0 {
+		return 0
+	}
+
+	return time.Duration(d)
+}
+
+// IsRetryableStatus returns whether responses with statusCode are retried.
+func (self RetryPolicy) IsRetryableStatus(statusCode int) bool {
+	for _, retryable := range self.RetryableStatus {
+		if statusCode == retryable {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RetryBudgetError is returned for a request that was rate limited for longer
+// than the RetryPolicy allows. Callers can tell it apart with errors.As, e.g.
+// to skip the request or to abort the run.
+type RetryBudgetError struct {
+	Url string
+	// Limit names the exceeded limit of the RetryPolicy, e.g.
+	// MAX_RETRY_AFTER. Duration is the Retry-After period or the total that
+	// exceeded it.
+	Limit    string
+	Duration time.Duration
+	Max      time.Duration
+	// Retries and MaxRetries are set instead of Duration and Max if Limit is
+	// MAX_RATE_LIMITED_RETRIES.
+	Retries    int
+	MaxRetries int
+}
+
+func (self *RetryBudgetError) Error() string {
+	if self.Limit == MAX_RATE_LIMITED_RETRIES {
+		return fmt.Sprintf("rate limited %d times on %s, exceeding %s of %d", self.Retries, self.Url, self.Limit, self.MaxRetries)
+	}
+
+	return fmt.Sprintf("rate limited for %s on %s, exceeding %s of %s", self.Duration, self.Url, self.Limit, self.Max)
+}
+
+// checkRateLimitedRetries returns a *RetryBudgetError if the request has been
+// retried after 429 responses too often.
+func (self RetryPolicy) checkRateLimitedRetries(url string, retries int) error {
+	if self.MaxRateLimitedRetries > 0 && retries > self.MaxRateLimitedRetries {
+		return &RetryBudgetError{
+			Url:        url,
+			Limit:      MAX_RATE_LIMITED_RETRIES,
+			Retries:    retries,
+			MaxRetries: self.MaxRateLimitedRetries,
+		}
+	}
+
+	return nil
+}
+
+// checkRetryAfter returns a *RetryBudgetError if retryAfter, or the totals of
+// the request or the client including it, exceed the limits.
+func (self RetryPolicy) checkRetryAfter(url string, retryAfter time.Duration, requestTotal time.Duration, clientTotal time.Duration) error {
+	limits := []struct {
+		name  string
+		value time.Duration
+		max   time.Duration
+	}{
+		{MAX_RETRY_AFTER, retryAfter, self.MaxRetryAfter},
+		{MAX_RETRY_AFTER_PER_REQUEST, requestTotal, self.MaxRetryAfterPerRequest},
+		{MAX_RETRY_AFTER_PER_CLIENT, clientTotal, self.MaxRetryAfterPerClient},
+	}
+
+	for _, limit := range limits {
+		if limit.max > 0 && limit.value > limit.max {
+			return &RetryBudgetError{
+				Url:      url,
+				Limit:    limit.name,
+				Duration: limit.value,
+				Max:      limit.max,
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseRetryAfter parses the Retry-After header, which is either a number of
+// seconds or an HTTP date. It returns MIN_RETRY_AFTER for missing or invalid
+// values, and for periods shorter than that, so that a server answering with
+// 0 cannot make the client retry without waiting.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	retryAfter := MIN_RETRY_AFTER
+
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		retryAfter = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		retryAfter = date.Sub(now)
+	}
+
+	if retryAfter < MIN_RETRY_AFTER {
+		return MIN_RETRY_AFTER
+	}
+
+	return retryAfter
+}
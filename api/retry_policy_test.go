@@ -0,0 +1,46 @@
+package api_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/api"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("RetryPolicy", func() {
+	var policy RetryPolicy
+
+	BeforeEach(func() {
+		policy = RetryPolicy{
+			InitialBackoff: time.Second,
+			MaxBackoff:     5 * time.Second,
+			Multiplier:     2,
+		}
+	})
+
+	It("Backs off exponentially up to the maximum", func() {
+		Expect(policy.Backoff(0, 0.5)).To(Equal(time.Second))
+		Expect(policy.Backoff(1, 0.5)).To(Equal(2 * time.Second))
+		Expect(policy.Backoff(2, 0.5)).To(Equal(4 * time.Second))
+		Expect(policy.Backoff(3, 0.5)).To(Equal(5 * time.Second))
+		Expect(policy.Backoff(10, 0.5)).To(Equal(5 * time.Second))
+	})
+
+	It("Randomizes the backoff by up to the jitter", func() {
+		policy.Jitter = 0.25
+
+		Expect(policy.Backoff(1, 0)).To(Equal(1500 * time.Millisecond))
+		Expect(policy.Backoff(1, 0.5)).To(Equal(2 * time.Second))
+		Expect(policy.Backoff(1, 0.75)).To(Equal(2250 * time.Millisecond))
+	})
+
+	It("Retries server errors by default", func() {
+		policy = DefaultRetryPolicy()
+
+		Expect(policy.IsRetryableStatus(503)).To(BeTrue())
+		Expect(policy.IsRetryableStatus(502)).To(BeTrue())
+		Expect(policy.IsRetryableStatus(404)).To(BeFalse())
+		Expect(policy.IsRetryableStatus(429)).To(BeFalse())
+	})
+})
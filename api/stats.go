@@ -0,0 +1,40 @@
+package api
+
+// Stats count the requests of a client since it was created. Cache hits and
+// misses count cacheable responses and albums, revalidations are cache misses
+// answered with 304 Not Modified.
+type Stats struct {
+	Requests    int `json:"requests"`
+	Retries     int `json:"retries"`
+	RateLimited int `json:"rate_limited"`
+	CacheHits   int `json:"cache_hits"`
+	CacheMisses int `json:"cache_misses"`
+	Revalidated int `json:"revalidated"`
+}
+
+// Sub returns the difference of two snapshots, e.g. the requests of one run
+// of a client that is shared between runs.
+func (self Stats) Sub(earlier Stats) Stats {
+	return Stats{
+		Requests:    self.Requests - earlier.Requests,
+		Retries:     self.Retries - earlier.Retries,
+		RateLimited: self.RateLimited - earlier.RateLimited,
+		CacheHits:   self.CacheHits - earlier.CacheHits,
+		CacheMisses: self.CacheMisses - earlier.CacheMisses,
+		Revalidated: self.Revalidated - earlier.Revalidated,
+	}
+}
+
+func (self *SpotifyApiClient) Stats() Stats {
+	self.statsMutex.Lock()
+	defer self.statsMutex.Unlock()
+
+	return self.stats
+}
+
+func (self *SpotifyApiClient) count(update func(stats *Stats)) {
+	self.statsMutex.Lock()
+	defer self.statsMutex.Unlock()
+
+	update(&self.stats)
+}
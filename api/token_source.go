@@ -0,0 +1,35 @@
+package api
+
+import (
+	"context"
+	"errors"
+)
+
+//go:generate counterfeiter . TokenSource
+
+// TokenSource provides the bearer token for API requests. Refresh is called
+// when the API rejects the current token with 401 Unauthorized.
+type TokenSource interface {
+	AccessToken(ctx context.Context) (string, error)
+	Refresh(ctx context.Context) (string, error)
+}
+
+// StaticTokenSource always returns the same access token. It cannot refresh
+// the token, so requests fail once it expires.
+type StaticTokenSource struct {
+	accessToken string
+}
+
+func NewStaticTokenSource(accessToken string) *StaticTokenSource {
+	return &StaticTokenSource{
+		accessToken: accessToken,
+	}
+}
+
+func (self *StaticTokenSource) AccessToken(ctx context.Context) (string, error) {
+	return self.accessToken, nil
+}
+
+func (self *StaticTokenSource) Refresh(ctx context.Context) (string, error) {
+	return "", errors.New("StaticTokenSource: cannot refresh access token")
+}
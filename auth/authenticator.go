@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andreasf/spotify-weekly-releases/platform"
+	"sync"
+)
+
+// Authenticator hands out valid access tokens. Expired tokens are refreshed
+// with the token client and the result is written back to the token store.
+// It implements api.TokenSource.
+type Authenticator struct {
+	client      *TokenClient
+	store       TokenStore
+	timeWrapper platform.Time
+	mutex       sync.Mutex
+	token       *Token
+}
+
+func NewAuthenticator(client *TokenClient, store TokenStore, timeWrapper platform.Time) *Authenticator {
+	return &Authenticator{
+		client:      client,
+		store:       store,
+		timeWrapper: timeWrapper,
+	}
+}
+
+func (self *Authenticator) AccessToken(ctx context.Context) (string, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	err := self.load()
+	if err != nil {
+		return "", fmt.Errorf("AccessToken: %w", err)
+	}
+
+	if self.token.Valid(self.timeWrapper.Now()) {
+		return self.token.AccessToken, nil
+	}
+
+	return self.refresh(ctx)
+}
+
+// Refresh obtains a new access token even if the current one has not expired
+// yet, e.g. because the API rejected it.
+func (self *Authenticator) Refresh(ctx context.Context) (string, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	err := self.load()
+	if err != nil {
+		return "", fmt.Errorf("Refresh: %w", err)
+	}
+
+	return self.refresh(ctx)
+}
+
+func (self *Authenticator) load() error {
+	if self.token != nil {
+		return nil
+	}
+
+	token, err := self.store.Load()
+	if err != nil {
+		return fmt.Errorf("load: error loading token: %w", err)
+	}
+	self.token = &token
+
+	return nil
+}
+
+func (self *Authenticator) refresh(ctx context.Context) (string, error) {
+	if self.token.RefreshToken == "" {
+		return "", errors.New("refresh: no refresh token available")
+	}
+
+	token, err := self.client.Refresh(ctx, self.token.RefreshToken)
+	if err != nil {
+		return "", fmt.Errorf("refresh: %w", err)
+	}
+	self.token = &token
+
+	err = self.store.Save(token)
+	if err != nil {
+		return "", fmt.Errorf("refresh: error saving token: %w", err)
+	}
+
+	return token.AccessToken, nil
+}
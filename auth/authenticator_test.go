@@ -0,0 +1,134 @@
+package auth_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/auth"
+
+	"context"
+	"errors"
+	"github.com/andreasf/spotify-weekly-releases/auth/authfakes"
+	"github.com/andreasf/spotify-weekly-releases/platform/platformfakes"
+	"github.com/andreasf/spotify-weekly-releases/test_resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"time"
+)
+
+var _ = Describe("Authenticator", func() {
+	var server *ghttp.Server
+	var timeWrapper *platformfakes.FakeTime
+	var store *authfakes.FakeTokenStore
+	var authenticator *Authenticator
+	var now time.Time
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		timeWrapper = &platformfakes.FakeTime{}
+		now = time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+		timeWrapper.NowReturns(now)
+		store = &authfakes.FakeTokenStore{}
+
+		client := NewTokenClient(Config{
+			ClientId: "client-id",
+			TokenUrl: server.URL() + "/api/token",
+		}, timeWrapper)
+		authenticator = NewAuthenticator(client, store, timeWrapper)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("Returns the stored access token while it is valid", func() {
+		store.LoadReturns(Token{
+			AccessToken:  "stored-access-token",
+			RefreshToken: "stored-refresh-token",
+			Expiry:       now.Add(30 * time.Minute),
+		}, nil)
+
+		token, err := authenticator.AccessToken(context.Background())
+		Expect(err).To(BeNil())
+		Expect(token).To(Equal("stored-access-token"))
+
+		token, err = authenticator.AccessToken(context.Background())
+		Expect(err).To(BeNil())
+		Expect(token).To(Equal("stored-access-token"))
+
+		Expect(store.LoadCallCount()).To(Equal(1))
+		Expect(server.ReceivedRequests()).To(HaveLen(0))
+	})
+
+	It("Refreshes and saves expired tokens", func() {
+		store.LoadReturns(Token{
+			AccessToken:  "stored-access-token",
+			RefreshToken: "stored-refresh-token",
+			Expiry:       now.Add(30 * time.Second),
+		}, nil)
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/api/token"),
+				ghttp.RespondWith(200, test_resources.LoadResource("../test_resources/refresh_token_response.json")),
+			),
+		)
+
+		token, err := authenticator.AccessToken(context.Background())
+
+		Expect(err).To(BeNil())
+		Expect(token).To(Equal("refreshed-access-token"))
+		Expect(store.SaveCallCount()).To(Equal(1))
+		Expect(store.SaveArgsForCall(0)).To(Equal(Token{
+			AccessToken:  "refreshed-access-token",
+			RefreshToken: "stored-refresh-token",
+			Expiry:       now.Add(time.Hour),
+		}))
+	})
+
+	It("Refresh always obtains a new token", func() {
+		store.LoadReturns(Token{
+			AccessToken:  "stored-access-token",
+			RefreshToken: "stored-refresh-token",
+			Expiry:       now.Add(30 * time.Minute),
+		}, nil)
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/api/token"),
+				ghttp.RespondWith(200, test_resources.LoadResource("../test_resources/refresh_token_response.json")),
+			),
+		)
+
+		token, err := authenticator.Refresh(context.Background())
+
+		Expect(err).To(BeNil())
+		Expect(token).To(Equal("refreshed-access-token"))
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+		token, err = authenticator.AccessToken(context.Background())
+
+		Expect(err).To(BeNil())
+		Expect(token).To(Equal("refreshed-access-token"))
+	})
+
+	It("Returns an error that tells a revoked refresh token apart", func() {
+		store.LoadReturns(Token{
+			AccessToken:  "stored-access-token",
+			RefreshToken: "revoked-refresh-token",
+			Expiry:       now.Add(-time.Minute),
+		}, nil)
+		server.AppendHandlers(ghttp.RespondWith(400, `{"error": "invalid_grant", "error_description": "Refresh token revoked"}`))
+
+		_, err := authenticator.AccessToken(context.Background())
+
+		var authErr *Error
+		Expect(errors.As(err, &authErr)).To(BeTrue())
+		Expect(authErr.IsInvalidGrant()).To(BeTrue())
+		Expect(store.SaveCallCount()).To(Equal(0))
+	})
+
+	It("Returns an error if no token is stored", func() {
+		store.LoadReturns(Token{}, errors.New("not found"))
+
+		_, err := authenticator.AccessToken(context.Background())
+
+		Expect(err).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,109 @@
+// This file was generated by counterfeiter
+package authfakes
+
+import (
+	"sync"
+
+	"github.com/andreasf/spotify-weekly-releases/auth"
+)
+
+type FakeTokenStore struct {
+	LoadStub        func() (auth.Token, error)
+	loadMutex       sync.RWMutex
+	loadArgsForCall []struct{}
+	loadReturns     struct {
+		result1 auth.Token
+		result2 error
+	}
+	SaveStub        func(token auth.Token) error
+	saveMutex       sync.RWMutex
+	saveArgsForCall []struct {
+		token auth.Token
+	}
+	saveReturns struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeTokenStore) Load() (auth.Token, error) {
+	fake.loadMutex.Lock()
+	fake.loadArgsForCall = append(fake.loadArgsForCall, struct{}{})
+	fake.recordInvocation("Load", []interface{}{})
+	fake.loadMutex.Unlock()
+	if fake.LoadStub != nil {
+		return fake.LoadStub()
+	}
+	return fake.loadReturns.result1, fake.loadReturns.result2
+}
+
+func (fake *FakeTokenStore) LoadCallCount() int {
+	fake.loadMutex.RLock()
+	defer fake.loadMutex.RUnlock()
+	return len(fake.loadArgsForCall)
+}
+
+func (fake *FakeTokenStore) LoadReturns(result1 auth.Token, result2 error) {
+	fake.LoadStub = nil
+	fake.loadReturns = struct {
+		result1 auth.Token
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTokenStore) Save(token auth.Token) error {
+	fake.saveMutex.Lock()
+	fake.saveArgsForCall = append(fake.saveArgsForCall, struct {
+		token auth.Token
+	}{token})
+	fake.recordInvocation("Save", []interface{}{token})
+	fake.saveMutex.Unlock()
+	if fake.SaveStub != nil {
+		return fake.SaveStub(token)
+	}
+	return fake.saveReturns.result1
+}
+
+func (fake *FakeTokenStore) SaveCallCount() int {
+	fake.saveMutex.RLock()
+	defer fake.saveMutex.RUnlock()
+	return len(fake.saveArgsForCall)
+}
+
+func (fake *FakeTokenStore) SaveArgsForCall(i int) auth.Token {
+	fake.saveMutex.RLock()
+	defer fake.saveMutex.RUnlock()
+	return fake.saveArgsForCall[i].token
+}
+
+func (fake *FakeTokenStore) SaveReturns(result1 error) {
+	fake.SaveStub = nil
+	fake.saveReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeTokenStore) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.loadMutex.RLock()
+	defer fake.loadMutex.RUnlock()
+	fake.saveMutex.RLock()
+	defer fake.saveMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeTokenStore) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ auth.TokenStore = new(FakeTokenStore)
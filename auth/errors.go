@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	json2 "github.com/andreasf/spotify-weekly-releases/json"
+)
+
+// OAuth error code of rejected authorization codes and refresh tokens
+const INVALID_GRANT string = "invalid_grant"
+
+// Error is returned for token requests that the token endpoint answered with
+// an error status. It is wrapped by the errors of the TokenClient and the
+// Authenticator, so callers can inspect it with errors.As.
+type Error struct {
+	Url        string
+	StatusCode int
+	// Code is the OAuth error code of the response, e.g. INVALID_GRANT, or
+	// empty if the response did not contain one.
+	Code        string
+	Description string
+}
+
+func (self *Error) Error() string {
+	message := fmt.Sprintf("received %d for POST %s", self.StatusCode, self.Url)
+	if self.Code != "" {
+		message += ": " + self.Code
+	}
+	if self.Description != "" {
+		message += ": " + self.Description
+	}
+
+	return message
+}
+
+// IsInvalidGrant returns whether the refresh token or authorization code was
+// rejected, e.g. because the user revoked access. The user has to log in
+// again.
+func (self *Error) IsInvalidGrant() bool {
+	return self.Code == INVALID_GRANT
+}
+
+// newError creates an Error from a response of the token endpoint and its
+// body. Bodies that are not OAuth error responses are ignored.
+func newError(url string, statusCode int, body []byte) *Error {
+	errorResponse := json2.TokenErrorResponse{}
+	err := json.Unmarshal(body, &errorResponse)
+	if err != nil {
+		errorResponse = json2.TokenErrorResponse{}
+	}
+
+	return &Error{
+		Url:         url,
+		StatusCode:  statusCode,
+		Code:        errorResponse.Error,
+		Description: errorResponse.ErrorDescription,
+	}
+}
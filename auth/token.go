@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"time"
+)
+
+const SPOTIFY_AUTHORIZE_URL string = "https://accounts.spotify.com/authorize"
+const SPOTIFY_TOKEN_URL string = "https://accounts.spotify.com/api/token"
+
+// Tokens are refreshed this long before they actually expire, so that a token
+// handed out does not expire while a request is in flight.
+const EXPIRY_MARGIN time.Duration = time.Minute
+
+// DEFAULT_TIMEOUT is the timeout of token requests if Config.HttpClient is
+// not set.
+const DEFAULT_TIMEOUT time.Duration = 30 * time.Second
+
+var SPOTIFY_SCOPES = []string{
+	"user-follow-read",
+	"user-library-read",
+	// the country of the user profile, which selects the market of releases
+	"user-read-private",
+	"playlist-read-private",
+	"playlist-modify-private",
+}
+
+type Config struct {
+	ClientId     string
+	AuthorizeUrl string
+	TokenUrl     string
+	RedirectUrl  string
+	Scopes       []string
+	// HttpClient performs the token requests. Defaults to a new client with
+	// DEFAULT_TIMEOUT.
+	HttpClient *http.Client
+}
+
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+func (self Token) Valid(now time.Time) bool {
+	return self.AccessToken != "" && now.Add(EXPIRY_MARGIN).Before(self.Expiry)
+}
+
+// NewCodeVerifier returns a random PKCE code verifier (RFC 7636).
+func NewCodeVerifier() (string, error) {
+	return randomString(32)
+}
+
+// CodeChallenge derives the S256 code challenge for the given verifier.
+func CodeChallenge(codeVerifier string) string {
+	digest := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(digest[:])
+}
+
+func NewState() (string, error) {
+	return randomString(16)
+}
+
+func randomString(length int) (string, error) {
+	buf := make([]byte, length)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	json2 "github.com/andreasf/spotify-weekly-releases/json"
+	"github.com/andreasf/spotify-weekly-releases/platform"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type TokenClient struct {
+	config      Config
+	httpClient  *http.Client
+	timeWrapper platform.Time
+}
+
+func NewTokenClient(config Config, timeWrapper platform.Time) *TokenClient {
+	httpClient := config.HttpClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DEFAULT_TIMEOUT}
+	}
+
+	return &TokenClient{
+		config:      config,
+		httpClient:  httpClient,
+		timeWrapper: timeWrapper,
+	}
+}
+
+func (self *TokenClient) AuthorizationUrl(state, codeChallenge string) string {
+	params := url.Values{}
+	params.Set("client_id", self.config.ClientId)
+	params.Set("response_type", "code")
+	params.Set("redirect_uri", self.config.RedirectUrl)
+	params.Set("scope", strings.Join(self.config.Scopes, " "))
+	params.Set("state", state)
+	params.Set("code_challenge_method", "S256")
+	params.Set("code_challenge", codeChallenge)
+
+	return self.config.AuthorizeUrl + "?" + params.Encode()
+}
+
+func (self *TokenClient) ExchangeCode(ctx context.Context, code, codeVerifier string) (Token, error) {
+	params := url.Values{}
+	params.Set("grant_type", "authorization_code")
+	params.Set("code", code)
+	params.Set("redirect_uri", self.config.RedirectUrl)
+	params.Set("client_id", self.config.ClientId)
+	params.Set("code_verifier", codeVerifier)
+
+	token, err := self.requestToken(ctx, params)
+	if err != nil {
+		return Token{}, fmt.Errorf("ExchangeCode: %w", err)
+	}
+
+	return token, nil
+}
+
+func (self *TokenClient) Refresh(ctx context.Context, refreshToken string) (Token, error) {
+	params := url.Values{}
+	params.Set("grant_type", "refresh_token")
+	params.Set("refresh_token", refreshToken)
+	params.Set("client_id", self.config.ClientId)
+
+	token, err := self.requestToken(ctx, params)
+	if err != nil {
+		return Token{}, fmt.Errorf("Refresh: %w", err)
+	}
+
+	// the token endpoint may or may not rotate the refresh token
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+
+	return token, nil
+}
+
+func (self *TokenClient) requestToken(ctx context.Context, params url.Values) (Token, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", self.config.TokenUrl, strings.NewReader(params.Encode()))
+	if err != nil {
+		return Token{}, fmt.Errorf("requestToken: error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := self.httpClient.Do(req)
+	if err != nil {
+		return Token{}, fmt.Errorf("requestToken: error performing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return Token{}, fmt.Errorf("requestToken: error reading response: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		return Token{}, fmt.Errorf("requestToken: %w", newError(self.config.TokenUrl, resp.StatusCode, contents))
+	}
+
+	tokenResponse := json2.TokenResponse{}
+	err = json.Unmarshal(contents, &tokenResponse)
+	if err != nil {
+		return Token{}, fmt.Errorf("requestToken: error deserializing JSON: %v", err)
+	}
+
+	return Token{
+		AccessToken:  tokenResponse.AccessToken,
+		RefreshToken: tokenResponse.RefreshToken,
+		Expiry:       self.timeWrapper.Now().Add(time.Duration(tokenResponse.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// Authorize runs the authorization code flow with PKCE. It listens on the
+// loopback address of the configured redirect URL, passes the authorization
+// URL to prompt (which should open it in a browser or show it to the user)
+// and exchanges the code once the authorization server redirects back. It
+// gives up waiting when ctx is done.
+func (self *TokenClient) Authorize(ctx context.Context, prompt func(authorizationUrl string)) (Token, error) {
+	redirectUrl, err := url.Parse(self.config.RedirectUrl)
+	if err != nil {
+		return Token{}, fmt.Errorf("Authorize: invalid redirect URL: %v", err)
+	}
+
+	codeVerifier, err := NewCodeVerifier()
+	if err != nil {
+		return Token{}, fmt.Errorf("Authorize: error creating code verifier: %v", err)
+	}
+
+	state, err := NewState()
+	if err != nil {
+		return Token{}, fmt.Errorf("Authorize: error creating state: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", redirectUrl.Host)
+	if err != nil {
+		return Token{}, fmt.Errorf("Authorize: error listening on %s: %v", redirectUrl.Host, err)
+	}
+	defer listener.Close()
+
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(redirectUrl.Path, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		if query.Get("state") != state {
+			http.Error(w, "Invalid state", http.StatusBadRequest)
+			return
+		}
+
+		if query.Get("error") != "" {
+			http.Error(w, "Authorization failed: "+query.Get("error"), http.StatusForbidden)
+			sendError(errs, fmt.Errorf("Authorize: authorization failed: %s", query.Get("error")))
+			return
+		}
+
+		code := query.Get("code")
+		if code == "" {
+			http.Error(w, "Missing code", http.StatusBadRequest)
+			return
+		}
+
+		fmt.Fprintln(w, "Authorization successful. You can close this window.")
+		select {
+		case codes <- code:
+		default:
+		}
+	})
+
+	go func() {
+		err := http.Serve(listener, mux)
+		if err != nil && !isClosedListenerError(err) {
+			sendError(errs, fmt.Errorf("Authorize: error serving redirect: %v", err))
+		}
+	}()
+
+	prompt(self.AuthorizationUrl(state, CodeChallenge(codeVerifier)))
+
+	select {
+	case code := <-codes:
+		return self.ExchangeCode(ctx, code, codeVerifier)
+	case err := <-errs:
+		return Token{}, err
+	case <-ctx.Done():
+		return Token{}, fmt.Errorf("Authorize: %w", ctx.Err())
+	}
+}
+
+func sendError(errs chan error, err error) {
+	select {
+	case errs <- err:
+	default:
+		log.Printf("Authorize: %v", err)
+	}
+}
+
+func isClosedListenerError(err error) bool {
+	return strings.Contains(err.Error(), "use of closed network connection")
+}
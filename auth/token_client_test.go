@@ -0,0 +1,220 @@
+package auth_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/auth"
+
+	"context"
+	"errors"
+	"github.com/andreasf/spotify-weekly-releases/platform/platformfakes"
+	"github.com/andreasf/spotify-weekly-releases/test_resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var _ = Describe("TokenClient", func() {
+	var server *ghttp.Server
+	var timeWrapper *platformfakes.FakeTime
+	var config Config
+	var client *TokenClient
+	var now time.Time
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		timeWrapper = &platformfakes.FakeTime{}
+		now = time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+		timeWrapper.NowReturns(now)
+
+		config = Config{
+			ClientId:     "client-id",
+			AuthorizeUrl: server.URL() + "/authorize",
+			TokenUrl:     server.URL() + "/api/token",
+			RedirectUrl:  "http://" + freeLoopbackAddress() + "/callback",
+			Scopes:       []string{"scope-1", "scope-2"},
+		}
+		client = NewTokenClient(config, timeWrapper)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("AuthorizationUrl", func() {
+		It("Includes the client id, redirect URL, scopes and PKCE challenge", func() {
+			authUrl, err := url.Parse(client.AuthorizationUrl("state-value", "challenge-value"))
+			Expect(err).To(BeNil())
+
+			Expect(authUrl.Path).To(Equal("/authorize"))
+			query := authUrl.Query()
+			Expect(query.Get("client_id")).To(Equal("client-id"))
+			Expect(query.Get("response_type")).To(Equal("code"))
+			Expect(query.Get("redirect_uri")).To(Equal(config.RedirectUrl))
+			Expect(query.Get("scope")).To(Equal("scope-1 scope-2"))
+			Expect(query.Get("state")).To(Equal("state-value"))
+			Expect(query.Get("code_challenge_method")).To(Equal("S256"))
+			Expect(query.Get("code_challenge")).To(Equal("challenge-value"))
+		})
+	})
+
+	Describe("CodeChallenge", func() {
+		It("Computes the S256 challenge from RFC 7636", func() {
+			verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+			Expect(CodeChallenge(verifier)).To(Equal("E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"))
+		})
+	})
+
+	Describe("ExchangeCode", func() {
+		It("POSTs the code and verifier to the token endpoint", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/token"),
+					ghttp.VerifyForm(url.Values{
+						"grant_type":    []string{"authorization_code"},
+						"code":          []string{"the-code"},
+						"redirect_uri":  []string{config.RedirectUrl},
+						"client_id":     []string{"client-id"},
+						"code_verifier": []string{"the-verifier"},
+					}),
+					ghttp.RespondWith(200, test_resources.LoadResource("../test_resources/token_response.json")),
+				),
+			)
+
+			token, err := client.ExchangeCode(context.Background(), "the-code", "the-verifier")
+
+			Expect(err).To(BeNil())
+			Expect(token).To(Equal(Token{
+				AccessToken:  "new-access-token",
+				RefreshToken: "new-refresh-token",
+				Expiry:       now.Add(time.Hour),
+			}))
+		})
+
+		It("Returns an error if the token endpoint rejects the request", func() {
+			server.AppendHandlers(ghttp.RespondWith(400, `{"error": "invalid_grant", "error_description": "Invalid authorization code"}`))
+
+			_, err := client.ExchangeCode(context.Background(), "the-code", "the-verifier")
+
+			Expect(err).ToNot(BeNil())
+			Expect(err.Error()).To(ContainSubstring("invalid_grant"))
+
+			var authErr *Error
+			Expect(errors.As(err, &authErr)).To(BeTrue())
+			Expect(authErr.StatusCode).To(Equal(400))
+			Expect(authErr.Code).To(Equal("invalid_grant"))
+			Expect(authErr.Description).To(Equal("Invalid authorization code"))
+			Expect(authErr.IsInvalidGrant()).To(BeTrue())
+		})
+
+		It("Gives up after the timeout of the configured HTTP client", func() {
+			config.HttpClient = &http.Client{Timeout: 50 * time.Millisecond}
+			client = NewTokenClient(config, timeWrapper)
+			server.AppendHandlers(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(500 * time.Millisecond)
+			})
+
+			_, err := client.ExchangeCode(context.Background(), "the-code", "the-verifier")
+
+			var netErr net.Error
+			Expect(errors.As(err, &netErr)).To(BeTrue())
+			Expect(netErr.Timeout()).To(BeTrue())
+		})
+	})
+
+	Describe("Refresh", func() {
+		It("Keeps the old refresh token if no new one is returned", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/token"),
+					ghttp.VerifyForm(url.Values{
+						"grant_type":    []string{"refresh_token"},
+						"refresh_token": []string{"old-refresh-token"},
+						"client_id":     []string{"client-id"},
+					}),
+					ghttp.RespondWith(200, test_resources.LoadResource("../test_resources/refresh_token_response.json")),
+				),
+			)
+
+			token, err := client.Refresh(context.Background(), "old-refresh-token")
+
+			Expect(err).To(BeNil())
+			Expect(token).To(Equal(Token{
+				AccessToken:  "refreshed-access-token",
+				RefreshToken: "old-refresh-token",
+				Expiry:       now.Add(time.Hour),
+			}))
+		})
+	})
+
+	Describe("Authorize", func() {
+		It("Receives the code on the loopback redirect URL and exchanges it", func() {
+			var challenge string
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/token"),
+					func(w http.ResponseWriter, r *http.Request) {
+						Expect(r.ParseForm()).To(Succeed())
+						Expect(r.PostForm.Get("code")).To(Equal("loopback-code"))
+						Expect(CodeChallenge(r.PostForm.Get("code_verifier"))).To(Equal(challenge))
+					},
+					ghttp.RespondWith(200, test_resources.LoadResource("../test_resources/token_response.json")),
+				),
+			)
+
+			token, err := client.Authorize(context.Background(), func(authorizationUrl string) {
+				authUrl, err := url.Parse(authorizationUrl)
+				Expect(err).To(BeNil())
+				challenge = authUrl.Query().Get("code_challenge")
+
+				redirect := config.RedirectUrl + "?code=loopback-code&state=" + url.QueryEscape(authUrl.Query().Get("state"))
+				go func() {
+					defer GinkgoRecover()
+					resp, err := http.Get(redirect)
+					Expect(err).To(BeNil())
+					Expect(resp.StatusCode).To(Equal(200))
+				}()
+			})
+
+			Expect(err).To(BeNil())
+			Expect(token.AccessToken).To(Equal("new-access-token"))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("Returns an error if the user denies access", func() {
+			_, err := client.Authorize(context.Background(), func(authorizationUrl string) {
+				authUrl, err := url.Parse(authorizationUrl)
+				Expect(err).To(BeNil())
+
+				redirect := config.RedirectUrl + "?error=access_denied&state=" + url.QueryEscape(authUrl.Query().Get("state"))
+				go http.Get(redirect)
+			})
+
+			Expect(err).ToNot(BeNil())
+			Expect(err.Error()).To(ContainSubstring("access_denied"))
+			Expect(server.ReceivedRequests()).To(HaveLen(0))
+		})
+
+		It("Stops waiting for the redirect when the context is cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+
+			_, err := client.Authorize(ctx, func(authorizationUrl string) {
+				cancel()
+			})
+
+			Expect(err).ToNot(BeNil())
+			Expect(err.Error()).To(ContainSubstring("context canceled"))
+			Expect(server.ReceivedRequests()).To(HaveLen(0))
+		})
+	})
+})
+
+func freeLoopbackAddress() string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).To(BeNil())
+	defer listener.Close()
+
+	return listener.Addr().String()
+}
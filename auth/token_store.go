@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"encoding/json"
+	"github.com/andreasf/spotify-weekly-releases/platform"
+	"io/ioutil"
+)
+
+//go:generate counterfeiter . TokenStore
+type TokenStore interface {
+	Load() (Token, error)
+	Save(token Token) error
+}
+
+type FileTokenStore struct {
+	path string
+}
+
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{
+		path: path,
+	}
+}
+
+func (self *FileTokenStore) Load() (Token, error) {
+	contents, err := ioutil.ReadFile(self.path)
+	if err != nil {
+		return Token{}, err
+	}
+
+	token := Token{}
+	err = json.Unmarshal(contents, &token)
+	if err != nil {
+		return Token{}, err
+	}
+
+	return token, nil
+}
+
+func (self *FileTokenStore) Save(token Token) error {
+	contents, err := json.Marshal(&token)
+	if err != nil {
+		return err
+	}
+
+	return platform.WriteFileAtomically(self.path, contents, 0600)
+}
@@ -0,0 +1,261 @@
+package cache_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/cache"
+
+	"bytes"
+	"fmt"
+	"github.com/andreasf/spotify-weekly-releases/platform"
+	"github.com/andreasf/spotify-weekly-releases/platform/platformfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"sync"
+	"time"
+)
+
+// cacheBehaviour describes what every Cache implementation has to do. It is
+// called from the Describe block of each implementation.
+func cacheBehaviour(newCache func(timeWrapper platform.Time) Cache) {
+	var timeWrapper *platformfakes.FakeTime
+	var now time.Time
+	var cache Cache
+
+	BeforeEach(func() {
+		now = time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+		timeWrapper = &platformfakes.FakeTime{}
+		timeWrapper.NowStub = func() time.Time {
+			return now
+		}
+
+		cache = newCache(timeWrapper)
+	})
+
+	It("Can retrieve data again", func() {
+		Expect(cache.Set("foo", []byte("bar"), 0)).To(Succeed())
+		Expect(cache.Set("bar", []byte("baz"), 0)).To(Succeed())
+		Expect(cache.Set("baz", []byte("foo"), 0)).To(Succeed())
+
+		Expect(cache.Get("foo")).To(Equal([]byte("bar")))
+		Expect(cache.Get("bar")).To(Equal([]byte("baz")))
+		Expect(cache.Get("baz")).To(Equal([]byte("foo")))
+	})
+
+	It("Replaces entries", func() {
+		Expect(cache.Set("foo", []byte("bar"), 0)).To(Succeed())
+		Expect(cache.Set("foo", []byte("baz"), 0)).To(Succeed())
+
+		Expect(cache.Get("foo")).To(Equal([]byte("baz")))
+	})
+
+	It("Returns an error for unknown keys", func() {
+		data, err := cache.Get("foo")
+		Expect(err).ToNot(BeNil())
+		Expect(data).To(BeNil())
+
+		_, err = cache.GetEntry("foo")
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("Can delete entries", func() {
+		Expect(cache.Set("foo", []byte("bar"), 0)).To(Succeed())
+		Expect(cache.Get("foo")).To(Equal([]byte("bar")))
+
+		Expect(cache.Delete("foo")).To(Succeed())
+
+		data, err := cache.Get("foo")
+		Expect(err).ToNot(BeNil())
+		Expect(data).To(BeNil())
+	})
+
+	It("Treats entries as expired once their TTL has passed", func() {
+		Expect(cache.Set("foo", []byte("bar"), time.Hour)).To(Succeed())
+		Expect(cache.Set("baz", []byte("qux"), 0)).To(Succeed())
+
+		now = now.Add(59 * time.Minute)
+		Expect(cache.Get("foo")).To(Equal([]byte("bar")))
+
+		now = now.Add(time.Minute)
+		_, err := cache.Get("foo")
+		Expect(err).To(Equal(ErrExpired))
+
+		now = now.Add(24 * 365 * time.Hour)
+		Expect(cache.Get("baz")).To(Equal([]byte("qux")))
+	})
+
+	It("Stores the ETag and returns expired entries from GetEntry", func() {
+		entry := Entry{
+			Data:      []byte("bar"),
+			ETag:      `"etag"`,
+			ExpiresAt: now.Add(time.Hour),
+		}
+		Expect(cache.SetEntry("foo", entry)).To(Succeed())
+
+		now = now.Add(2 * time.Hour)
+
+		_, err := cache.Get("foo")
+		Expect(err).To(Equal(ErrExpired))
+
+		actual, err := cache.GetEntry("foo")
+		Expect(err).To(BeNil())
+		Expect(actual.Data).To(Equal(entry.Data))
+		Expect(actual.ETag).To(Equal(entry.ETag))
+		Expect(actual.ExpiresAt.Equal(entry.ExpiresAt)).To(BeTrue())
+		Expect(actual.Fresh(now)).To(BeFalse())
+	})
+
+	It("Never returns partially written entries under concurrent use", func() {
+		const goroutines = 16
+		const iterations = 25
+		const size = 64 * 1024
+
+		wg := sync.WaitGroup{}
+
+		for i := 0; i < goroutines; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				value := bytes.Repeat([]byte{byte('a' + i)}, size)
+				ownKey := fmt.Sprintf("own:%d", i)
+
+				for j := 0; j < iterations; j++ {
+					Expect(cache.Set("shared", value, 0)).To(Succeed())
+					Expect(cache.Set(ownKey, value, 0)).To(Succeed())
+
+					shared, err := cache.Get("shared")
+					Expect(err).To(BeNil())
+					Expect(shared).To(HaveLen(size))
+					Expect(bytes.Count(shared, shared[:1])).To(Equal(size))
+
+					Expect(cache.Get(ownKey)).To(Equal(value))
+
+					if maintainer, ok := cache.(Maintainer); ok && j%5 == 0 {
+						_, err = maintainer.Stats()
+						Expect(err).To(BeNil())
+					}
+				}
+			}(i)
+		}
+
+		wg.Wait()
+	})
+}
+
+// maintainerBehaviour describes what caches that implement Maintainer have to
+// do in addition to cacheBehaviour.
+func maintainerBehaviour(newCache func(timeWrapper platform.Time) Cache) {
+	var timeWrapper *platformfakes.FakeTime
+	var now time.Time
+	var cache Cache
+	var maintainer Maintainer
+
+	BeforeEach(func() {
+		now = time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+		timeWrapper = &platformfakes.FakeTime{}
+		timeWrapper.NowStub = func() time.Time {
+			return now
+		}
+
+		cache = newCache(timeWrapper)
+		maintainer = cache.(Maintainer)
+	})
+
+	setAt := func(writtenAt time.Time, key string, data string) {
+		now = writtenAt
+		Expect(cache.Set(key, []byte(data), 0)).To(Succeed())
+	}
+
+	sizeOf := func(key string, data string) int64 {
+		Expect(cache.Set(key, []byte(data), 0)).To(Succeed())
+		stats, err := maintainer.Stats()
+		Expect(err).To(BeNil())
+		Expect(maintainer.Clear()).To(Equal(1))
+		return stats[0].Size
+	}
+
+	It("Reports the number and size of entries per namespace", func() {
+		Expect(cache.Set("album:1", []byte("one"), 0)).To(Succeed())
+		Expect(cache.Set("album:2", []byte("two"), 0)).To(Succeed())
+		Expect(cache.Set("artist-albums:1", []byte("three"), time.Hour)).To(Succeed())
+
+		stats, err := maintainer.Stats()
+
+		Expect(err).To(BeNil())
+		Expect(stats).To(HaveLen(2))
+		Expect(stats[0].Namespace).To(Equal("album"))
+		Expect(stats[0].Entries).To(Equal(2))
+		Expect(stats[1].Namespace).To(Equal("artist-albums"))
+		Expect(stats[1].Entries).To(Equal(1))
+		Expect(stats[0].Size).To(BeNumerically(">", len("onetwo")))
+	})
+
+	It("Reports no entries for an empty cache", func() {
+		stats, err := maintainer.Stats()
+
+		Expect(err).To(BeNil())
+		Expect(stats).To(BeEmpty())
+	})
+
+	It("Prunes entries older than the maximum age", func() {
+		start := time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+		setAt(start, "album:old", "old")
+		setAt(start.Add(2*time.Hour), "album:new", "new")
+
+		now = start.Add(3 * time.Hour)
+		removed, err := maintainer.Prune(2*time.Hour, 0)
+
+		Expect(err).To(BeNil())
+		Expect(removed).To(Equal(1))
+
+		_, err = cache.Get("album:old")
+		Expect(err).ToNot(BeNil())
+		Expect(cache.Get("album:new")).To(Equal([]byte("new")))
+	})
+
+	It("Prunes the oldest entries until the cache fits the size budget", func() {
+		entrySize := sizeOf("album:1", "one")
+
+		start := time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+		setAt(start.Add(time.Minute), "album:2", "two")
+		setAt(start, "album:1", "one")
+		setAt(start.Add(2*time.Minute), "album:3", "thr")
+
+		removed, err := maintainer.Prune(0, 2*entrySize)
+
+		Expect(err).To(BeNil())
+		Expect(removed).To(Equal(1))
+
+		_, err = cache.Get("album:1")
+		Expect(err).ToNot(BeNil())
+		Expect(cache.Get("album:2")).To(Equal([]byte("two")))
+		Expect(cache.Get("album:3")).To(Equal([]byte("thr")))
+	})
+
+	It("Clears only the given namespaces", func() {
+		Expect(cache.Set("album:1", []byte("one"), 0)).To(Succeed())
+		Expect(cache.Set("artist-albums:1", []byte("two"), 0)).To(Succeed())
+
+		removed, err := maintainer.Clear("artist-albums")
+
+		Expect(err).To(BeNil())
+		Expect(removed).To(Equal(1))
+		Expect(cache.Get("album:1")).To(Equal([]byte("one")))
+		_, err = cache.Get("artist-albums:1")
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("Clears everything if no namespace is given", func() {
+		Expect(cache.Set("album:1", []byte("one"), 0)).To(Succeed())
+		Expect(cache.Set("artist-albums:1", []byte("two"), 0)).To(Succeed())
+
+		removed, err := maintainer.Clear()
+
+		Expect(err).To(BeNil())
+		Expect(removed).To(Equal(2))
+
+		stats, err := maintainer.Stats()
+		Expect(err).To(BeNil())
+		Expect(stats).To(BeEmpty())
+	})
+}
@@ -1,62 +1,175 @@
 package cache
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/andreasf/spotify-weekly-releases/platform"
 	"io/ioutil"
 	"os"
 	"path"
+	"sync"
+	"time"
 )
 
+// ErrExpired is returned by Get for entries whose TTL has passed. Callers
+// should treat it like any other miss.
+var ErrExpired = errors.New("cache: entry expired")
+
 //go:generate counterfeiter . Cache
+
+// Cache stores data by key. Entries set with a TTL of 0 never expire.
+//
+// GetEntry and SetEntry give access to the metadata stored with the data.
+// Unlike Get, GetEntry also returns expired entries, so that they can be
+// revalidated.
 type Cache interface {
-	Set(key string, data []byte) error
+	Set(key string, data []byte, ttl time.Duration) error
 	Get(key string) ([]byte, error)
+	SetEntry(key string, entry Entry) error
+	GetEntry(key string) (Entry, error)
 	Delete(key string) error
 }
 
+type Entry struct {
+	Data []byte
+	// ETag is the entity tag of the HTTP response the data came from, if any.
+	ETag string
+	// ExpiresAt is the zero time for entries that never expire.
+	ExpiresAt time.Time
+}
+
+func (self Entry) Fresh(now time.Time) bool {
+	return self.ExpiresAt.IsZero() || now.Before(self.ExpiresAt)
+}
+
+// Each DiskCache file starts with a header line, which is headerPrefix
+// followed by the JSON encoded entryHeader. Files without a header were
+// written by earlier versions and are treated as expired.
+var headerPrefix = []byte("#cache ")
+
+// The key and the time of writing are only recorded for maintenance, see
+// Stats, Prune and Clear.
+type entryHeader struct {
+	Key       string    `json:"key"`
+	WrittenAt time.Time `json:"written_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	ETag      string    `json:"etag,omitempty"`
+}
+
+// Writes go to a temporary file in the entry's directory first, which is then
+// renamed to the entry's file. Readers, including other processes, therefore
+// never see partially written entries.
+const tempFilePrefix string = platform.TEMP_FILE_PREFIX
+
+// Writes and deletes of the same key are serialized with one of LOCK_STRIPES
+// mutexes, chosen by the key's digest.
+const LOCK_STRIPES int = 64
+
+// DiskCache is safe for concurrent use, also by several processes sharing
+// the same directory.
 type DiskCache struct {
-	baseDir string
+	baseDir     string
+	timeWrapper platform.Time
+	locks       [LOCK_STRIPES]sync.Mutex
 }
 
-func NewDiskCache(baseDir string) *DiskCache {
+func NewDiskCache(baseDir string, timeWrapper platform.Time) *DiskCache {
 	return &DiskCache{
-		baseDir: baseDir,
+		baseDir:     baseDir,
+		timeWrapper: timeWrapper,
 	}
 }
 
-func (self *DiskCache) Set(key string, data []byte) error {
-	dirPath, filePath := self.getPaths(key)
+func (self *DiskCache) Set(key string, data []byte, ttl time.Duration) error {
+	entry := Entry{
+		Data: data,
+	}
+	if ttl > 0 {
+		entry.ExpiresAt = self.timeWrapper.Now().Add(ttl)
+	}
 
-	err := os.MkdirAll(dirPath, 0770)
+	return self.SetEntry(key, entry)
+}
+
+func (self *DiskCache) Get(key string) ([]byte, error) {
+	entry, err := self.GetEntry(key)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if !entry.Fresh(self.timeWrapper.Now()) {
+		return nil, ErrExpired
+	}
+
+	return entry.Data, nil
+}
+
+func (self *DiskCache) SetEntry(key string, entry Entry) error {
+	dirPath, filePath := self.getPaths(key)
+	lock := self.lockFor(key)
+
+	header := entryHeader{
+		Key:       key,
+		WrittenAt: self.timeWrapper.Now(),
+		ExpiresAt: entry.ExpiresAt,
+		ETag:      entry.ETag,
 	}
 
-	err = ioutil.WriteFile(filePath, data, 0660)
+	headerJson, err := json.Marshal(&header)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	contents := make([]byte, 0, len(headerPrefix)+len(headerJson)+1+len(entry.Data))
+	contents = append(contents, headerPrefix...)
+	contents = append(contents, headerJson...)
+	contents = append(contents, '\n')
+	contents = append(contents, entry.Data...)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	return writeFileAtomically(dirPath, filePath, contents)
 }
 
-func (self *DiskCache) Get(key string) ([]byte, error) {
+func (self *DiskCache) GetEntry(key string) (Entry, error) {
 	_, filePath := self.getPaths(key)
 
 	contents, err := ioutil.ReadFile(filePath)
 	if err != nil {
-		return nil, err
+		return Entry{}, err
+	}
+
+	header, data, err := parseEntry(contents)
+	if err != nil {
+		return Entry{}, err
 	}
 
-	return contents, nil
+	return Entry{
+		Data:      data,
+		ETag:      header.ETag,
+		ExpiresAt: header.ExpiresAt,
+	}, nil
 }
 
 func (self *DiskCache) Delete(key string) error {
 	_, filePath := self.getPaths(key)
+	lock := self.lockFor(key)
+
+	lock.Lock()
+	defer lock.Unlock()
+
 	return os.Remove(filePath)
 }
 
+func (self *DiskCache) lockFor(key string) *sync.Mutex {
+	digest := sha256.Sum256([]byte(key))
+	return &self.locks[int(digest[0])%LOCK_STRIPES]
+}
+
 func (self *DiskCache) getPaths(key string) (dirPath, filePath string) {
 	hexdigest := hexDigest([]byte(key))
 
@@ -65,6 +178,40 @@ func (self *DiskCache) getPaths(key string) (dirPath, filePath string) {
 	return
 }
 
+func writeFileAtomically(dirPath, filePath string, contents []byte) error {
+	err := os.MkdirAll(dirPath, 0770)
+	if err != nil {
+		return err
+	}
+
+	err = platform.WriteFileAtomically(filePath, contents, 0660)
+	if os.IsNotExist(err) {
+		// Prune or Clear removed the directory after it was created above.
+		err = os.MkdirAll(dirPath, 0770)
+		if err == nil {
+			err = platform.WriteFileAtomically(filePath, contents, 0660)
+		}
+	}
+
+	return err
+}
+
+func parseEntry(contents []byte) (entryHeader, []byte, error) {
+	header := entryHeader{}
+
+	newline := bytes.IndexByte(contents, '\n')
+	if !bytes.HasPrefix(contents, headerPrefix) || newline < 0 {
+		return header, nil, ErrExpired
+	}
+
+	err := json.Unmarshal(contents[len(headerPrefix):newline], &header)
+	if err != nil {
+		return header, nil, ErrExpired
+	}
+
+	return header, contents[newline+1:], nil
+}
+
 func hexDigest(data []byte) string {
 	hash := sha256.New()
 	hash.Write(data)
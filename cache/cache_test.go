@@ -3,11 +3,15 @@ package cache_test
 import (
 	. "github.com/andreasf/spotify-weekly-releases/cache"
 
+	"github.com/andreasf/spotify-weekly-releases/platform"
+	"github.com/andreasf/spotify-weekly-releases/platform/platformfakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
+	"time"
 )
 
 var _ = Describe("DiskCache", func() {
@@ -17,11 +21,19 @@ var _ = Describe("DiskCache", func() {
 	// os.Stat
 	// os.IsNotExists
 	var tempDir string
+	var timeWrapper *platformfakes.FakeTime
+	var now time.Time
 
 	BeforeEach(func() {
 		var tempErr error
 		tempDir, tempErr = ioutil.TempDir("", "test")
 		Expect(tempErr).To(BeNil())
+
+		now = time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+		timeWrapper = &platformfakes.FakeTime{}
+		timeWrapper.NowStub = func() time.Time {
+			return now
+		}
 	})
 
 	AfterEach(func() {
@@ -29,60 +41,84 @@ var _ = Describe("DiskCache", func() {
 		Expect(err).To(BeNil())
 	})
 
+	newDiskCache := func(timeWrapper platform.Time) Cache {
+		return NewDiskCache(tempDir, timeWrapper)
+	}
+
+	cacheBehaviour(newDiskCache)
+	maintainerBehaviour(newDiskCache)
+
 	It("Stores data in a file below the cache root directory", func() {
 		fooSha256 := "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
 
-		cache := NewDiskCache(tempDir)
+		cache := NewDiskCache(tempDir, timeWrapper)
 		expectedPath := path.Join(tempDir, fooSha256[0:4], fooSha256[4:])
 
-		err := cache.Set("foo", []byte("bar"))
+		err := cache.Set("foo", []byte("bar"), 0)
 
 		Expect(err).To(BeNil())
 
 		contents, err := ioutil.ReadFile(expectedPath)
 		Expect(err).To(BeNil())
 		Expect(contents).ToNot(BeNil())
-		Expect(contents).To(Equal([]byte("bar")))
+		Expect(string(contents)).To(HavePrefix("#cache "))
+		Expect(string(contents)).To(HaveSuffix("\nbar"))
 	})
 
-	It("Can retrieve data again", func() {
-		cache := NewDiskCache(tempDir)
-		err := cache.Set("foo", []byte("bar"))
-		Expect(err).To(BeNil())
+	It("Leaves no temporary files behind", func() {
+		cache := NewDiskCache(tempDir, timeWrapper)
+		Expect(cache.Set("foo", []byte("bar"), 0)).To(Succeed())
+		Expect(cache.Set("foo", []byte("baz"), 0)).To(Succeed())
 
-		err = cache.Set("bar", []byte("baz"))
+		leftovers, err := filepath.Glob(path.Join(tempDir, "*", ".tmp-*"))
 		Expect(err).To(BeNil())
+		Expect(leftovers).To(BeEmpty())
+	})
 
-		err = cache.Set("baz", []byte("foo"))
-		Expect(err).To(BeNil())
+	It("Treats files without a header as expired", func() {
+		fooSha256 := "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+		Expect(os.MkdirAll(path.Join(tempDir, fooSha256[0:4]), 0770)).To(Succeed())
+		Expect(ioutil.WriteFile(path.Join(tempDir, fooSha256[0:4], fooSha256[4:]), []byte("bar"), 0660)).To(Succeed())
 
-		data, err := cache.Get("foo")
-		Expect(err).To(BeNil())
-		Expect(data).To(Equal([]byte("bar")))
+		cache := NewDiskCache(tempDir, timeWrapper)
+		_, err := cache.Get("foo")
+		Expect(err).To(Equal(ErrExpired))
+	})
 
-		data, err = cache.Get("bar")
-		Expect(err).To(BeNil())
-		Expect(data).To(Equal([]byte("baz")))
+	It("Counts files without a header in the empty namespace", func() {
+		dirPath := path.Join(tempDir, "abcd")
+		Expect(os.MkdirAll(dirPath, 0770)).To(Succeed())
+		Expect(ioutil.WriteFile(path.Join(dirPath, "ef"), []byte("legacy"), 0660)).To(Succeed())
+
+		stats, err := NewDiskCache(tempDir, timeWrapper).Stats()
 
-		data, err = cache.Get("baz")
 		Expect(err).To(BeNil())
-		Expect(data).To(Equal([]byte("foo")))
+		Expect(stats).To(Equal([]NamespaceStats{{Namespace: "", Entries: 1, Size: 6}}))
 	})
 
-	It("Can delete entries", func() {
-		cache := NewDiskCache(tempDir)
-		err := cache.Set("foo", []byte("bar"))
-		Expect(err).To(BeNil())
+	It("Reports no entries if the cache directory does not exist", func() {
+		stats, err := NewDiskCache(path.Join(tempDir, "missing"), timeWrapper).Stats()
 
-		data, err := cache.Get("foo")
 		Expect(err).To(BeNil())
-		Expect(data).To(Equal([]byte("bar")))
+		Expect(stats).To(BeEmpty())
+	})
 
-		err = cache.Delete("foo")
+	It("Removes empty directories when clearing", func() {
+		cache := NewDiskCache(tempDir, timeWrapper)
+		Expect(cache.Set("album:1", []byte("one"), 0)).To(Succeed())
+
+		Expect(cache.Clear()).To(Equal(1))
+
+		entries, err := ioutil.ReadDir(tempDir)
 		Expect(err).To(BeNil())
+		Expect(entries).To(BeEmpty())
+	})
+})
 
-		data, err = cache.Get("foo")
-		Expect(err).ToNot(BeNil())
-		Expect(data).To(BeNil())
+var _ = Describe("Namespace", func() {
+	It("Returns the part of the key before the first colon", func() {
+		Expect(Namespace("album:foo")).To(Equal("album"))
+		Expect(Namespace("artist-albums:https://example.com/v1")).To(Equal("artist-albums"))
+		Expect(Namespace("foo")).To(Equal(""))
 	})
 })
@@ -3,16 +3,18 @@ package cachefakes
 
 import (
 	"sync"
+	"time"
 
 	"github.com/andreasf/spotify-weekly-releases/cache"
 )
 
 type FakeCache struct {
-	SetStub        func(key string, data []byte) error
+	SetStub        func(key string, data []byte, ttl time.Duration) error
 	setMutex       sync.RWMutex
 	setArgsForCall []struct {
 		key  string
 		data []byte
+		ttl  time.Duration
 	}
 	setReturns struct {
 		result1 error
@@ -26,6 +28,24 @@ type FakeCache struct {
 		result1 []byte
 		result2 error
 	}
+	SetEntryStub        func(key string, entry cache.Entry) error
+	setEntryMutex       sync.RWMutex
+	setEntryArgsForCall []struct {
+		key   string
+		entry cache.Entry
+	}
+	setEntryReturns struct {
+		result1 error
+	}
+	GetEntryStub        func(key string) (cache.Entry, error)
+	getEntryMutex       sync.RWMutex
+	getEntryArgsForCall []struct {
+		key string
+	}
+	getEntryReturns struct {
+		result1 cache.Entry
+		result2 error
+	}
 	DeleteStub        func(key string) error
 	deleteMutex       sync.RWMutex
 	deleteArgsForCall []struct {
@@ -38,7 +58,7 @@ type FakeCache struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeCache) Set(key string, data []byte) error {
+func (fake *FakeCache) Set(key string, data []byte, ttl time.Duration) error {
 	var dataCopy []byte
 	if data != nil {
 		dataCopy = make([]byte, len(data))
@@ -48,11 +68,12 @@ func (fake *FakeCache) Set(key string, data []byte) error {
 	fake.setArgsForCall = append(fake.setArgsForCall, struct {
 		key  string
 		data []byte
-	}{key, dataCopy})
-	fake.recordInvocation("Set", []interface{}{key, dataCopy})
+		ttl  time.Duration
+	}{key, dataCopy, ttl})
+	fake.recordInvocation("Set", []interface{}{key, dataCopy, ttl})
 	fake.setMutex.Unlock()
 	if fake.SetStub != nil {
-		return fake.SetStub(key, data)
+		return fake.SetStub(key, data, ttl)
 	}
 	return fake.setReturns.result1
 }
@@ -63,10 +84,10 @@ func (fake *FakeCache) SetCallCount() int {
 	return len(fake.setArgsForCall)
 }
 
-func (fake *FakeCache) SetArgsForCall(i int) (string, []byte) {
+func (fake *FakeCache) SetArgsForCall(i int) (string, []byte, time.Duration) {
 	fake.setMutex.RLock()
 	defer fake.setMutex.RUnlock()
-	return fake.setArgsForCall[i].key, fake.setArgsForCall[i].data
+	return fake.setArgsForCall[i].key, fake.setArgsForCall[i].data, fake.setArgsForCall[i].ttl
 }
 
 func (fake *FakeCache) SetReturns(result1 error) {
@@ -109,6 +130,72 @@ func (fake *FakeCache) GetReturns(result1 []byte, result2 error) {
 	}{result1, result2}
 }
 
+func (fake *FakeCache) SetEntry(key string, entry cache.Entry) error {
+	fake.setEntryMutex.Lock()
+	fake.setEntryArgsForCall = append(fake.setEntryArgsForCall, struct {
+		key   string
+		entry cache.Entry
+	}{key, entry})
+	fake.recordInvocation("SetEntry", []interface{}{key, entry})
+	fake.setEntryMutex.Unlock()
+	if fake.SetEntryStub != nil {
+		return fake.SetEntryStub(key, entry)
+	}
+	return fake.setEntryReturns.result1
+}
+
+func (fake *FakeCache) SetEntryCallCount() int {
+	fake.setEntryMutex.RLock()
+	defer fake.setEntryMutex.RUnlock()
+	return len(fake.setEntryArgsForCall)
+}
+
+func (fake *FakeCache) SetEntryArgsForCall(i int) (string, cache.Entry) {
+	fake.setEntryMutex.RLock()
+	defer fake.setEntryMutex.RUnlock()
+	return fake.setEntryArgsForCall[i].key, fake.setEntryArgsForCall[i].entry
+}
+
+func (fake *FakeCache) SetEntryReturns(result1 error) {
+	fake.SetEntryStub = nil
+	fake.setEntryReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeCache) GetEntry(key string) (cache.Entry, error) {
+	fake.getEntryMutex.Lock()
+	fake.getEntryArgsForCall = append(fake.getEntryArgsForCall, struct {
+		key string
+	}{key})
+	fake.recordInvocation("GetEntry", []interface{}{key})
+	fake.getEntryMutex.Unlock()
+	if fake.GetEntryStub != nil {
+		return fake.GetEntryStub(key)
+	}
+	return fake.getEntryReturns.result1, fake.getEntryReturns.result2
+}
+
+func (fake *FakeCache) GetEntryCallCount() int {
+	fake.getEntryMutex.RLock()
+	defer fake.getEntryMutex.RUnlock()
+	return len(fake.getEntryArgsForCall)
+}
+
+func (fake *FakeCache) GetEntryArgsForCall(i int) string {
+	fake.getEntryMutex.RLock()
+	defer fake.getEntryMutex.RUnlock()
+	return fake.getEntryArgsForCall[i].key
+}
+
+func (fake *FakeCache) GetEntryReturns(result1 cache.Entry, result2 error) {
+	fake.GetEntryStub = nil
+	fake.getEntryReturns = struct {
+		result1 cache.Entry
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeCache) Delete(key string) error {
 	fake.deleteMutex.Lock()
 	fake.deleteArgsForCall = append(fake.deleteArgsForCall, struct {
@@ -148,6 +235,10 @@ func (fake *FakeCache) Invocations() map[string][][]interface{} {
 	defer fake.setMutex.RUnlock()
 	fake.getMutex.RLock()
 	defer fake.getMutex.RUnlock()
+	fake.setEntryMutex.RLock()
+	defer fake.setEntryMutex.RUnlock()
+	fake.getEntryMutex.RLock()
+	defer fake.getEntryMutex.RUnlock()
 	fake.deleteMutex.RLock()
 	defer fake.deleteMutex.RUnlock()
 	return fake.invocations
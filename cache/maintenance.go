@@ -0,0 +1,228 @@
+package cache
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Namespace returns the part of a key before the first colon, e.g. "album"
+// for "album:<id>". Keys without a colon are in the "" namespace.
+func Namespace(key string) string {
+	colon := strings.IndexByte(key, ':')
+	if colon < 0 {
+		return ""
+	}
+
+	return key[:colon]
+}
+
+// Maintainer is implemented by caches that can report on and clean up their
+// entries, which is done by the cache command of the CLI.
+type Maintainer interface {
+	Stats() ([]NamespaceStats, error)
+	Prune(maxAge time.Duration, maxSize int64) (int, error)
+	Clear(namespaces ...string) (int, error)
+}
+
+type NamespaceStats struct {
+	Namespace string
+	Entries   int
+	// Size is the total size of the entries' files in bytes.
+	Size int64
+}
+
+type diskEntry struct {
+	filePath string
+	header   entryHeader
+	size     int64
+}
+
+// Stats returns the number and size of entries per namespace, ordered by
+// namespace. Entries written by earlier versions do not record their key and
+// are counted in the "" namespace.
+func (self *DiskCache) Stats() ([]NamespaceStats, error) {
+	entries, err := self.listEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	statsByNamespace := map[string]*NamespaceStats{}
+	namespaces := []string{}
+	for _, entry := range entries {
+		namespace := Namespace(entry.header.Key)
+		stats, found := statsByNamespace[namespace]
+		if !found {
+			stats = &NamespaceStats{Namespace: namespace}
+			statsByNamespace[namespace] = stats
+			namespaces = append(namespaces, namespace)
+		}
+
+		stats.Entries++
+		stats.Size += entry.size
+	}
+
+	sort.Strings(namespaces)
+
+	result := []NamespaceStats{}
+	for _, namespace := range namespaces {
+		result = append(result, *statsByNamespace[namespace])
+	}
+
+	return result, nil
+}
+
+// Prune removes entries written more than maxAge ago, and then the oldest
+// entries until the cache is no larger than maxSize bytes. A maxAge or
+// maxSize of 0 disables that limit. It returns the number of entries removed.
+func (self *DiskCache) Prune(maxAge time.Duration, maxSize int64) (int, error) {
+	entries, err := self.listEntries()
+	if err != nil {
+		return 0, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].header.WrittenAt.Before(entries[j].header.WrittenAt)
+	})
+
+	var totalSize int64
+	for _, entry := range entries {
+		totalSize += entry.size
+	}
+
+	cutoff := self.timeWrapper.Now().Add(-maxAge)
+	removed := 0
+	for _, entry := range entries {
+		tooOld := maxAge > 0 && entry.header.WrittenAt.Before(cutoff)
+		tooLarge := maxSize > 0 && totalSize > maxSize
+		if !tooOld && !tooLarge {
+			break
+		}
+
+		err = removeEntry(entry.filePath)
+		if err != nil {
+			return removed, err
+		}
+
+		totalSize -= entry.size
+		removed++
+	}
+
+	return removed, nil
+}
+
+// Clear removes all entries in the given namespaces, or all entries if no
+// namespace is given. It returns the number of entries removed.
+func (self *DiskCache) Clear(namespaces ...string) (int, error) {
+	entries, err := self.listEntries()
+	if err != nil {
+		return 0, err
+	}
+
+	selected := map[string]bool{}
+	for _, namespace := range namespaces {
+		selected[namespace] = true
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if len(namespaces) > 0 && !selected[Namespace(entry.header.Key)] {
+			continue
+		}
+
+		err = removeEntry(entry.filePath)
+		if err != nil {
+			return removed, err
+		}
+
+		removed++
+	}
+
+	return removed, nil
+}
+
+func (self *DiskCache) listEntries() ([]diskEntry, error) {
+	shards, err := ioutil.ReadDir(self.baseDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []diskEntry{}
+	for _, shard := range shards {
+		if !shard.IsDir() {
+			continue
+		}
+
+		dirPath := path.Join(self.baseDir, shard.Name())
+		files, err := ioutil.ReadDir(dirPath)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			if file.IsDir() || strings.HasPrefix(file.Name(), tempFilePrefix) {
+				continue
+			}
+
+			filePath := path.Join(dirPath, file.Name())
+			header, err := readHeader(filePath)
+			if os.IsNotExist(err) {
+				// Deleted or replaced concurrently
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			entries = append(entries, diskEntry{
+				filePath: filePath,
+				header:   header,
+				size:     file.Size(),
+			})
+		}
+	}
+
+	return entries, nil
+}
+
+// readHeader only reads the header line of an entry. Files without a header
+// get a zero header, so that they are the first to be pruned.
+func readHeader(filePath string) (entryHeader, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return entryHeader{}, err
+	}
+	defer file.Close()
+
+	line, err := bufio.NewReader(file).ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return entryHeader{}, err
+	}
+
+	header, _, err := parseEntry(line)
+	if err != nil {
+		return entryHeader{}, nil
+	}
+
+	return header, nil
+}
+
+func removeEntry(filePath string) error {
+	err := os.Remove(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// Remove the shard directory as well if this was its last entry. This
+	// fails for directories that are not empty yet, which is fine.
+	os.Remove(path.Dir(filePath))
+	return nil
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"github.com/andreasf/spotify-weekly-releases/platform"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by MemoryCache for keys it does not hold.
+var ErrNotFound = errors.New("cache: entry not found")
+
+const DEFAULT_MEMORY_CACHE_ENTRIES int = 10000
+
+// MemoryCache keeps up to maxEntries entries in memory and evicts the least
+// recently used entry when it is full. It is safe for concurrent use.
+//
+// Data is not copied, callers must not modify slices passed to or returned
+// from the cache.
+type MemoryCache struct {
+	maxEntries  int
+	timeWrapper platform.Time
+	mutex       sync.Mutex
+	entries     map[string]*list.Element
+	// recency holds *memoryEntry values, most recently used first.
+	recency *list.List
+}
+
+type memoryEntry struct {
+	key   string
+	entry Entry
+}
+
+func NewMemoryCache(maxEntries int, timeWrapper platform.Time) *MemoryCache {
+	if maxEntries <= 0 {
+		maxEntries = DEFAULT_MEMORY_CACHE_ENTRIES
+	}
+
+	return &MemoryCache{
+		maxEntries:  maxEntries,
+		timeWrapper: timeWrapper,
+		entries:     map[string]*list.Element{},
+		recency:     list.New(),
+	}
+}
+
+func (self *MemoryCache) Set(key string, data []byte, ttl time.Duration) error {
+	entry := Entry{
+		Data: data,
+	}
+	if ttl > 0 {
+		entry.ExpiresAt = self.timeWrapper.Now().Add(ttl)
+	}
+
+	return self.SetEntry(key, entry)
+}
+
+func (self *MemoryCache) Get(key string) ([]byte, error) {
+	entry, err := self.GetEntry(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !entry.Fresh(self.timeWrapper.Now()) {
+		return nil, ErrExpired
+	}
+
+	return entry.Data, nil
+}
+
+func (self *MemoryCache) SetEntry(key string, entry Entry) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	element, found := self.entries[key]
+	if found {
+		element.Value.(*memoryEntry).entry = entry
+		self.recency.MoveToFront(element)
+		return nil
+	}
+
+	self.entries[key] = self.recency.PushFront(&memoryEntry{
+		key:   key,
+		entry: entry,
+	})
+
+	for self.recency.Len() > self.maxEntries {
+		oldest := self.recency.Back()
+		self.recency.Remove(oldest)
+		delete(self.entries, oldest.Value.(*memoryEntry).key)
+	}
+
+	return nil
+}
+
+func (self *MemoryCache) GetEntry(key string) (Entry, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	element, found := self.entries[key]
+	if !found {
+		return Entry{}, ErrNotFound
+	}
+
+	self.recency.MoveToFront(element)
+	return element.Value.(*memoryEntry).entry, nil
+}
+
+func (self *MemoryCache) Delete(key string) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	element, found := self.entries[key]
+	if !found {
+		return ErrNotFound
+	}
+
+	self.recency.Remove(element)
+	delete(self.entries, key)
+	return nil
+}
+
+func (self *MemoryCache) Len() int {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return self.recency.Len()
+}
@@ -0,0 +1,64 @@
+package cache_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/cache"
+
+	"github.com/andreasf/spotify-weekly-releases/platform"
+	"github.com/andreasf/spotify-weekly-releases/platform/platformfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("MemoryCache", func() {
+	var timeWrapper *platformfakes.FakeTime
+	var now time.Time
+	var cache *MemoryCache
+
+	BeforeEach(func() {
+		now = time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+		timeWrapper = &platformfakes.FakeTime{}
+		timeWrapper.NowStub = func() time.Time {
+			return now
+		}
+
+		cache = NewMemoryCache(2, timeWrapper)
+	})
+
+	cacheBehaviour(func(timeWrapper platform.Time) Cache {
+		return NewMemoryCache(100, timeWrapper)
+	})
+
+	It("Returns ErrNotFound for unknown and deleted keys", func() {
+		_, err := cache.Get("foo")
+		Expect(err).To(Equal(ErrNotFound))
+
+		Expect(cache.Set("foo", []byte("bar"), 0)).To(Succeed())
+		Expect(cache.Delete("foo")).To(Succeed())
+
+		_, err = cache.Get("foo")
+		Expect(err).To(Equal(ErrNotFound))
+	})
+
+	It("Evicts the least recently used entry when full", func() {
+		Expect(cache.Set("a", []byte("1"), 0)).To(Succeed())
+		Expect(cache.Set("b", []byte("2"), 0)).To(Succeed())
+		Expect(cache.Get("a")).To(Equal([]byte("1")))
+
+		Expect(cache.Set("c", []byte("3"), 0)).To(Succeed())
+
+		Expect(cache.Len()).To(Equal(2))
+		_, err := cache.Get("b")
+		Expect(err).To(Equal(ErrNotFound))
+		Expect(cache.Get("a")).To(Equal([]byte("1")))
+		Expect(cache.Get("c")).To(Equal([]byte("3")))
+	})
+
+	It("Replaces entries without growing", func() {
+		Expect(cache.Set("a", []byte("1"), 0)).To(Succeed())
+		Expect(cache.Set("a", []byte("2"), 0)).To(Succeed())
+
+		Expect(cache.Len()).To(Equal(1))
+		Expect(cache.Get("a")).To(Equal([]byte("2")))
+	})
+})
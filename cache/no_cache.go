@@ -0,0 +1,28 @@
+package cache
+
+import "time"
+
+// NoCache holds nothing: every Get is a miss, and writes are discarded. It is
+// used where the requests made must not depend on what was cached before, like
+// recording and replaying them.
+type NoCache struct{}
+
+func (self NoCache) Set(key string, data []byte, ttl time.Duration) error {
+	return nil
+}
+
+func (self NoCache) Get(key string) ([]byte, error) {
+	return nil, ErrNotFound
+}
+
+func (self NoCache) SetEntry(key string, entry Entry) error {
+	return nil
+}
+
+func (self NoCache) GetEntry(key string) (Entry, error) {
+	return Entry{}, ErrNotFound
+}
+
+func (self NoCache) Delete(key string) error {
+	return nil
+}
@@ -0,0 +1,22 @@
+package cache_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/cache"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NoCache", func() {
+	It("Does not keep anything", func() {
+		cache := NoCache{}
+		Expect(cache.Set("key", []byte("data"), 0)).To(Succeed())
+		Expect(cache.SetEntry("entry-key", Entry{Data: []byte("data")})).To(Succeed())
+
+		_, err := cache.Get("key")
+		Expect(err).To(Equal(ErrNotFound))
+
+		_, err = cache.GetEntry("entry-key")
+		Expect(err).To(Equal(ErrNotFound))
+	})
+})
@@ -0,0 +1,267 @@
+package cache
+
+import (
+	"database/sql"
+	"github.com/andreasf/spotify-weekly-releases/platform"
+	_ "github.com/mattn/go-sqlite3"
+	"strings"
+	"time"
+)
+
+// Times are stored as Unix nanoseconds, an expires_at of 0 means the entry
+// never expires.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS cache_entries (
+	key TEXT PRIMARY KEY,
+	namespace TEXT NOT NULL,
+	data BLOB NOT NULL,
+	etag TEXT NOT NULL,
+	written_at INTEGER NOT NULL,
+	expires_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS cache_entries_namespace ON cache_entries (namespace);
+CREATE INDEX IF NOT EXISTS cache_entries_written_at ON cache_entries (written_at);
+`
+
+// The size of an entry is approximated by the length of its columns.
+const sqliteEntrySize = "LENGTH(key) + LENGTH(data) + LENGTH(etag)"
+
+// SQLiteCache stores all entries in a single SQLite database file. It is safe
+// for concurrent use, also by several processes sharing the same file.
+type SQLiteCache struct {
+	db          *sql.DB
+	timeWrapper platform.Time
+}
+
+// NewSQLiteCache opens or creates the database at filePath.
+func NewSQLiteCache(filePath string, timeWrapper platform.Time) (*SQLiteCache, error) {
+	db, err := sql.Open("sqlite3", "file:"+filePath+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows only one writer at a time, more connections would just
+	// wait for each other.
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(sqliteSchema)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteCache{
+		db:          db,
+		timeWrapper: timeWrapper,
+	}, nil
+}
+
+func (self *SQLiteCache) Close() error {
+	return self.db.Close()
+}
+
+func (self *SQLiteCache) Set(key string, data []byte, ttl time.Duration) error {
+	entry := Entry{
+		Data: data,
+	}
+	if ttl > 0 {
+		entry.ExpiresAt = self.timeWrapper.Now().Add(ttl)
+	}
+
+	return self.SetEntry(key, entry)
+}
+
+func (self *SQLiteCache) Get(key string) ([]byte, error) {
+	entry, err := self.GetEntry(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !entry.Fresh(self.timeWrapper.Now()) {
+		return nil, ErrExpired
+	}
+
+	return entry.Data, nil
+}
+
+func (self *SQLiteCache) SetEntry(key string, entry Entry) error {
+	data := entry.Data
+	if data == nil {
+		data = []byte{}
+	}
+
+	_, err := self.db.Exec(
+		"INSERT OR REPLACE INTO cache_entries (key, namespace, data, etag, written_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+		key,
+		Namespace(key),
+		data,
+		entry.ETag,
+		self.timeWrapper.Now().UnixNano(),
+		toUnixNano(entry.ExpiresAt),
+	)
+	return err
+}
+
+func (self *SQLiteCache) GetEntry(key string) (Entry, error) {
+	entry := Entry{}
+	var expiresAt int64
+
+	err := self.db.QueryRow("SELECT data, etag, expires_at FROM cache_entries WHERE key = ?", key).
+		Scan(&entry.Data, &entry.ETag, &expiresAt)
+	if err == sql.ErrNoRows {
+		return Entry{}, ErrNotFound
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+
+	entry.ExpiresAt = fromUnixNano(expiresAt)
+	return entry, nil
+}
+
+func (self *SQLiteCache) Delete(key string) error {
+	result, err := self.db.Exec("DELETE FROM cache_entries WHERE key = ?", key)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Stats returns the number and approximate size of entries per namespace,
+// ordered by namespace.
+func (self *SQLiteCache) Stats() ([]NamespaceStats, error) {
+	rows, err := self.db.Query("SELECT namespace, COUNT(*), SUM(" + sqliteEntrySize + ") FROM cache_entries GROUP BY namespace ORDER BY namespace")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []NamespaceStats{}
+	for rows.Next() {
+		stats := NamespaceStats{}
+		err = rows.Scan(&stats.Namespace, &stats.Entries, &stats.Size)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, stats)
+	}
+
+	return result, rows.Err()
+}
+
+// Prune removes entries written more than maxAge ago, and then the oldest
+// entries until the cache is no larger than maxSize bytes. A maxAge or
+// maxSize of 0 disables that limit. It returns the number of entries removed.
+func (self *SQLiteCache) Prune(maxAge time.Duration, maxSize int64) (int, error) {
+	tx, err := self.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var removed int64
+	if maxAge > 0 {
+		cutoff := self.timeWrapper.Now().Add(-maxAge).UnixNano()
+		result, err := tx.Exec("DELETE FROM cache_entries WHERE written_at < ?", cutoff)
+		if err != nil {
+			return 0, err
+		}
+
+		removed, err = result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if maxSize > 0 {
+		tooLarge, err := keysOverBudget(tx, maxSize)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, key := range tooLarge {
+			_, err = tx.Exec("DELETE FROM cache_entries WHERE key = ?", key)
+			if err != nil {
+				return 0, err
+			}
+			removed++
+		}
+	}
+
+	return int(removed), tx.Commit()
+}
+
+// keysOverBudget returns the keys of the oldest entries that do not fit into
+// maxSize bytes together with all newer entries.
+func keysOverBudget(tx *sql.Tx, maxSize int64) ([]string, error) {
+	rows, err := tx.Query("SELECT key, " + sqliteEntrySize + " FROM cache_entries ORDER BY written_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	var totalSize int64
+	for rows.Next() {
+		var key string
+		var size int64
+		err = rows.Scan(&key, &size)
+		if err != nil {
+			return nil, err
+		}
+
+		totalSize += size
+		if totalSize > maxSize {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, rows.Err()
+}
+
+// Clear removes all entries in the given namespaces, or all entries if no
+// namespace is given. It returns the number of entries removed.
+func (self *SQLiteCache) Clear(namespaces ...string) (int, error) {
+	query := "DELETE FROM cache_entries"
+	args := []interface{}{}
+	if len(namespaces) > 0 {
+		query += " WHERE namespace IN (?" + strings.Repeat(", ?", len(namespaces)-1) + ")"
+		for _, namespace := range namespaces {
+			args = append(args, namespace)
+		}
+	}
+
+	result, err := self.db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	removed, err := result.RowsAffected()
+	return int(removed), err
+}
+
+func toUnixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano()
+}
+
+func fromUnixNano(nanoseconds int64) time.Time {
+	if nanoseconds == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, nanoseconds).UTC()
+}
@@ -0,0 +1,68 @@
+package cache_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/cache"
+
+	"github.com/andreasf/spotify-weekly-releases/platform"
+	"github.com/andreasf/spotify-weekly-releases/platform/platformfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+)
+
+var _ = Describe("SQLiteCache", func() {
+	var tempDir string
+	var caches []*SQLiteCache
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "test")
+		Expect(err).To(BeNil())
+		caches = []*SQLiteCache{}
+	})
+
+	AfterEach(func() {
+		for _, cache := range caches {
+			Expect(cache.Close()).To(Succeed())
+		}
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	openCache := func(timeWrapper platform.Time) *SQLiteCache {
+		cache, err := NewSQLiteCache(path.Join(tempDir, "cache.db"), timeWrapper)
+		Expect(err).To(BeNil())
+		caches = append(caches, cache)
+		return cache
+	}
+
+	newSQLiteCache := func(timeWrapper platform.Time) Cache {
+		return openCache(timeWrapper)
+	}
+
+	cacheBehaviour(newSQLiteCache)
+	maintainerBehaviour(newSQLiteCache)
+
+	It("Keeps entries when the database is opened again", func() {
+		timeWrapper := &platformfakes.FakeTime{}
+		timeWrapper.NowReturns(time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC))
+		expiresAt := time.Date(2017, 1, 2, 12, 0, 0, 0, time.UTC)
+
+		Expect(openCache(timeWrapper).SetEntry("album:1", Entry{Data: []byte("one"), ETag: "etag", ExpiresAt: expiresAt})).To(Succeed())
+
+		entry, err := openCache(timeWrapper).GetEntry("album:1")
+
+		Expect(err).To(BeNil())
+		Expect(entry).To(Equal(Entry{Data: []byte("one"), ETag: "etag", ExpiresAt: expiresAt}))
+	})
+
+	It("Returns ErrNotFound for unknown keys", func() {
+		cache := openCache(&platformfakes.FakeTime{})
+
+		_, err := cache.Get("foo")
+		Expect(err).To(Equal(ErrNotFound))
+		Expect(cache.Delete("foo")).To(Equal(ErrNotFound))
+	})
+})
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"github.com/andreasf/spotify-weekly-releases/platform"
+	"time"
+)
+
+// TieredCache puts a fast cache, usually a MemoryCache, in front of a slower
+// one, usually a DiskCache. Writes go to both tiers. Reads are served by the
+// fast tier if it holds a fresh entry; otherwise the slow tier is read and
+// its entry is copied into the fast tier.
+//
+// Because stale entries are always looked up in the slow tier, entries that
+// another process refreshed are picked up, too. Entries that another process
+// deleted may still be served by the fast tier until they expire.
+type TieredCache struct {
+	fast        Cache
+	slow        Cache
+	timeWrapper platform.Time
+}
+
+func NewTieredCache(fast Cache, slow Cache, timeWrapper platform.Time) *TieredCache {
+	return &TieredCache{
+		fast:        fast,
+		slow:        slow,
+		timeWrapper: timeWrapper,
+	}
+}
+
+func (self *TieredCache) Set(key string, data []byte, ttl time.Duration) error {
+	entry := Entry{
+		Data: data,
+	}
+	if ttl > 0 {
+		entry.ExpiresAt = self.timeWrapper.Now().Add(ttl)
+	}
+
+	return self.SetEntry(key, entry)
+}
+
+func (self *TieredCache) Get(key string) ([]byte, error) {
+	entry, err := self.GetEntry(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !entry.Fresh(self.timeWrapper.Now()) {
+		return nil, ErrExpired
+	}
+
+	return entry.Data, nil
+}
+
+// SetEntry writes through to the slow tier first, so that the fast tier never
+// holds entries that failed to be stored.
+func (self *TieredCache) SetEntry(key string, entry Entry) error {
+	err := self.slow.SetEntry(key, entry)
+	if err != nil {
+		self.fast.Delete(key)
+		return err
+	}
+
+	return self.fast.SetEntry(key, entry)
+}
+
+func (self *TieredCache) GetEntry(key string) (Entry, error) {
+	entry, err := self.fast.GetEntry(key)
+	if err == nil && entry.Fresh(self.timeWrapper.Now()) {
+		return entry, nil
+	}
+
+	entry, err = self.slow.GetEntry(key)
+	if err != nil {
+		self.fast.Delete(key)
+		return Entry{}, err
+	}
+
+	// Failing to promote the entry only costs another read of the slow tier.
+	self.fast.SetEntry(key, entry)
+	return entry, nil
+}
+
+func (self *TieredCache) Delete(key string) error {
+	self.fast.Delete(key)
+	return self.slow.Delete(key)
+}
@@ -0,0 +1,107 @@
+package cache_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/cache"
+
+	"errors"
+	"github.com/andreasf/spotify-weekly-releases/cache/cachefakes"
+	"github.com/andreasf/spotify-weekly-releases/platform/platformfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"os"
+	"time"
+)
+
+var _ = Describe("TieredCache", func() {
+	var timeWrapper *platformfakes.FakeTime
+	var now time.Time
+	var memory *MemoryCache
+	var disk *cachefakes.FakeCache
+	var cache *TieredCache
+
+	BeforeEach(func() {
+		now = time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+		timeWrapper = &platformfakes.FakeTime{}
+		timeWrapper.NowStub = func() time.Time {
+			return now
+		}
+
+		memory = NewMemoryCache(10, timeWrapper)
+		disk = &cachefakes.FakeCache{}
+		cache = NewTieredCache(memory, disk, timeWrapper)
+	})
+
+	It("Writes through to both tiers", func() {
+		Expect(cache.Set("foo", []byte("bar"), time.Hour)).To(Succeed())
+
+		Expect(disk.SetEntryCallCount()).To(Equal(1))
+		key, entry := disk.SetEntryArgsForCall(0)
+		Expect(key).To(Equal("foo"))
+		Expect(entry).To(Equal(Entry{Data: []byte("bar"), ExpiresAt: now.Add(time.Hour)}))
+
+		Expect(memory.Get("foo")).To(Equal([]byte("bar")))
+	})
+
+	It("Does not keep entries in memory that could not be written to disk", func() {
+		Expect(memory.Set("foo", []byte("old"), 0)).To(Succeed())
+		disk.SetEntryReturns(errors.New("disk full"))
+
+		Expect(cache.Set("foo", []byte("bar"), 0)).ToNot(Succeed())
+
+		_, err := memory.Get("foo")
+		Expect(err).To(Equal(ErrNotFound))
+	})
+
+	It("Serves fresh entries from memory without reading the disk", func() {
+		Expect(cache.Set("foo", []byte("bar"), 0)).To(Succeed())
+
+		Expect(cache.Get("foo")).To(Equal([]byte("bar")))
+		Expect(disk.GetEntryCallCount()).To(Equal(0))
+	})
+
+	It("Promotes entries read from disk into memory", func() {
+		disk.GetEntryReturns(Entry{Data: []byte("bar")}, nil)
+
+		Expect(cache.Get("foo")).To(Equal([]byte("bar")))
+		Expect(cache.Get("foo")).To(Equal([]byte("bar")))
+
+		Expect(disk.GetEntryCallCount()).To(Equal(1))
+		Expect(memory.Get("foo")).To(Equal([]byte("bar")))
+	})
+
+	It("Reads stale memory entries from disk again", func() {
+		Expect(memory.Set("foo", []byte("old"), time.Hour)).To(Succeed())
+		now = now.Add(2 * time.Hour)
+		disk.GetEntryReturns(Entry{Data: []byte("new"), ExpiresAt: now.Add(time.Hour)}, nil)
+
+		Expect(cache.Get("foo")).To(Equal([]byte("new")))
+	})
+
+	It("Returns ErrExpired for entries that are stale in both tiers", func() {
+		disk.GetEntryReturns(Entry{Data: []byte("bar"), ExpiresAt: now.Add(-time.Hour)}, nil)
+
+		_, err := cache.Get("foo")
+		Expect(err).To(Equal(ErrExpired))
+
+		entry, err := cache.GetEntry("foo")
+		Expect(err).To(BeNil())
+		Expect(entry.Data).To(Equal([]byte("bar")))
+	})
+
+	It("Returns disk errors for entries missing from memory", func() {
+		disk.GetEntryReturns(Entry{}, os.ErrNotExist)
+
+		_, err := cache.Get("foo")
+		Expect(err).To(Equal(os.ErrNotExist))
+	})
+
+	It("Deletes from both tiers", func() {
+		Expect(cache.Set("foo", []byte("bar"), 0)).To(Succeed())
+
+		Expect(cache.Delete("foo")).To(Succeed())
+
+		Expect(disk.DeleteCallCount()).To(Equal(1))
+		_, err := memory.Get("foo")
+		Expect(err).To(Equal(ErrNotFound))
+	})
+})
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/andreasf/spotify-weekly-releases/cache"
+	"github.com/andreasf/spotify-weekly-releases/platform"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const CACHE_DIR string = "cache"
+const CACHE_DB string = "cache.db"
+
+const cacheUsage = `Usage: %[1]s cache stats [cache options]
+       %[1]s cache prune [cache options] [-max-age <duration>] [-max-size <size>]
+       %[1]s cache clear [cache options] [-namespace <namespaces>]
+
+Cache options: [-cache-backend disk|sqlite] [-cache-dir <dir>] [-cache-db <file>]`
+
+// maintainedCache is implemented by all cache backends.
+type maintainedCache interface {
+	cache.Cache
+	cache.Maintainer
+}
+
+type cacheFlags struct {
+	backend *string
+	dir     *string
+	db      *string
+}
+
+func addCacheFlags(flags *flag.FlagSet) cacheFlags {
+	return cacheFlags{
+		backend: flags.String("cache-backend", "disk", "where to cache API responses: disk (one file per entry) or sqlite (a single database file)"),
+		dir:     flags.String("cache-dir", CACHE_DIR, "cache directory of the disk backend"),
+		db:      flags.String("cache-db", CACHE_DB, "database file of the sqlite backend"),
+	}
+}
+
+func (self cacheFlags) open(timeWrapper platform.Time) (maintainedCache, error) {
+	switch *self.backend {
+	case "disk":
+		return cache.NewDiskCache(*self.dir, timeWrapper), nil
+	case "sqlite":
+		return cache.NewSQLiteCache(*self.db, timeWrapper)
+	}
+
+	return nil, fmt.Errorf("unknown cache backend: %s", *self.backend)
+}
+
+// closeCache closes caches that hold resources, like database connections.
+func closeCache(openCache maintainedCache) {
+	closer, ok := openCache.(io.Closer)
+	if ok {
+		closer.Close()
+	}
+}
+
+// runCacheCommand implements the cache subcommand, which reports on and
+// cleans up the API cache.
+func runCacheCommand(args []string) error {
+	if len(args) == 0 {
+		return usageError()
+	}
+
+	flags := flag.NewFlagSet("cache "+args[0], flag.ExitOnError)
+	cacheOptions := addCacheFlags(flags)
+	maxAge := time.Duration(0)
+	maxSize := ""
+	namespaces := ""
+
+	switch args[0] {
+	case "stats":
+	case "prune":
+		flags.DurationVar(&maxAge, "max-age", 0, "remove entries written longer ago than this, e.g. 720h")
+		flags.StringVar(&maxSize, "max-size", "", "remove the oldest entries until the cache is no larger than this, e.g. 500M")
+	case "clear":
+		flags.StringVar(&namespaces, "namespace", "", "comma-separated namespaces to clear, e.g. artist-albums; all entries if empty")
+	default:
+		return usageError()
+	}
+
+	flags.Parse(args[1:])
+
+	maxSizeBytes, err := parseSize(maxSize)
+	if err != nil {
+		return err
+	}
+	if args[0] == "prune" && maxAge == 0 && maxSizeBytes == 0 {
+		return errors.New("prune needs -max-age or -max-size")
+	}
+
+	openCache, err := cacheOptions.open(&platform.TimeWrapper{})
+	if err != nil {
+		return err
+	}
+	defer closeCache(openCache)
+
+	var removed int
+	switch args[0] {
+	case "stats":
+		return printCacheStats(openCache)
+
+	case "prune":
+		removed, err = openCache.Prune(maxAge, maxSizeBytes)
+
+	case "clear":
+		selected := []string{}
+		if namespaces != "" {
+			selected = strings.Split(namespaces, ",")
+		}
+
+		removed, err = openCache.Clear(selected...)
+	}
+
+	fmt.Printf("Removed %d entries.\n", removed)
+	return err
+}
+
+func usageError() error {
+	return fmt.Errorf(cacheUsage, os.Args[0])
+}
+
+func printCacheStats(maintainer cache.Maintainer) error {
+	stats, err := maintainer.Stats()
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(writer, "NAMESPACE\tENTRIES\tSIZE\t")
+
+	totalEntries := 0
+	var totalSize int64
+	for _, namespace := range stats {
+		name := namespace.Namespace
+		if name == "" {
+			name = "(none)"
+		}
+
+		fmt.Fprintf(writer, "%s\t%d\t%s\t\n", name, namespace.Entries, formatSize(namespace.Size))
+		totalEntries += namespace.Entries
+		totalSize += namespace.Size
+	}
+
+	fmt.Fprintf(writer, "total\t%d\t%s\t\n", totalEntries, formatSize(totalSize))
+	return writer.Flush()
+}
+
+var sizeUnits = []string{"K", "M", "G"}
+
+// parseSize parses a number of bytes with an optional K, M or G suffix.
+func parseSize(size string) (int64, error) {
+	if size == "" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	number := strings.ToUpper(size)
+	for i, unit := range sizeUnits {
+		if strings.HasSuffix(number, unit) {
+			number = strings.TrimSuffix(number, unit)
+			multiplier = int64(1) << (10 * uint(i+1))
+			break
+		}
+	}
+
+	value, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size: %s", size)
+	}
+
+	return value * multiplier, nil
+}
+
+func formatSize(size int64) string {
+	value := float64(size)
+	unit := "B"
+	for _, larger := range sizeUnits {
+		if value < 1024 {
+			break
+		}
+		value /= 1024
+		unit = larger
+	}
+
+	if unit == "B" {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f %s", value, unit)
+}
@@ -1,48 +1,220 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"github.com/andreasf/spotify-weekly-releases/api"
+	"github.com/andreasf/spotify-weekly-releases/auth"
 	"github.com/andreasf/spotify-weekly-releases/cache"
 	"github.com/andreasf/spotify-weekly-releases/model"
 	"github.com/andreasf/spotify-weekly-releases/platform"
+	"github.com/andreasf/spotify-weekly-releases/replay"
 	"github.com/andreasf/spotify-weekly-releases/services"
+	"github.com/andreasf/spotify-weekly-releases/store"
+	"net/http"
 	"os"
-	"time"
+	"os/signal"
+	"strings"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Printf("Usage: %s <access token>\n", os.Args[0])
+	if len(os.Args) > 1 && os.Args[1] == "cache" {
+		err := runCacheCommand(os.Args[2:])
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	clientId := flag.String("client-id", os.Getenv("SPOTIFY_CLIENT_ID"), "Spotify application client id")
+	redirectUrl := flag.String("redirect-url", "http://127.0.0.1:8888/callback", "loopback redirect URL registered for the application")
+	authorizeUrl := flag.String("authorize-url", auth.SPOTIFY_AUTHORIZE_URL, "authorization endpoint")
+	tokenUrl := flag.String("token-url", auth.SPOTIFY_TOKEN_URL, "token endpoint")
+	tokenFile := flag.String("token-file", "token.json", "file to store access and refresh tokens in")
+	cacheOptions := addCacheFlags(flag.CommandLine)
+	lastRunFile := flag.String("last-run-file", "last_run.json", "file to remember the last successful run in")
+	fallbackWindow := flag.Duration("fallback-window", services.DEFAULT_FALLBACK_WINDOW, "how far back to look for releases on the first run")
+	albumGroups := flag.String("album-groups", strings.Join(model.DEFAULT_ALBUM_GROUPS, ","), "comma-separated kinds of releases to include: album, single, appears_on, compilation")
+	workers := flag.Int("workers", services.DEFAULT_WORKERS, "number of artists to retrieve concurrently")
+	tolerant := flag.Bool("tolerant", false, "skip artists and albums that cannot be retrieved instead of failing the run")
+	requestsPerSecond := flag.Float64("requests-per-second", api.DEFAULT_REQUESTS_PER_SECOND, "average number of API requests per second")
+	burst := flag.Int("burst", api.DEFAULT_BURST, "number of API requests allowed at once before pacing to -requests-per-second")
+	requestTimeout := flag.Duration("request-timeout", api.DEFAULT_TIMEOUT, "timeout of each API request")
+	maxAttempts := flag.Int("max-attempts", api.DEFAULT_MAX_ATTEMPTS, "number of attempts of API requests failing with a server or network error, 1 for no retries")
+	maxRetryAfter := flag.Duration("max-retry-after", api.DefaultRetryPolicy().MaxRetryAfter, "longest Retry-After period to wait for when rate limited, 0 for no limit")
+	maxRetryAfterPerRun := flag.Duration("max-retry-after-per-run", api.DefaultRetryPolicy().MaxRetryAfterPerClient, "longest total time to wait when rate limited during a run, 0 for no limit")
+	timeout := flag.Duration("timeout", 0, "give up if the playlist has not been created after this time, 0 for no limit")
+	persistent := flag.Bool("persistent", false, "update a single playlist instead of creating a new one")
+	playlistName := flag.String("playlist-name", services.PERSISTENT_PLAYLIST_NAME, "name of the playlist updated with -persistent")
+	recordDir := flag.String("record", "", "directory to record all API requests and responses in, see -replay")
+	replayDir := flag.String("replay", "", "directory of a recording made with -record to replay API responses from, without network access")
+	reportFormat := flag.String("report", "text", "format of the report printed after the run: text or json")
+	flag.Parse()
+
+	if *recordDir != "" && *replayDir != "" {
+		fmt.Println("-record and -replay cannot be used together")
+		os.Exit(1)
+	}
+
+	if *clientId == "" && *replayDir == "" {
+		fmt.Printf("Usage: %s -client-id <client id> [options]\n", os.Args[0])
+		flag.PrintDefaults()
 		os.Exit(1)
 	}
 
-	accessToken := os.Args[1]
+	if *reportFormat != "text" && *reportFormat != "json" {
+		fmt.Printf("Invalid report format: %s\n", *reportFormat)
+		os.Exit(1)
+	}
+
+	parsedAlbumGroups, err := model.ParseAlbumGroups(*albumGroups)
+	if err != nil {
+		fmt.Printf("Invalid album groups: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Ctrl-C cancels all requests and waits in progress
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
 
 	timeWrapper := &platform.TimeWrapper{}
-	cache := cache.NewDiskCache("cache")
-	apiClient := api.NewSpotifyApiClient("https://api.spotify.com", timeWrapper, cache)
-	service := services.NewSpotifyService(apiClient, timeWrapper)
 
-	var albums model.AlbumList
-	albums, err := service.GetRecentReleases(accessToken)
+	var tokens api.TokenSource
+	if *replayDir != "" {
+		tokens = api.NewStaticTokenSource("replay")
+	} else {
+		tokens = authenticate(ctx, *clientId, *authorizeUrl, *tokenUrl, *redirectUrl, *tokenFile, timeWrapper)
+	}
+
+	// os.Exit skips deferred calls, so the cache is closed explicitly before
+	// exiting
+	var apiCache cache.Cache
+	closeApiCache := func() {}
+	if *recordDir != "" || *replayDir != "" {
+		// Without a cache, every request is recorded, and the same requests
+		// are made again when replaying, regardless of evictions.
+		apiCache = cache.NoCache{}
+	} else {
+		openCache, err := cacheOptions.open(timeWrapper)
+		if err != nil {
+			fmt.Printf("Error opening cache: %v\n", err)
+			os.Exit(1)
+		}
+		closeApiCache = func() { closeCache(openCache) }
+		apiCache = openCache
+	}
+
+	retryPolicy := api.DefaultRetryPolicy()
+	retryPolicy.MaxAttempts = *maxAttempts
+	retryPolicy.MaxRetryAfter = *maxRetryAfter
+	retryPolicy.MaxRetryAfterPerClient = *maxRetryAfterPerRun
+	clientOptions := api.ClientOptions{
+		HttpClient: &http.Client{},
+		Timeout:    *requestTimeout,
+		Limiter:    api.NewRateLimiter(*requestsPerSecond, *burst, timeWrapper),
+		Retry:      &retryPolicy,
+	}
+	var lastRuns services.LastRunStore = store.NewFileLastRunStore(*lastRunFile)
+
+	if *recordDir != "" {
+		clientOptions.HttpClient.Transport, err = startRecording(*recordDir, *lastRunFile)
+		if err != nil {
+			fmt.Printf("Error starting to record: %v\n", err)
+			closeApiCache()
+			os.Exit(1)
+		}
+	}
+
+	if *replayDir != "" {
+		clientOptions.HttpClient.Transport, err = replay.NewReplayTransport(*replayDir)
+		if err != nil {
+			fmt.Printf("Error reading recording: %v\n", err)
+			closeApiCache()
+			os.Exit(1)
+		}
+		lastRuns = replayedLastRuns(*replayDir)
+		// retries get the next recorded response, there is no need to wait for it
+		retryPolicy.InitialBackoff = 0
+	}
+
+	apiClient := api.NewSpotifyApiClient("https://api.spotify.com", tokens, timeWrapper, apiCache, clientOptions)
+
+	service := services.NewSpotifyService(apiClient, lastRuns, timeWrapper, services.Options{
+		FallbackWindow: *fallbackWindow,
+		AlbumGroups:    parsedAlbumGroups,
+		Workers:        *workers,
+		Tolerant:       *tolerant,
+	})
+
+	var report services.RunReport
+	if *persistent {
+		report, err = service.UpdateWeeklyPlaylist(ctx, "", *playlistName)
+	} else {
+		report, err = service.CreateWeeklyPlaylist(ctx, services.WeeklyPlaylistName(timeWrapper.Now()))
+	}
+	closeApiCache()
+
+	// the report is printed for failed runs as well, it shows how far they got
+	reportErr := printReport(report, *reportFormat)
+	if reportErr != nil {
+		fmt.Printf("Error printing report: %v\n", reportErr)
+	}
+
 	if err != nil {
-		fmt.Printf("Error retrieving followed albums: %v", err)
+		fmt.Printf("Error creating playlist: %v\n", err)
 		os.Exit(1)
 	}
+}
 
-	albums = albums.RemoveDuplicates()
+func printReport(report services.RunReport, format string) error {
+	if format == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
 
-	var tracks model.TrackList
-	tracks = make([]model.Track, 0, len(albums))
-	for _, album := range albums {
-		tracks = append(tracks, *album.GetSampleTrack())
+		_, err = fmt.Println(string(data))
+		return err
 	}
 
-	tracks = tracks.RemoveDuplicates()
+	return report.WriteText(os.Stdout)
+}
 
-	fmt.Printf("Creating a playlist from %d releases...\n", len(tracks))
+func authenticate(ctx context.Context, clientId, authorizeUrl, tokenUrl, redirectUrl, tokenFile string, timeWrapper platform.Time) api.TokenSource {
+	tokenClient := auth.NewTokenClient(auth.Config{
+		ClientId:     clientId,
+		AuthorizeUrl: authorizeUrl,
+		TokenUrl:     tokenUrl,
+		RedirectUrl:  redirectUrl,
+		Scopes:       auth.SPOTIFY_SCOPES,
+	}, timeWrapper)
+	tokenStore := auth.NewFileTokenStore(tokenFile)
+
+	_, err := tokenStore.Load()
+	if err != nil {
+		token, err := tokenClient.Authorize(ctx, func(authorizationUrl string) {
+			fmt.Printf("Open the following URL in your browser to log in to Spotify:\n\n%s\n\n", authorizationUrl)
+		})
+		if err != nil {
+			fmt.Printf("Error authorizing: %v\n", err)
+			os.Exit(1)
+		}
+
+		err = tokenStore.Save(token)
+		if err != nil {
+			fmt.Printf("Error saving token: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
-	date := time.Now().Format("2006-01-02")
-	service.CreatePlaylist(accessToken, "Weekly Releases - "+date, tracks)
+	return auth.NewAuthenticator(tokenClient, tokenStore, timeWrapper)
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"github.com/andreasf/spotify-weekly-releases/replay"
+	"github.com/andreasf/spotify-weekly-releases/services"
+	"github.com/andreasf/spotify-weekly-releases/store"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+)
+
+// A recording contains the last run file as it was before the run, so that
+// the replay looks for releases in the same period.
+const RECORDED_LAST_RUN_FILE string = "last_run.json"
+
+// startRecording refuses to record into a directory that is not empty, as
+// exchanges of different runs would be mixed up under one last run.
+func startRecording(recordDir string, lastRunFile string) (*replay.RecordingTransport, error) {
+	files, err := ioutil.ReadDir(recordDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(files) > 0 {
+		return nil, fmt.Errorf("%s is not empty", recordDir)
+	}
+
+	transport, err := replay.NewRecordingTransport(recordDir, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	lastRuns, err := ioutil.ReadFile(lastRunFile)
+	if os.IsNotExist(err) {
+		return transport, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = ioutil.WriteFile(path.Join(recordDir, RECORDED_LAST_RUN_FILE), lastRuns, 0660)
+	if err != nil {
+		return nil, err
+	}
+
+	return transport, nil
+}
+
+// replayedLastRuns reads the last run of the recording, and does not change
+// it, so that a recording can be replayed any number of times.
+func replayedLastRuns(replayDir string) services.LastRunStore {
+	return readOnlyLastRunStore{store.NewFileLastRunStore(path.Join(replayDir, RECORDED_LAST_RUN_FILE))}
+}
+
+type readOnlyLastRunStore struct {
+	services.LastRunStore
+}
+
+func (self readOnlyLastRunStore) SetLastRun(userId string, lastRun time.Time) error {
+	return nil
+}
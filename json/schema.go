@@ -44,6 +44,7 @@ type ArtistAlbums struct {
 }
 
 type ArtistAlbum struct {
+	AlbumGroup           string   `json:"album_group"`
 	Id                   string   `json:"id"`
 	Artists              []Artist `json:"artists"`
 	Name                 string   `json:"name"`
@@ -53,6 +54,8 @@ type ArtistAlbum struct {
 	Tracks               Tracks   `json:"tracks"`
 }
 
+// ToModel converts the album to model.Album. An invalid release date results in
+// a zero model.ReleaseDate.
 func (self ArtistAlbum) ToModel() model.Album {
 	artistIds := make([]string, 0, len(self.Artists))
 
@@ -60,13 +63,16 @@ func (self ArtistAlbum) ToModel() model.Album {
 		artistIds = append(artistIds, artist.Id)
 	}
 
+	releaseDate, _ := model.ParseReleaseDate(self.ReleaseDate, self.ReleaseDatePrecision)
+
 	return model.Album{
 		Id:          self.Id,
 		ArtistIds:   artistIds,
 		Name:        self.Name,
-		ReleaseDate: self.ReleaseDate,
+		ReleaseDate: releaseDate,
 		Markets:     self.AvailableMarkets,
 		Tracks:      self.Tracks.ToModel(),
+		AlbumGroup:  self.AlbumGroup,
 	}
 }
 
@@ -152,6 +158,40 @@ type AddTracksRequest struct {
 	Uris []string `json:"uris"`
 }
 
+type ChangePlaylistDetailsRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type PaginatedPlaylists struct {
+	Items []Playlist `json:"items"`
+	Next  string     `json:"next"`
+}
+
+type Playlist struct {
+	Id     string         `json:"id"`
+	Name   string         `json:"name"`
+	Owner  PlaylistOwner  `json:"owner"`
+	Tracks PlaylistTracks `json:"tracks"`
+}
+
+type PlaylistOwner struct {
+	Id string `json:"id"`
+}
+
+type PlaylistTracks struct {
+	Total int `json:"total"`
+}
+
+func (self Playlist) ToModel() model.Playlist {
+	return model.Playlist{
+		Id:         self.Id,
+		Name:       self.Name,
+		OwnerId:    self.Owner.Id,
+		TrackCount: self.Tracks.Total,
+	}
+}
+
 type PaginatedSavedAlbums struct {
 	Items []SavedAlbum `json:"items"`
 	Next  string       `json:"next"`
@@ -161,3 +201,28 @@ type SavedAlbum struct {
 	AddedAt string      `json:"added_at"`
 	Album   ArtistAlbum `json:"album"`
 }
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	Scope        string `json:"scope"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenErrorResponse is the body of error responses of the token endpoint,
+// as defined by OAuth 2.0.
+type TokenErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// ErrorResponse is the body of Web API error responses.
+type ErrorResponse struct {
+	Error ErrorObject `json:"error"`
+}
+
+type ErrorObject struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/andreasf/spotify-weekly-releases/test_resources"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"time"
 )
 
 var _ = Describe("Schema", func() {
@@ -88,10 +89,11 @@ var _ = Describe("Schema", func() {
 		err := json.Unmarshal(rawJson, &albums)
 
 		Expect(err).To(BeNil())
-		Expect(albums.Next).To(Equal("${API_PREFIX}/v1/artists/foo-id/albums?offset=2&limit=2&album_type=single,album,compilation,appears_on,ep"))
+		Expect(albums.Next).To(Equal("${API_PREFIX}/v1/artists/foo-id/albums?offset=2&limit=2&include_groups=album,single,appears_on"))
 
 		Expect(albums.Items).To(HaveLen(2))
 		Expect(albums.Items[0]).To(Equal(ArtistAlbum{
+			AlbumGroup:       "album",
 			Name:             "Foo, The Album",
 			Id:               "foo-album",
 			AvailableMarkets: []string{"SG"},
@@ -104,6 +106,7 @@ var _ = Describe("Schema", func() {
 			},
 		}))
 		Expect(albums.Items[1]).To(Equal(ArtistAlbum{
+			AlbumGroup:       "single",
 			Name:             "Bar, The Album",
 			Id:               "bar-album",
 			AvailableMarkets: []string{"CA", "MX", "US"},
@@ -134,7 +137,7 @@ var _ = Describe("Schema", func() {
 		Expect(mezzanine.ToModel()).To(Equal(model.Album{
 			Name:        "Mezzanine",
 			Id:          "49MNmJhZQewjt06rpwp6QR",
-			ReleaseDate: "1998-04-20",
+			ReleaseDate: model.ReleaseDate{Date: time.Date(1998, 4, 20, 0, 0, 0, 0, time.UTC), Precision: model.PRECISION_DAY},
 			Markets:     []string{"AB", "CD"},
 			ArtistIds:   []string{"6FXMGgJwohJLUSr5nVlf9X"},
 			Tracks: []model.Track{
@@ -160,6 +163,20 @@ var _ = Describe("Schema", func() {
 		}))
 	})
 
+	It("Keeps the release date precision when converting to model.Album", func() {
+		album := ArtistAlbum{
+			Id:                   "album-id",
+			Artists:              []Artist{fooArtist},
+			ReleaseDate:          "1998",
+			ReleaseDatePrecision: "year",
+		}
+
+		Expect(album.ToModel().ReleaseDate).To(Equal(model.ReleaseDate{
+			Date:      time.Date(1998, 1, 1, 0, 0, 0, 0, time.UTC),
+			Precision: model.PRECISION_YEAR,
+		}))
+	})
+
 	It("Deserializes the user profile response", func() {
 		rawJson := test_resources.LoadResource("../test_resources/user_profile.json")
 		profile := UserProfile{}
@@ -205,6 +222,23 @@ var _ = Describe("Schema", func() {
 		Expect(response.Items[0].AddedAt).To(Equal("2016-11-20T10:14:43Z"))
 		Expect(response.Items[0].Album.Id).To(Equal("4xjys0dhhX8AD2Oiz5Y5S6"))
 	})
+
+	It("Deserializes the user playlists response", func() {
+		rawJson := test_resources.LoadResource("../test_resources/user_playlists_page2.json")
+		response := PaginatedPlaylists{}
+
+		err := json.Unmarshal(rawJson, &response)
+
+		Expect(err).To(BeNil())
+		Expect(response.Next).To(BeEmpty())
+		Expect(response.Items).To(HaveLen(1))
+		Expect(response.Items[0].ToModel()).To(Equal(model.Playlist{
+			Id:         "playlist-id",
+			Name:       "Weekly Releases",
+			OwnerId:    "user-id",
+			TrackCount: 42,
+		}))
+	})
 })
 
 var blackRadio ArtistAlbum = ArtistAlbum{
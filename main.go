@@ -1,5 +1,150 @@
 package main
 
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/andreasf/spotify-weekly-releases/api"
+	"github.com/andreasf/spotify-weekly-releases/auth"
+	"github.com/andreasf/spotify-weekly-releases/cache"
+	"github.com/andreasf/spotify-weekly-releases/platform"
+	"github.com/andreasf/spotify-weekly-releases/scheduler"
+	"github.com/andreasf/spotify-weekly-releases/services"
+	"github.com/andreasf/spotify-weekly-releases/store"
+	"github.com/andreasf/spotify-weekly-releases/web"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+)
+
 func main() {
-	panic("not implemented")
+	listenAddress := flag.String("listen", ":8080", "address to listen on")
+	baseUrl := flag.String("base-url", "http://localhost:8080", "public URL of the web app, used for the OAuth redirect")
+	clientId := flag.String("client-id", os.Getenv("SPOTIFY_CLIENT_ID"), "Spotify application client id")
+	authorizeUrl := flag.String("authorize-url", auth.SPOTIFY_AUTHORIZE_URL, "authorization endpoint")
+	tokenUrl := flag.String("token-url", auth.SPOTIFY_TOKEN_URL, "token endpoint")
+	apiUrl := flag.String("api-url", "https://api.spotify.com", "Spotify Web API URL")
+	dataDir := flag.String("data-dir", "data", "directory for subscribers and the cache")
+	fallbackWindow := flag.Duration("fallback-window", services.DEFAULT_FALLBACK_WINDOW, "how far back to look for releases on a subscriber's first run")
+	workers := flag.Int("workers", services.DEFAULT_WORKERS, "number of artists to retrieve concurrently for each subscriber")
+	tolerant := flag.Bool("tolerant", false, "skip artists and albums that cannot be retrieved instead of failing the run")
+	requestsPerSecond := flag.Float64("requests-per-second", api.DEFAULT_REQUESTS_PER_SECOND, "average number of API requests per second, shared by all subscribers")
+	burst := flag.Int("burst", api.DEFAULT_BURST, "number of API requests allowed at once before pacing to -requests-per-second")
+	cacheBackend := flag.String("cache-backend", "disk", "where to cache API responses below -data-dir: disk (one file per entry) or sqlite (a single database file)")
+	memoryCacheEntries := flag.Int("memory-cache-entries", cache.DEFAULT_MEMORY_CACHE_ENTRIES, "number of API cache entries to keep in memory in front of the disk cache")
+	requestTimeout := flag.Duration("request-timeout", api.DEFAULT_TIMEOUT, "timeout of each API request")
+	maxAttempts := flag.Int("max-attempts", api.DEFAULT_MAX_ATTEMPTS, "number of attempts of API requests failing with a server or network error, 1 for no retries")
+	maxRetryAfter := flag.Duration("max-retry-after", api.DefaultRetryPolicy().MaxRetryAfter, "longest Retry-After period to wait for when rate limited, 0 for no limit")
+	maxRetryAfterPerRun := flag.Duration("max-retry-after-per-run", api.DefaultRetryPolicy().MaxRetryAfterPerClient, "longest total time to wait when rate limited during a run, 0 for no limit")
+	scheduleSpec := flag.String("schedule", "0 6 * * 5", "cron-like schedule (minute hour day-of-month month day-of-week) for regenerating playlists")
+	flag.Parse()
+
+	if *clientId == "" {
+		fmt.Printf("Usage: %s -client-id <client id> [options]\n", os.Args[0])
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	schedule, err := scheduler.ParseSchedule(*scheduleSpec)
+	if err != nil {
+		fmt.Printf("Invalid schedule: %v\n", err)
+		os.Exit(1)
+	}
+
+	timeWrapper := &platform.TimeWrapper{}
+
+	tokenClient := auth.NewTokenClient(auth.Config{
+		ClientId:     *clientId,
+		AuthorizeUrl: *authorizeUrl,
+		TokenUrl:     *tokenUrl,
+		RedirectUrl:  strings.TrimSuffix(*baseUrl, "/") + "/callback",
+		Scopes:       auth.SPOTIFY_SCOPES,
+	}, timeWrapper)
+
+	subscribers := store.NewFileSubscriberStore(path.Join(*dataDir, "subscribers"))
+	lastRuns := store.NewSubscriberLastRunStore(subscribers)
+
+	var persistentCache cache.Cache
+	switch *cacheBackend {
+	case "disk":
+		persistentCache = cache.NewDiskCache(path.Join(*dataDir, "cache"), timeWrapper)
+	case "sqlite":
+		persistentCache, err = cache.NewSQLiteCache(path.Join(*dataDir, "cache.db"), timeWrapper)
+		if err != nil {
+			fmt.Printf("Error opening cache: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("Unknown cache backend: %s\n", *cacheBackend)
+		os.Exit(1)
+	}
+
+	memoryCache := cache.NewMemoryCache(*memoryCacheEntries, timeWrapper)
+	tieredCache := cache.NewTieredCache(memoryCache, persistentCache, timeWrapper)
+	limiter := api.NewRateLimiter(*requestsPerSecond, *burst, timeWrapper)
+	retryPolicy := api.DefaultRetryPolicy()
+	retryPolicy.MaxAttempts = *maxAttempts
+	retryPolicy.MaxRetryAfter = *maxRetryAfter
+	retryPolicy.MaxRetryAfterPerClient = *maxRetryAfterPerRun
+
+	newService := func(tokens api.TokenSource, albumGroups []string) services.SpotifyService {
+		apiClient := api.NewSpotifyApiClient(*apiUrl, tokens, timeWrapper, tieredCache, api.ClientOptions{
+			Timeout: *requestTimeout,
+			Limiter: limiter,
+			Retry:   &retryPolicy,
+		})
+		return services.NewSpotifyService(apiClient, lastRuns, timeWrapper, services.Options{
+			FallbackWindow: *fallbackWindow,
+			AlbumGroups:    albumGroups,
+			Workers:        *workers,
+			Tolerant:       *tolerant,
+		})
+	}
+
+	playlistScheduler := scheduler.NewScheduler(schedule, subscribers, tokenClient, newService, timeWrapper)
+	// the scheduler stops, cancelling a run in progress, on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go playlistScheduler.Run(ctx)
+
+	server := web.NewServer(ctx, tokenClient, subscribers, newService, playlistScheduler, timeWrapper)
+	httpServer := &http.Server{
+		Addr:    *listenAddress,
+		Handler: server.Handler(),
+	}
+
+	shutdown := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		httpServer.Shutdown(context.Background())
+		close(shutdown)
+	}()
+
+	log.Printf("Listening on %s", *listenAddress)
+	err = httpServer.ListenAndServe()
+	if err != http.ErrServerClosed {
+		closeCache(persistentCache)
+		log.Fatal(err)
+	}
+	log.Printf("Shutting down")
+
+	// runs are cancelled with ctx, but may still be writing to the cache
+	<-shutdown
+	playlistScheduler.Wait()
+	closeCache(persistentCache)
+}
+
+func closeCache(persistentCache cache.Cache) {
+	closer, ok := persistentCache.(io.Closer)
+	if ok {
+		err := closer.Close()
+		if err != nil {
+			log.Printf("Error closing cache: %v", err)
+		}
+	}
 }
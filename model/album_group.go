@@ -0,0 +1,60 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Album groups describe how an album relates to the artist it was retrieved
+// for. Singles include EPs.
+const ALBUM_GROUP_ALBUM string = "album"
+const ALBUM_GROUP_SINGLE string = "single"
+const ALBUM_GROUP_APPEARS_ON string = "appears_on"
+const ALBUM_GROUP_COMPILATION string = "compilation"
+
+var ALBUM_GROUPS = []string{
+	ALBUM_GROUP_ALBUM,
+	ALBUM_GROUP_SINGLE,
+	ALBUM_GROUP_APPEARS_ON,
+	ALBUM_GROUP_COMPILATION,
+}
+
+var DEFAULT_ALBUM_GROUPS = []string{
+	ALBUM_GROUP_ALBUM,
+	ALBUM_GROUP_SINGLE,
+}
+
+// ParseAlbumGroups parses a comma-separated list of album groups, e.g.
+// "album,single".
+func ParseAlbumGroups(groups string) ([]string, error) {
+	parsed := []string{}
+
+	for _, group := range strings.Split(groups, ",") {
+		group = strings.TrimSpace(group)
+		if group == "" {
+			continue
+		}
+
+		if !isAlbumGroup(group) {
+			return nil, fmt.Errorf("ParseAlbumGroups: unknown album group %s", group)
+		}
+
+		parsed = append(parsed, group)
+	}
+
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("ParseAlbumGroups: no album groups given")
+	}
+
+	return parsed, nil
+}
+
+func isAlbumGroup(group string) bool {
+	for _, known := range ALBUM_GROUPS {
+		if group == known {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,25 @@
+package model_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/model"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseAlbumGroups", func() {
+	It("Parses a comma-separated list", func() {
+		groups, err := ParseAlbumGroups("album, single,appears_on")
+
+		Expect(err).To(BeNil())
+		Expect(groups).To(Equal([]string{ALBUM_GROUP_ALBUM, ALBUM_GROUP_SINGLE, ALBUM_GROUP_APPEARS_ON}))
+	})
+
+	It("Rejects unknown and empty lists", func() {
+		_, err := ParseAlbumGroups("album,ep")
+		Expect(err).NotTo(BeNil())
+
+		_, err = ParseAlbumGroups(" , ")
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -21,9 +21,10 @@ type Album struct {
 	Name        string
 	Id          string
 	ArtistIds   []string
-	ReleaseDate string
+	ReleaseDate ReleaseDate
 	Markets     []string
 	Tracks      []Track
+	AlbumGroup  string
 }
 
 type AlbumList []Album
@@ -65,6 +66,20 @@ func (self AlbumList) Remove(albums []Album) AlbumList {
 	return filtered
 }
 
+// GetSampleTracks returns one sample track per album, skipping albums without tracks.
+func (self AlbumList) GetSampleTracks() TrackList {
+	tracks := make([]Track, 0, len(self))
+
+	for _, album := range self {
+		track := album.GetSampleTrack()
+		if track != nil {
+			tracks = append(tracks, *track)
+		}
+	}
+
+	return tracks
+}
+
 func (self AlbumList) GetArtistIds() []string {
 	artistIds := make([]string, 0, len(self))
 
@@ -94,6 +109,13 @@ func (self *Album) GetSampleTrack() *Track {
 	return nil
 }
 
+type Playlist struct {
+	Id         string
+	Name       string
+	OwnerId    string
+	TrackCount int
+}
+
 type UserProfile struct {
 	Id      string
 	Country string
@@ -123,19 +123,19 @@ var _ = Describe("Model", func() {
 					Name:        "fooplicate",
 					ArtistIds:   []string{"foo-id"},
 					Id:          "baz-album-id",
-					ReleaseDate: "2017-01-01",
+					ReleaseDate: releaseDate("2017-01-01"),
 				},
 				{
 					Name:        "fooplicate",
 					Id:          "foo-album-id",
 					ArtistIds:   []string{"foo-id"},
-					ReleaseDate: "2016-06-02",
+					ReleaseDate: releaseDate("2016-06-02"),
 				},
 				{
 					Name:        "barnique",
 					Id:          "bar-album-id",
 					ArtistIds:   []string{"bar-id", "baz-id"},
-					ReleaseDate: "2016-05-23",
+					ReleaseDate: releaseDate("2016-05-23"),
 				},
 			}
 		})
@@ -163,6 +163,30 @@ var _ = Describe("Model", func() {
 			})
 		})
 
+		Describe("GetSampleTracks", func() {
+			It("Returns one track per album and skips albums without tracks", func() {
+				var albums AlbumList = []Album{
+					{
+						Id:     "album-1",
+						Tracks: []Track{{Id: "track-1"}},
+					},
+					{
+						Id:     "album-2",
+						Tracks: []Track{},
+					},
+					{
+						Id:     "album-3",
+						Tracks: []Track{{Id: "track-2"}, {Id: "track-3"}, {Id: "track-4"}, {Id: "track-5"}},
+					},
+				}
+
+				Expect(albums.GetSampleTracks()).To(Equal(TrackList{
+					{Id: "track-1"},
+					{Id: "track-4"},
+				}))
+			})
+		})
+
 		It("Remove removes the given albums from the list", func() {
 			toRemove := Album{
 				Id: "baz-album-id",
@@ -204,3 +228,9 @@ var _ = Describe("Model", func() {
 		})
 	})
 })
+
+func releaseDate(date string) ReleaseDate {
+	parsed, err := ParseReleaseDate(date, "")
+	Expect(err).To(BeNil())
+	return parsed
+}
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const PRECISION_DAY string = "day"
+const PRECISION_MONTH string = "month"
+const PRECISION_YEAR string = "year"
+
+var releaseDateLayouts = map[string]string{
+	PRECISION_DAY:   "2006-01-02",
+	PRECISION_MONTH: "2006-01",
+	PRECISION_YEAR:  "2006",
+}
+
+// ReleaseDate is the release date of an album. Spotify only knows the year or
+// month of some releases, so a ReleaseDate stands for the whole interval from
+// Start to End.
+type ReleaseDate struct {
+	Date      time.Time
+	Precision string
+}
+
+// ParseReleaseDate parses a Spotify release date ("2017", "2017-03" or
+// "2017-03-04") in UTC. If precision is empty, it is inferred from the date.
+func ParseReleaseDate(date string, precision string) (ReleaseDate, error) {
+	if precision == "" {
+		precision = inferPrecision(date)
+	}
+
+	layout, known := releaseDateLayouts[precision]
+	if !known {
+		return ReleaseDate{}, fmt.Errorf("ParseReleaseDate: unknown precision %s", precision)
+	}
+
+	parsed, err := time.ParseInLocation(layout, date, time.UTC)
+	if err != nil {
+		return ReleaseDate{}, fmt.Errorf("ParseReleaseDate: %v", err)
+	}
+
+	return ReleaseDate{
+		Date:      parsed,
+		Precision: precision,
+	}, nil
+}
+
+func inferPrecision(date string) string {
+	switch len(date) {
+	case len("2006"):
+		return PRECISION_YEAR
+	case len("2006-01"):
+		return PRECISION_MONTH
+	default:
+		return PRECISION_DAY
+	}
+}
+
+func (self ReleaseDate) IsZero() bool {
+	return self.Date.IsZero()
+}
+
+// Start returns the beginning of the first day the release may have been
+// released on.
+func (self ReleaseDate) Start() time.Time {
+	return self.Date
+}
+
+// End returns the beginning of the first day after the interval.
+func (self ReleaseDate) End() time.Time {
+	switch self.Precision {
+	case PRECISION_YEAR:
+		return self.Date.AddDate(1, 0, 0)
+	case PRECISION_MONTH:
+		return self.Date.AddDate(0, 1, 0)
+	default:
+		return self.Date.AddDate(0, 0, 1)
+	}
+}
+
+// ReleasedAfter returns true if any part of the interval lies after t.
+func (self ReleaseDate) ReleasedAfter(t time.Time) bool {
+	return !self.IsZero() && self.End().After(t)
+}
+
+func (self ReleaseDate) String() string {
+	layout, known := releaseDateLayouts[self.Precision]
+	if !known {
+		layout = releaseDateLayouts[PRECISION_DAY]
+	}
+
+	return self.Date.Format(layout)
+}
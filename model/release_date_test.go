@@ -0,0 +1,59 @@
+package model_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/model"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("ReleaseDate", func() {
+	It("Parses dates of each precision", func() {
+		day, err := ParseReleaseDate("2017-03-04", PRECISION_DAY)
+		Expect(err).To(BeNil())
+		Expect(day.Start()).To(Equal(time.Date(2017, 3, 4, 0, 0, 0, 0, time.UTC)))
+		Expect(day.End()).To(Equal(time.Date(2017, 3, 5, 0, 0, 0, 0, time.UTC)))
+
+		month, err := ParseReleaseDate("2017-03", PRECISION_MONTH)
+		Expect(err).To(BeNil())
+		Expect(month.Start()).To(Equal(time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)))
+		Expect(month.End()).To(Equal(time.Date(2017, 4, 1, 0, 0, 0, 0, time.UTC)))
+
+		year, err := ParseReleaseDate("2017", PRECISION_YEAR)
+		Expect(err).To(BeNil())
+		Expect(year.Start()).To(Equal(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)))
+		Expect(year.End()).To(Equal(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("Infers the precision if none is given", func() {
+		Expect(releaseDate("2017").Precision).To(Equal(PRECISION_YEAR))
+		Expect(releaseDate("2017-03").Precision).To(Equal(PRECISION_MONTH))
+		Expect(releaseDate("2017-03-04").Precision).To(Equal(PRECISION_DAY))
+	})
+
+	It("Rejects dates that do not match the precision", func() {
+		_, err := ParseReleaseDate("2017", PRECISION_DAY)
+		Expect(err).NotTo(BeNil())
+
+		_, err = ParseReleaseDate("2017-03-04", "decade")
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("Is released after a time if any part of the interval is", func() {
+		since := time.Date(2017, 3, 4, 6, 0, 0, 0, time.UTC)
+
+		Expect(releaseDate("2017-03-04").ReleasedAfter(since)).To(BeTrue())
+		Expect(releaseDate("2017-03-03").ReleasedAfter(since)).To(BeFalse())
+		Expect(releaseDate("2017-03").ReleasedAfter(since)).To(BeTrue())
+		Expect(releaseDate("2017-02").ReleasedAfter(since)).To(BeFalse())
+		Expect(releaseDate("2017").ReleasedAfter(since)).To(BeTrue())
+		Expect(releaseDate("2016").ReleasedAfter(since)).To(BeFalse())
+		Expect(ReleaseDate{}.ReleasedAfter(since)).To(BeFalse())
+	})
+
+	It("Formats the date according to its precision", func() {
+		Expect(releaseDate("2017-03").String()).To(Equal("2017-03"))
+		Expect(releaseDate("2017-03-04").String()).To(Equal("2017-03-04"))
+	})
+})
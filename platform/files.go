@@ -0,0 +1,47 @@
+package platform
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// TEMP_FILE_PREFIX is the prefix of the temporary files written by
+// WriteFileAtomically.
+const TEMP_FILE_PREFIX string = ".tmp-"
+
+// WriteFileAtomically writes contents to a temporary file in the directory of
+// filePath first, which is then renamed to filePath. Readers, including other
+// processes, therefore never see a partially written file, and a crash leaves
+// the previous contents in place. The directory must exist.
+func WriteFileAtomically(filePath string, contents []byte, perm os.FileMode) error {
+	tempFile, err := ioutil.TempFile(filepath.Dir(filePath), TEMP_FILE_PREFIX)
+	if err != nil {
+		return err
+	}
+
+	_, err = tempFile.Write(contents)
+	if err == nil {
+		err = tempFile.Sync()
+	}
+
+	closeErr := tempFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Chmod(tempFile.Name(), perm)
+	}
+
+	if err == nil {
+		err = os.Rename(tempFile.Name(), filePath)
+	}
+
+	if err != nil {
+		os.Remove(tempFile.Name())
+		return err
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@
 package platformfakes
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -14,6 +15,15 @@ type FakeTime struct {
 	sleepArgsForCall []struct {
 		d time.Duration
 	}
+	SleepContextStub        func(ctx context.Context, d time.Duration) error
+	sleepContextMutex       sync.RWMutex
+	sleepContextArgsForCall []struct {
+		ctx context.Context
+		d   time.Duration
+	}
+	sleepContextReturns struct {
+		result1 error
+	}
 	NowStub        func() time.Time
 	nowMutex       sync.RWMutex
 	nowArgsForCall []struct{}
@@ -48,6 +58,39 @@ func (fake *FakeTime) SleepArgsForCall(i int) time.Duration {
 	return fake.sleepArgsForCall[i].d
 }
 
+func (fake *FakeTime) SleepContext(ctx context.Context, d time.Duration) error {
+	fake.sleepContextMutex.Lock()
+	fake.sleepContextArgsForCall = append(fake.sleepContextArgsForCall, struct {
+		ctx context.Context
+		d   time.Duration
+	}{ctx, d})
+	fake.recordInvocation("SleepContext", []interface{}{ctx, d})
+	fake.sleepContextMutex.Unlock()
+	if fake.SleepContextStub != nil {
+		return fake.SleepContextStub(ctx, d)
+	}
+	return fake.sleepContextReturns.result1
+}
+
+func (fake *FakeTime) SleepContextCallCount() int {
+	fake.sleepContextMutex.RLock()
+	defer fake.sleepContextMutex.RUnlock()
+	return len(fake.sleepContextArgsForCall)
+}
+
+func (fake *FakeTime) SleepContextArgsForCall(i int) (context.Context, time.Duration) {
+	fake.sleepContextMutex.RLock()
+	defer fake.sleepContextMutex.RUnlock()
+	return fake.sleepContextArgsForCall[i].ctx, fake.sleepContextArgsForCall[i].d
+}
+
+func (fake *FakeTime) SleepContextReturns(result1 error) {
+	fake.SleepContextStub = nil
+	fake.sleepContextReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeTime) Now() time.Time {
 	fake.nowMutex.Lock()
 	fake.nowArgsForCall = append(fake.nowArgsForCall, struct{}{})
@@ -77,6 +120,8 @@ func (fake *FakeTime) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.sleepMutex.RLock()
 	defer fake.sleepMutex.RUnlock()
+	fake.sleepContextMutex.RLock()
+	defer fake.sleepContextMutex.RUnlock()
 	fake.nowMutex.RLock()
 	defer fake.nowMutex.RUnlock()
 	return fake.invocations
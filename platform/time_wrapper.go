@@ -1,10 +1,16 @@
 package platform
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 //go:generate counterfeiter . Time
 type Time interface {
 	Sleep(d time.Duration)
+	// SleepContext sleeps like Sleep, but returns ctx.Err() as soon as ctx is
+	// done.
+	SleepContext(ctx context.Context, d time.Duration) error
 	Now() time.Time
 }
 
@@ -14,6 +20,18 @@ func (self *TimeWrapper) Sleep(d time.Duration) {
 	time.Sleep(d)
 }
 
+func (self *TimeWrapper) SleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (self *TimeWrapper) Now() time.Time {
 	return time.Now()
 }
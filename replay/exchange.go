@@ -0,0 +1,131 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Only these response headers are recorded. Request headers are not recorded
+// at all, so that recordings never contain access tokens.
+var recordedHeaders = []string{"Content-Type", "ETag", "Location", "Retry-After"}
+
+// Exchange is a recorded request and its response. Each exchange is stored in
+// a JSON file of its own, named after its sequence number and request.
+type Exchange struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string `json:"method"`
+	Url    string `json:"url"`
+	Body   Body   `json:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       Body        `json:"body,omitempty"`
+}
+
+// Body is stored as JSON if it is a JSON object or array, which keeps API
+// responses readable, and as a JSON string otherwise. JSON bodies are
+// replayed with their whitespace changed.
+type Body []byte
+
+func (self Body) MarshalJSON() ([]byte, error) {
+	trimmed := strings.TrimSpace(string(self))
+	if json.Valid(self) && (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) {
+		return self, nil
+	}
+
+	return json.Marshal(string(self))
+}
+
+func (self *Body) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*self = nil
+		return nil
+	}
+
+	if strings.HasPrefix(string(data), `"`) {
+		text := ""
+		err := json.Unmarshal(data, &text)
+		if err != nil {
+			return err
+		}
+
+		*self = Body(text)
+		return nil
+	}
+
+	*self = append(Body{}, data...)
+	return nil
+}
+
+func (self Exchange) key() string {
+	return self.Request.Method + " " + self.Request.Url
+}
+
+var unsafeFileNameCharacters = regexp.MustCompile("[^A-Za-z0-9]+")
+
+func fileName(sequence int, method string, urlPath string) string {
+	name := strings.Trim(unsafeFileNameCharacters.ReplaceAllString(urlPath, "-"), "-")
+	if len(name) > 80 {
+		name = name[:80]
+	}
+
+	return fmt.Sprintf("%05d-%s-%s.json", sequence, method, name)
+}
+
+// readExchanges returns the exchanges recorded in dirPath, in the order they
+// were recorded.
+func readExchanges(dirPath string) ([]Exchange, error) {
+	fileNames, err := exchangeFileNames(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	exchanges := []Exchange{}
+	for _, name := range fileNames {
+		contents, err := ioutil.ReadFile(path.Join(dirPath, name))
+		if err != nil {
+			return nil, err
+		}
+
+		exchange := Exchange{}
+		err = json.Unmarshal(contents, &exchange)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		exchanges = append(exchanges, exchange)
+	}
+
+	return exchanges, nil
+}
+
+var exchangeFileName = regexp.MustCompile(`^\d{5}-.*\.json$`)
+
+func exchangeFileNames(dirPath string) ([]string, error) {
+	files, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, file := range files {
+		if !file.IsDir() && exchangeFileName.MatchString(file.Name()) {
+			names = append(names, file.Name())
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
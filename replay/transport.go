@@ -0,0 +1,179 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+)
+
+// RecordingTransport passes requests on to the next transport and saves each
+// request and its response in a directory. Requests that fail without a
+// response are not recorded.
+type RecordingTransport struct {
+	dirPath  string
+	next     http.RoundTripper
+	mutex    sync.Mutex
+	sequence int
+}
+
+// NewRecordingTransport creates dirPath if necessary. Exchanges recorded
+// earlier in the same directory are kept, new ones are appended. The next
+// transport may be nil for http.DefaultTransport.
+func NewRecordingTransport(dirPath string, next http.RoundTripper) (*RecordingTransport, error) {
+	err := os.MkdirAll(dirPath, 0770)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := exchangeFileNames(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &RecordingTransport{
+		dirPath:  dirPath,
+		next:     next,
+		sequence: len(existing),
+	}, nil
+}
+
+func (self *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		requestBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	resp, err := self.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	header := http.Header{}
+	for _, name := range recordedHeaders {
+		for _, value := range resp.Header.Values(name) {
+			header.Add(name, value)
+		}
+	}
+
+	err = self.save(Exchange{
+		Request: Request{
+			Method: req.Method,
+			Url:    req.URL.String(),
+			Body:   requestBody,
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     header,
+			Body:       responseBody,
+		},
+	}, req.URL.Path)
+	if err != nil {
+		return nil, fmt.Errorf("RecordingTransport: error saving exchange: %v", err)
+	}
+
+	return resp, nil
+}
+
+func (self *RecordingTransport) save(exchange Exchange, urlPath string) error {
+	contents, err := json.MarshalIndent(exchange, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	self.mutex.Lock()
+	self.sequence++
+	sequence := self.sequence
+	self.mutex.Unlock()
+
+	return ioutil.WriteFile(path.Join(self.dirPath, fileName(sequence, exchange.Request.Method, urlPath)), contents, 0660)
+}
+
+// ReplayTransport serves the responses recorded by a RecordingTransport
+// without any network access. Requests are matched by method and URL. A
+// request that was recorded several times gets the recorded responses in
+// order, and the last one once they are used up.
+type ReplayTransport struct {
+	mutex     sync.Mutex
+	exchanges map[string][]Exchange
+	served    map[string]int
+}
+
+func NewReplayTransport(dirPath string) (*ReplayTransport, error) {
+	exchanges, err := readExchanges(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := map[string][]Exchange{}
+	for _, exchange := range exchanges {
+		byKey[exchange.key()] = append(byKey[exchange.key()], exchange)
+	}
+
+	return &ReplayTransport{
+		exchanges: byKey,
+		served:    map[string]int{},
+	}, nil
+}
+
+func (self *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	key := req.Method + " " + req.URL.String()
+
+	self.mutex.Lock()
+	exchanges := self.exchanges[key]
+	index := self.served[key]
+	if index < len(exchanges)-1 {
+		self.served[key]++
+	}
+	self.mutex.Unlock()
+
+	if len(exchanges) == 0 {
+		return nil, fmt.Errorf("ReplayTransport: no recorded response for %s", key)
+	}
+
+	recorded := exchanges[index].Response
+	header := http.Header{}
+	for name, values := range recorded.Header {
+		header[name] = append([]string{}, values...)
+	}
+
+	return &http.Response{
+		Status:        strconv.Itoa(recorded.StatusCode) + " " + http.StatusText(recorded.StatusCode),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,155 @@
+package replay_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/replay"
+
+	"bytes"
+	json2 "encoding/json"
+	"github.com/onsi/gomega/ghttp"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Transports", func() {
+	var tempDir string
+	var server *ghttp.Server
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "test")
+		Expect(err).To(BeNil())
+
+		server = ghttp.NewServer()
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v1/me"),
+				ghttp.RespondWith(200, `{"id": "user-id"}`, http.Header{
+					"Etag":       {`"etag"`},
+					"Set-Cookie": {"session=secret"},
+				}),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v1/users/user-id/playlists"),
+				ghttp.VerifyBody([]byte("plain text")),
+				ghttp.RespondWith(201, "created"),
+			),
+			ghttp.RespondWith(200, `{"id": "other-user-id"}`),
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	request := func(client *http.Client, method string, url string, body string) (*http.Response, string) {
+		req, err := http.NewRequest(method, url, bytes.NewReader([]byte(body)))
+		Expect(err).To(BeNil())
+		req.Header.Set("Authorization", "Bearer access-token")
+
+		resp, err := client.Do(req)
+		Expect(err).To(BeNil())
+
+		contents, err := ioutil.ReadAll(resp.Body)
+		Expect(err).To(BeNil())
+		Expect(resp.Body.Close()).To(Succeed())
+		return resp, string(contents)
+	}
+
+	record := func() {
+		recorder, err := NewRecordingTransport(tempDir, nil)
+		Expect(err).To(BeNil())
+		client := &http.Client{Transport: recorder}
+
+		request(client, "GET", server.URL()+"/v1/me", "")
+		request(client, "POST", server.URL()+"/v1/users/user-id/playlists", "plain text")
+		request(client, "GET", server.URL()+"/v1/me", "")
+	}
+
+	It("Passes responses through while recording them", func() {
+		recorder, err := NewRecordingTransport(tempDir, nil)
+		Expect(err).To(BeNil())
+		client := &http.Client{Transport: recorder}
+
+		resp, body := request(client, "GET", server.URL()+"/v1/me", "")
+
+		Expect(resp.StatusCode).To(Equal(200))
+		Expect(body).To(Equal(`{"id": "user-id"}`))
+	})
+
+	It("Saves each exchange in a numbered file", func() {
+		record()
+
+		files, err := ioutil.ReadDir(tempDir)
+		Expect(err).To(BeNil())
+		Expect(files).To(HaveLen(3))
+		Expect(files[0].Name()).To(Equal("00001-GET-v1-me.json"))
+		Expect(files[1].Name()).To(Equal("00002-POST-v1-users-user-id-playlists.json"))
+
+		contents, err := ioutil.ReadFile(path.Join(tempDir, files[0].Name()))
+		Expect(err).To(BeNil())
+
+		exchange := Exchange{}
+		Expect(json2.Unmarshal(contents, &exchange)).To(Succeed())
+		Expect(exchange.Request.Method).To(Equal("GET"))
+		Expect(exchange.Request.Url).To(Equal(server.URL() + "/v1/me"))
+		Expect(exchange.Response.StatusCode).To(Equal(200))
+		Expect(exchange.Response.Header.Get("ETag")).To(Equal(`"etag"`))
+		Expect(string(contents)).To(ContainSubstring(`"id": "user-id"`))
+	})
+
+	It("Does not record credentials", func() {
+		record()
+
+		files, err := ioutil.ReadDir(tempDir)
+		Expect(err).To(BeNil())
+		for _, file := range files {
+			contents, err := ioutil.ReadFile(path.Join(tempDir, file.Name()))
+			Expect(err).To(BeNil())
+			Expect(string(contents)).ToNot(ContainSubstring("access-token"))
+			Expect(string(contents)).ToNot(ContainSubstring("secret"))
+		}
+	})
+
+	It("Replays recorded responses in order without network access", func() {
+		record()
+		serverUrl := server.URL()
+		server.Close()
+
+		replayer, err := NewReplayTransport(tempDir)
+		Expect(err).To(BeNil())
+		client := &http.Client{Transport: replayer}
+
+		resp, body := request(client, "GET", serverUrl+"/v1/me", "")
+		Expect(resp.StatusCode).To(Equal(200))
+		Expect(resp.Header.Get("ETag")).To(Equal(`"etag"`))
+		Expect(body).To(MatchJSON(`{"id": "user-id"}`))
+
+		resp, body = request(client, "POST", serverUrl+"/v1/users/user-id/playlists", "ignored")
+		Expect(resp.StatusCode).To(Equal(201))
+		Expect(body).To(Equal("created"))
+
+		_, body = request(client, "GET", serverUrl+"/v1/me", "")
+		Expect(body).To(MatchJSON(`{"id": "other-user-id"}`))
+
+		_, body = request(client, "GET", serverUrl+"/v1/me", "")
+		Expect(body).To(MatchJSON(`{"id": "other-user-id"}`))
+	})
+
+	It("Fails requests that were not recorded", func() {
+		record()
+
+		replayer, err := NewReplayTransport(tempDir)
+		Expect(err).To(BeNil())
+		client := &http.Client{Transport: replayer}
+
+		_, err = client.Get(server.URL() + "/v1/albums")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("no recorded response for GET"))
+	})
+})
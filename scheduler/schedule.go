@@ -0,0 +1,168 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a cron-like schedule with the five fields minute, hour, day of
+// month, month and day of week. Each field can be "*", a number, a range
+// ("1-5"), a step ("*/15", "0-30/10") or a comma-separated list of these.
+// As in cron, a time matches if either day field matches when both are
+// restricted.
+type Schedule struct {
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+	anyDay      bool
+	anyWeekday  bool
+}
+
+type fieldRange struct {
+	name string
+	min  int
+	max  int
+}
+
+var fieldRanges = []fieldRange{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Next matching times are searched for this far into the future.
+const MAX_SEARCH_YEARS int = 5
+
+func ParseSchedule(spec string) (*Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != len(fieldRanges) {
+		return nil, fmt.Errorf("ParseSchedule: expected %d fields, got %d in %q", len(fieldRanges), len(fields), spec)
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, field := range fields {
+		var err error
+		bits[i], err = parseField(field, fieldRanges[i])
+		if err != nil {
+			return nil, fmt.Errorf("ParseSchedule: %v", err)
+		}
+	}
+
+	// Sunday can be written as 0 or 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return &Schedule{
+		minutes:     bits[0],
+		hours:       bits[1],
+		daysOfMonth: bits[2],
+		months:      bits[3],
+		daysOfWeek:  bits[4],
+		anyDay:      fields[2] == "*",
+		anyWeekday:  fields[4] == "*",
+	}, nil
+}
+
+func parseField(field string, fieldRange fieldRange) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		from, to, step := fieldRange.min, fieldRange.max, 1
+
+		rangePart := part
+		slash := strings.Index(part, "/")
+		if slash >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[slash+1:])
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %s field %q", fieldRange.name, part)
+			}
+			rangePart = part[:slash]
+		}
+
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			from, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return 0, fmt.Errorf("invalid value in %s field %q", fieldRange.name, part)
+			}
+
+			to = from
+			if len(bounds) == 2 {
+				to, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return 0, fmt.Errorf("invalid value in %s field %q", fieldRange.name, part)
+				}
+			} else if slash >= 0 {
+				to = fieldRange.max
+			}
+		}
+
+		if from < fieldRange.min || to > fieldRange.max || from > to {
+			return 0, fmt.Errorf("%s field %q out of range %d-%d", fieldRange.name, part, fieldRange.min, fieldRange.max)
+		}
+
+		for value := from; value <= to; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+
+	return bits, nil
+}
+
+// Next returns the first matching time after the given time, or the zero time
+// if the schedule does not match within MAX_SEARCH_YEARS.
+func (self *Schedule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), after.Minute(), 0, 0, loc).Add(time.Minute)
+	limit := t.AddDate(MAX_SEARCH_YEARS, 0, 0)
+
+	for t.Before(limit) {
+		if !has(self.months, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !self.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !has(self.hours, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+
+		if !has(self.minutes, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (self *Schedule) matchesDay(t time.Time) bool {
+	dayMatches := has(self.daysOfMonth, t.Day())
+	weekdayMatches := has(self.daysOfWeek, int(t.Weekday()))
+
+	if self.anyDay || self.anyWeekday {
+		return dayMatches && weekdayMatches
+	}
+
+	return dayMatches || weekdayMatches
+}
+
+func has(bits uint64, value int) bool {
+	return bits&(1<<uint(value)) != 0
+}
@@ -0,0 +1,72 @@
+package scheduler_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/scheduler"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("Schedule", func() {
+	var parse = func(spec string) *Schedule {
+		schedule, err := ParseSchedule(spec)
+		Expect(err).To(BeNil())
+		return schedule
+	}
+
+	var at = func(value string) time.Time {
+		t, err := time.Parse("2006-01-02 15:04", value)
+		Expect(err).To(BeNil())
+		return t
+	}
+
+	It("Finds the next weekly run", func() {
+		// 2017-01-01 is a Sunday
+		schedule := parse("0 6 * * 5")
+
+		Expect(schedule.Next(at("2017-01-01 12:00"))).To(Equal(at("2017-01-06 06:00")))
+		Expect(schedule.Next(at("2017-01-06 06:00"))).To(Equal(at("2017-01-13 06:00")))
+		Expect(schedule.Next(at("2017-01-06 05:59"))).To(Equal(at("2017-01-06 06:00")))
+	})
+
+	It("Supports lists, ranges and steps", func() {
+		schedule := parse("*/15 9-10 * * 1,3")
+
+		Expect(schedule.Next(at("2017-01-01 12:00"))).To(Equal(at("2017-01-02 09:00")))
+		Expect(schedule.Next(at("2017-01-02 09:00"))).To(Equal(at("2017-01-02 09:15")))
+		Expect(schedule.Next(at("2017-01-02 10:45"))).To(Equal(at("2017-01-04 09:00")))
+	})
+
+	It("Treats 7 as Sunday", func() {
+		schedule := parse("30 8 * * 7")
+
+		Expect(schedule.Next(at("2017-01-02 00:00"))).To(Equal(at("2017-01-08 08:30")))
+	})
+
+	It("Matches either day field if both are restricted", func() {
+		schedule := parse("0 0 15 * 1")
+
+		Expect(schedule.Next(at("2017-01-01 12:00"))).To(Equal(at("2017-01-02 00:00")))
+		Expect(schedule.Next(at("2017-01-09 00:00"))).To(Equal(at("2017-01-15 00:00")))
+	})
+
+	It("Skips months without a matching day", func() {
+		schedule := parse("0 0 31 * *")
+
+		Expect(schedule.Next(at("2017-01-31 00:00"))).To(Equal(at("2017-03-31 00:00")))
+	})
+
+	It("Returns the zero time if the schedule never matches", func() {
+		schedule := parse("0 0 30 2 *")
+
+		Expect(schedule.Next(at("2017-01-01 00:00")).IsZero()).To(BeTrue())
+	})
+
+	It("Rejects invalid schedules", func() {
+		for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+			_, err := ParseSchedule(spec)
+			Expect(err).ToNot(BeNil(), spec)
+		}
+	})
+})
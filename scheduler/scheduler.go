@@ -0,0 +1,219 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andreasf/spotify-weekly-releases/api"
+	"github.com/andreasf/spotify-weekly-releases/auth"
+	"github.com/andreasf/spotify-weekly-releases/platform"
+	"github.com/andreasf/spotify-weekly-releases/services"
+	"github.com/andreasf/spotify-weekly-releases/store"
+	"log"
+	"sync"
+	"time"
+)
+
+var ErrAlreadyRunning = errors.New("scheduler: playlist is already being created")
+
+// ServiceFactory creates a SpotifyService acting on behalf of the user whose
+// tokens are provided by the given token source. Empty albumGroups select the
+// defaults.
+type ServiceFactory func(tokens api.TokenSource, albumGroups []string) services.SpotifyService
+
+// Scheduler regenerates the playlists of all subscribers according to a
+// schedule and records the outcome of each run in the subscriber store.
+type Scheduler struct {
+	schedule    *Schedule
+	subscribers store.SubscriberStore
+	tokenClient *auth.TokenClient
+	newService  ServiceFactory
+	timeWrapper platform.Time
+	mutex       sync.Mutex
+	running     map[string]bool
+	// finished is signalled whenever a run finishes, see Wait
+	finished *sync.Cond
+}
+
+func NewScheduler(schedule *Schedule, subscribers store.SubscriberStore, tokenClient *auth.TokenClient, newService ServiceFactory, timeWrapper platform.Time) *Scheduler {
+	scheduler := &Scheduler{
+		schedule:    schedule,
+		subscribers: subscribers,
+		tokenClient: tokenClient,
+		newService:  newService,
+		timeWrapper: timeWrapper,
+		running:     make(map[string]bool),
+	}
+	scheduler.finished = sync.NewCond(&scheduler.mutex)
+
+	return scheduler
+}
+
+// Run sleeps until the next scheduled time and then runs all subscribers,
+// until ctx is done. Cancelling ctx also cancels the current run.
+func (self *Scheduler) Run(ctx context.Context) {
+	var last time.Time
+
+	for {
+		now := self.timeWrapper.Now()
+		after := now
+		if after.Before(last) {
+			after = last
+		}
+
+		next := self.schedule.Next(after)
+		if next.IsZero() {
+			log.Printf("Scheduler: schedule never matches, stopping")
+			return
+		}
+
+		log.Printf("Scheduler: next run at %s", next.Format(time.RFC3339))
+		err := self.timeWrapper.SleepContext(ctx, next.Sub(now))
+		if err != nil {
+			log.Printf("Scheduler: stopping: %v", err)
+			return
+		}
+
+		self.RunAll(ctx)
+		last = next
+	}
+}
+
+// RunAll creates new playlists for all subscribers, one after another. It
+// stops once ctx is done.
+func (self *Scheduler) RunAll(ctx context.Context) {
+	subscribers, err := self.subscribers.List()
+	if err != nil {
+		log.Printf("RunAll: error listing subscribers: %v", err)
+		return
+	}
+
+	for _, subscriber := range subscribers {
+		if ctx.Err() != nil {
+			log.Printf("RunAll: stopping: %v", ctx.Err())
+			return
+		}
+
+		err := self.RunSubscriber(ctx, subscriber.Id)
+		if err != nil {
+			log.Printf("RunAll: %v", err)
+		}
+	}
+}
+
+// RunSubscriber creates a new playlist for the given subscriber, or updates
+// their persistent playlist, and records the time and outcome of the run.
+func (self *Scheduler) RunSubscriber(ctx context.Context, userId string) error {
+	if !self.start(userId) {
+		return ErrAlreadyRunning
+	}
+	defer self.finish(userId)
+
+	return self.runSubscriber(ctx, userId)
+}
+
+// StartSubscriber runs RunSubscriber in the background. It returns false if a
+// playlist is already being created for the subscriber. The run is cancelled
+// when ctx is done, so ctx should outlive the caller, e.g. an HTTP request.
+func (self *Scheduler) StartSubscriber(ctx context.Context, userId string) bool {
+	if !self.start(userId) {
+		return false
+	}
+
+	go func() {
+		defer self.finish(userId)
+
+		err := self.runSubscriber(ctx, userId)
+		if err != nil {
+			log.Printf("StartSubscriber: %v", err)
+		}
+	}()
+
+	return true
+}
+
+func (self *Scheduler) runSubscriber(ctx context.Context, userId string) error {
+	subscriber, err := self.subscribers.Get(userId)
+	if err != nil {
+		return fmt.Errorf("runSubscriber: error retrieving subscriber %s: %v", userId, err)
+	}
+
+	tokens := auth.NewAuthenticator(self.tokenClient, store.NewSubscriberTokenStore(self.subscribers, userId), self.timeWrapper)
+	service := self.newService(tokens, subscriber.AlbumGroups)
+
+	startedAt := self.timeWrapper.Now()
+
+	var report services.RunReport
+	var runErr error
+	if subscriber.PersistentPlaylist {
+		playlistId := ""
+		if subscriber.LastPlaylistName == services.PERSISTENT_PLAYLIST_NAME {
+			playlistId = subscriber.LastPlaylistId
+		}
+		report, runErr = service.UpdateWeeklyPlaylist(ctx, playlistId, services.PERSISTENT_PLAYLIST_NAME)
+	} else {
+		report, runErr = service.CreateWeeklyPlaylist(ctx, services.WeeklyPlaylistName(startedAt))
+	}
+
+	// only the results are written, settings and tokens may have changed during the run
+	err = self.subscribers.Update(userId, func(subscriber *store.Subscriber) error {
+		subscriber.LastRunAt = startedAt
+		subscriber.LastReport = &report
+		if runErr != nil {
+			subscriber.LastError = runErr.Error()
+		} else {
+			subscriber.LastPlaylistId = report.PlaylistId
+			subscriber.LastPlaylistName = report.PlaylistName
+			subscriber.LastTrackCount = report.TracksAdded
+			subscriber.LastError = ""
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("runSubscriber: error saving subscriber %s: %v", userId, err)
+	}
+
+	if runErr != nil {
+		return fmt.Errorf("runSubscriber: error creating playlist for %s: %v", userId, runErr)
+	}
+
+	return nil
+}
+
+func (self *Scheduler) start(userId string) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if self.running[userId] {
+		return false
+	}
+
+	self.running[userId] = true
+	return true
+}
+
+func (self *Scheduler) finish(userId string) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	delete(self.running, userId)
+	self.finished.Broadcast()
+}
+
+// Wait blocks until no subscriber is being run, e.g. to let the runs
+// cancelled on shutdown finish before closing the cache.
+func (self *Scheduler) Wait() {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	for len(self.running) > 0 {
+		self.finished.Wait()
+	}
+}
+
+func (self *Scheduler) IsRunning(userId string) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return self.running[userId]
+}
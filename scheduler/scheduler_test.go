@@ -0,0 +1,319 @@
+package scheduler_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/scheduler"
+
+	"context"
+	"errors"
+	"github.com/andreasf/spotify-weekly-releases/api"
+	"github.com/andreasf/spotify-weekly-releases/auth"
+	"github.com/andreasf/spotify-weekly-releases/platform/platformfakes"
+	"github.com/andreasf/spotify-weekly-releases/services"
+	"github.com/andreasf/spotify-weekly-releases/services/servicesfakes"
+	"github.com/andreasf/spotify-weekly-releases/store"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+var _ = Describe("Scheduler", func() {
+	var tempDir string
+	var subscribers *store.FileSubscriberStore
+	var service *servicesfakes.FakeSpotifyService
+	var timeWrapper *platformfakes.FakeTime
+	var now time.Time
+	var playlistScheduler *Scheduler
+	var serviceAlbumGroups [][]string
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var err error
+		tempDir, err = ioutil.TempDir("", "test")
+		Expect(err).To(BeNil())
+		subscribers = store.NewFileSubscriberStore(tempDir)
+
+		for _, userId := range []string{"bar", "foo"} {
+			Expect(subscribers.Save(store.Subscriber{
+				Id: userId,
+				Token: auth.Token{
+					AccessToken:  userId + "-access-token",
+					RefreshToken: userId + "-refresh-token",
+					Expiry:       time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+				},
+			})).To(Succeed())
+		}
+
+		// 2017-01-01 is a Sunday
+		now = time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+		timeWrapper = &platformfakes.FakeTime{}
+		timeWrapper.NowStub = func() time.Time {
+			return now
+		}
+
+		service = &servicesfakes.FakeSpotifyService{}
+		service.CreateWeeklyPlaylistStub = func(_ context.Context, name string) (services.RunReport, error) {
+			return services.RunReport{PlaylistId: "playlist-id", PlaylistName: name, TracksAdded: 3}, nil
+		}
+
+		schedule, err := ParseSchedule("0 6 * * 5")
+		Expect(err).To(BeNil())
+
+		tokenClient := auth.NewTokenClient(auth.Config{}, timeWrapper)
+		serviceAlbumGroups = [][]string{}
+		newService := func(tokens api.TokenSource, albumGroups []string) services.SpotifyService {
+			serviceAlbumGroups = append(serviceAlbumGroups, albumGroups)
+			return service
+		}
+		playlistScheduler = NewScheduler(schedule, subscribers, tokenClient, newService, timeWrapper)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	Describe("RunAll", func() {
+		It("Creates a playlist for every subscriber and records the result", func() {
+			playlistScheduler.RunAll(ctx)
+
+			Expect(service.CreateWeeklyPlaylistCallCount()).To(Equal(2))
+			Expect(playlistName(service, 0)).To(Equal("Weekly Releases - 2017-01-01"))
+
+			subscriber, err := subscribers.Get("foo")
+			Expect(err).To(BeNil())
+			Expect(subscriber.LastRunAt).To(Equal(now))
+			Expect(subscriber.LastPlaylistId).To(Equal("playlist-id"))
+			Expect(subscriber.LastPlaylistName).To(Equal("Weekly Releases - 2017-01-01"))
+			Expect(subscriber.LastTrackCount).To(Equal(3))
+			Expect(subscriber.LastError).To(BeEmpty())
+			Expect(subscriber.LastReport).To(Equal(&services.RunReport{
+				PlaylistId:   "playlist-id",
+				PlaylistName: "Weekly Releases - 2017-01-01",
+				TracksAdded:  3,
+			}))
+		})
+
+		It("Records failures and continues with the next subscriber", func() {
+			service.CreateWeeklyPlaylistStub = func(_ context.Context, name string) (services.RunReport, error) {
+				if service.CreateWeeklyPlaylistCallCount() == 1 {
+					return services.RunReport{PlaylistName: name, ArtistsScanned: 7}, errors.New("something broke")
+				}
+				return services.RunReport{PlaylistId: "playlist-id", PlaylistName: name}, nil
+			}
+
+			playlistScheduler.RunAll(ctx)
+
+			bar, err := subscribers.Get("bar")
+			Expect(err).To(BeNil())
+			Expect(bar.LastRunAt).To(Equal(now))
+			Expect(bar.LastSuccessAt.IsZero()).To(BeTrue())
+			Expect(bar.LastError).To(ContainSubstring("something broke"))
+			Expect(bar.LastReport.ArtistsScanned).To(Equal(7))
+
+			foo, err := subscribers.Get("foo")
+			Expect(err).To(BeNil())
+			Expect(foo.LastPlaylistId).To(Equal("playlist-id"))
+			Expect(foo.LastError).To(BeEmpty())
+		})
+
+		It("Leaves the last successful run to the service", func() {
+			recordedAt := now.Add(-time.Minute)
+			service.CreateWeeklyPlaylistStub = func(_ context.Context, name string) (services.RunReport, error) {
+				Expect(store.NewSubscriberLastRunStore(subscribers).SetLastRun("foo", recordedAt)).To(Succeed())
+				return services.RunReport{PlaylistId: "playlist-id", PlaylistName: name}, nil
+			}
+
+			Expect(playlistScheduler.RunSubscriber(ctx, "foo")).To(Succeed())
+			Expect(playlistScheduler.RunSubscriber(ctx, "bar")).To(Succeed())
+
+			foo, err := subscribers.Get("foo")
+			Expect(err).To(BeNil())
+			Expect(foo.LastRunAt).To(Equal(now))
+			Expect(foo.LastSuccessAt).To(Equal(recordedAt))
+
+			bar, err := subscribers.Get("bar")
+			Expect(err).To(BeNil())
+			Expect(bar.LastSuccessAt.IsZero()).To(BeTrue())
+		})
+
+		It("Keeps the tokens and settings saved during the run", func() {
+			service.CreateWeeklyPlaylistStub = func(_ context.Context, name string) (services.RunReport, error) {
+				Expect(store.NewSubscriberTokenStore(subscribers, "foo").Save(auth.Token{RefreshToken: "rotated"})).To(Succeed())
+				Expect(subscribers.Update("foo", func(subscriber *store.Subscriber) error {
+					subscriber.PersistentPlaylist = true
+					return nil
+				})).To(Succeed())
+				return services.RunReport{PlaylistId: "playlist-id", PlaylistName: name}, nil
+			}
+
+			Expect(playlistScheduler.RunSubscriber(ctx, "foo")).To(Succeed())
+
+			subscriber, err := subscribers.Get("foo")
+			Expect(err).To(BeNil())
+			Expect(subscriber.Token.RefreshToken).To(Equal("rotated"))
+			Expect(subscriber.PersistentPlaylist).To(BeTrue())
+			Expect(subscriber.LastPlaylistId).To(Equal("playlist-id"))
+		})
+
+		It("Does not start further subscribers once the context is cancelled", func() {
+			ctx, cancel := context.WithCancel(ctx)
+			service.CreateWeeklyPlaylistStub = func(_ context.Context, name string) (services.RunReport, error) {
+				cancel()
+				return services.RunReport{}, context.Canceled
+			}
+
+			playlistScheduler.RunAll(ctx)
+
+			Expect(service.CreateWeeklyPlaylistCallCount()).To(Equal(1))
+
+			foo, err := subscribers.Get("foo")
+			Expect(err).To(BeNil())
+			Expect(foo.LastRunAt.IsZero()).To(BeTrue())
+		})
+	})
+
+	Describe("Persistent playlists", func() {
+		BeforeEach(func() {
+			service.UpdateWeeklyPlaylistStub = func(_ context.Context, playlistId string, name string) (services.RunReport, error) {
+				return services.RunReport{PlaylistId: "persistent-playlist-id", PlaylistName: name, TracksAdded: 5}, nil
+			}
+
+			subscriber, err := subscribers.Get("foo")
+			Expect(err).To(BeNil())
+			subscriber.PersistentPlaylist = true
+			Expect(subscribers.Save(subscriber)).To(Succeed())
+		})
+
+		It("Updates the persistent playlist instead of creating a new one", func() {
+			Expect(playlistScheduler.RunSubscriber(ctx, "foo")).To(Succeed())
+			Expect(playlistScheduler.RunSubscriber(ctx, "foo")).To(Succeed())
+
+			Expect(service.CreateWeeklyPlaylistCallCount()).To(Equal(0))
+			Expect(service.UpdateWeeklyPlaylistCallCount()).To(Equal(2))
+
+			_, playlistId, name := service.UpdateWeeklyPlaylistArgsForCall(0)
+			Expect(playlistId).To(BeEmpty())
+			Expect(name).To(Equal("Weekly Releases"))
+
+			_, playlistId, _ = service.UpdateWeeklyPlaylistArgsForCall(1)
+			Expect(playlistId).To(Equal("persistent-playlist-id"))
+
+			subscriber, err := subscribers.Get("foo")
+			Expect(err).To(BeNil())
+			Expect(subscriber.LastPlaylistId).To(Equal("persistent-playlist-id"))
+			Expect(subscriber.LastTrackCount).To(Equal(5))
+		})
+
+		It("Does not update a dated playlist from an earlier run", func() {
+			Expect(playlistScheduler.RunSubscriber(ctx, "bar")).To(Succeed())
+
+			subscriber, err := subscribers.Get("bar")
+			Expect(err).To(BeNil())
+			subscriber.PersistentPlaylist = true
+			Expect(subscribers.Save(subscriber)).To(Succeed())
+
+			Expect(playlistScheduler.RunSubscriber(ctx, "bar")).To(Succeed())
+
+			_, playlistId, _ := service.UpdateWeeklyPlaylistArgsForCall(0)
+			Expect(playlistId).To(BeEmpty())
+		})
+	})
+
+	It("Creates the service with the subscriber's album groups", func() {
+		subscriber, err := subscribers.Get("foo")
+		Expect(err).To(BeNil())
+		subscriber.AlbumGroups = []string{"single", "appears_on"}
+		Expect(subscribers.Save(subscriber)).To(Succeed())
+
+		Expect(playlistScheduler.RunSubscriber(ctx, "foo")).To(Succeed())
+
+		Expect(serviceAlbumGroups).To(Equal([][]string{{"single", "appears_on"}}))
+	})
+
+	Describe("Run", func() {
+		It("Sleeps until each scheduled time and then runs all subscribers", func() {
+			ctx, cancel := context.WithCancel(ctx)
+			runsAtSleep := []int{}
+
+			timeWrapper.SleepContextStub = func(ctx context.Context, d time.Duration) error {
+				runsAtSleep = append(runsAtSleep, service.CreateWeeklyPlaylistCallCount())
+				now = now.Add(d)
+				if len(runsAtSleep) == 3 {
+					cancel()
+				}
+				return ctx.Err()
+			}
+
+			playlistScheduler.Run(ctx)
+
+			Expect(timeWrapper.SleepContextCallCount()).To(Equal(3))
+			Expect(sleptFor(timeWrapper, 0)).To(Equal(4*24*time.Hour + 18*time.Hour))
+			Expect(sleptFor(timeWrapper, 1)).To(Equal(7 * 24 * time.Hour))
+			Expect(sleptFor(timeWrapper, 2)).To(Equal(7 * 24 * time.Hour))
+			Expect(runsAtSleep).To(Equal([]int{0, 2, 4}))
+
+			Expect(playlistName(service, 0)).To(Equal("Weekly Releases - 2017-01-06"))
+			Expect(playlistName(service, 2)).To(Equal("Weekly Releases - 2017-01-13"))
+		})
+	})
+
+	Describe("RunSubscriber", func() {
+		It("Refuses to run twice for the same subscriber at the same time", func() {
+			release := make(chan struct{})
+			service.CreateWeeklyPlaylistStub = func(_ context.Context, name string) (services.RunReport, error) {
+				<-release
+				return services.RunReport{PlaylistId: "playlist-id", PlaylistName: name}, nil
+			}
+
+			Expect(playlistScheduler.StartSubscriber(ctx, "foo")).To(BeTrue())
+			Expect(playlistScheduler.IsRunning("foo")).To(BeTrue())
+			Expect(playlistScheduler.StartSubscriber(ctx, "foo")).To(BeFalse())
+			Expect(playlistScheduler.RunSubscriber(ctx, "foo")).To(Equal(ErrAlreadyRunning))
+
+			close(release)
+			Eventually(func() bool { return playlistScheduler.IsRunning("foo") }).Should(BeFalse())
+			Expect(service.CreateWeeklyPlaylistCallCount()).To(Equal(1))
+		})
+	})
+
+	Describe("Wait", func() {
+		It("Returns once the runs in the background have finished", func() {
+			release := make(chan struct{})
+			service.CreateWeeklyPlaylistStub = func(_ context.Context, name string) (services.RunReport, error) {
+				<-release
+				return services.RunReport{PlaylistId: "playlist-id", PlaylistName: name}, nil
+			}
+
+			Expect(playlistScheduler.StartSubscriber(ctx, "foo")).To(BeTrue())
+			Expect(playlistScheduler.StartSubscriber(ctx, "bar")).To(BeTrue())
+
+			waited := make(chan struct{})
+			go func() {
+				playlistScheduler.Wait()
+				close(waited)
+			}()
+			Consistently(waited).ShouldNot(BeClosed())
+
+			close(release)
+			Eventually(waited).Should(BeClosed())
+			Expect(playlistScheduler.IsRunning("foo")).To(BeFalse())
+			Expect(playlistScheduler.IsRunning("bar")).To(BeFalse())
+		})
+
+		It("Returns immediately if nothing is running", func() {
+			playlistScheduler.Wait()
+		})
+	})
+})
+
+func sleptFor(timeWrapper *platformfakes.FakeTime, call int) time.Duration {
+	_, d := timeWrapper.SleepContextArgsForCall(call)
+	return d
+}
+
+func playlistName(service *servicesfakes.FakeSpotifyService, call int) string {
+	_, name := service.CreateWeeklyPlaylistArgsForCall(call)
+	return name
+}
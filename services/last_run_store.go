@@ -0,0 +1,12 @@
+package services
+
+import "time"
+
+//go:generate counterfeiter . LastRunStore
+
+// LastRunStore remembers when a playlist was last created successfully for a
+// user. GetLastRun returns the zero time if there has been no run yet.
+type LastRunStore interface {
+	GetLastRun(userId string) (time.Time, error)
+	SetLastRun(userId string, lastRun time.Time) error
+}
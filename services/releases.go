@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andreasf/spotify-weekly-releases/api"
+	"github.com/andreasf/spotify-weekly-releases/auth"
+	"github.com/andreasf/spotify-weekly-releases/model"
+	"strings"
+)
+
+// Releases are the recent releases of a user. In tolerant mode (see
+// Options.Tolerant), Skipped lists what could not be retrieved, so that the
+// albums may be incomplete.
+type Releases struct {
+	Albums  []model.Album
+	Skipped []Skipped
+}
+
+// Skipped is an artist whose albums, or a batch of albums whose details could
+// not be retrieved. Exactly one of ArtistId and AlbumIds is set.
+type Skipped struct {
+	ArtistId string
+	AlbumIds []string
+	Err      error
+}
+
+func (self Skipped) String() string {
+	if self.ArtistId != "" {
+		return fmt.Sprintf("artist %s: %v", self.ArtistId, self.Err)
+	}
+
+	return fmt.Sprintf("albums %s: %v", strings.Join(self.AlbumIds, ","), self.Err)
+}
+
+// canSkip returns whether a request may be skipped in tolerant mode. Errors
+// that would make all further requests fail as well abort the run instead:
+// cancellation, an access token that is rejected even after refreshing it or
+// that cannot be refreshed, a Retry-After period longer than allowed, and
+// the rate limiting budget of the run being used up.
+func canSkip(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) && apiErr.IsUnauthorized() {
+		return false
+	}
+
+	var authErr *auth.Error
+	if errors.As(err, &authErr) {
+		return false
+	}
+
+	var budgetErr *api.RetryBudgetError
+	if errors.As(err, &budgetErr) && (budgetErr.Limit == api.MAX_RETRY_AFTER || budgetErr.Limit == api.MAX_RETRY_AFTER_PER_CLIENT) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/andreasf/spotify-weekly-releases/api"
+	"io"
+	"time"
+)
+
+// RunReport describes a run of CreateWeeklyPlaylist or UpdateWeeklyPlaylist.
+// Of the albums found, those saved by the user, those in skipped batches,
+// those without tracks, those released before the last run and duplicates are
+// removed, and one track of each remaining album is added to the playlist. For
+// successful runs, AlbumsFound is therefore the sum of the removed albums and
+// TracksAdded.
+//
+// SkippedAlbums counts the albums of skipped batches, whose details could not
+// be retrieved. The albums of skipped artists are not found in the first
+// place, so they are not counted at all.
+type RunReport struct {
+	StartedAt    time.Time `json:"started_at"`
+	PlaylistId   string    `json:"playlist_id,omitempty"`
+	PlaylistName string    `json:"playlist_name"`
+
+	ArtistsScanned      int `json:"artists_scanned"`
+	AlbumsFound         int `json:"albums_found"`
+	RemovedAsSaved      int `json:"removed_as_saved"`
+	SkippedAlbums       int `json:"skipped_albums"`
+	WithoutTracks       int `json:"without_tracks"`
+	FilteredByDate      int `json:"filtered_by_date"`
+	RemovedAsDuplicates int `json:"removed_as_duplicates"`
+	TracksAdded         int `json:"tracks_added"`
+
+	// Skipped is only ever non-empty in tolerant mode, see Options.Tolerant.
+	Skipped []Skipped `json:"skipped,omitempty"`
+
+	Api       api.Stats `json:"api"`
+	Durations Durations `json:"durations"`
+}
+
+// Durations of the phases of a run: retrieving the followed artists and saved
+// albums, the albums of the artists, the details of the albums, and creating
+// or updating the playlist.
+type Durations struct {
+	Artists      Duration `json:"artists"`
+	ArtistAlbums Duration `json:"artist_albums"`
+	AlbumDetails Duration `json:"album_details"`
+	Playlist     Duration `json:"playlist"`
+	Total        Duration `json:"total"`
+}
+
+// Duration is a time.Duration that is readable in JSON, e.g. "1m30s".
+type Duration time.Duration
+
+func (self Duration) String() string {
+	return time.Duration(self).String()
+}
+
+func (self Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(self.String())
+}
+
+func (self *Duration) UnmarshalJSON(data []byte) error {
+	text := ""
+	err := json.Unmarshal(data, &text)
+	if err != nil {
+		return err
+	}
+
+	d, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+
+	*self = Duration(d)
+	return nil
+}
+
+type skippedJson struct {
+	ArtistId string   `json:"artist_id,omitempty"`
+	AlbumIds []string `json:"album_ids,omitempty"`
+	Error    string   `json:"error"`
+}
+
+// MarshalJSON stores the error message only, so a stored report's errors
+// cannot be inspected with errors.As anymore.
+func (self Skipped) MarshalJSON() ([]byte, error) {
+	message := ""
+	if self.Err != nil {
+		message = self.Err.Error()
+	}
+
+	return json.Marshal(skippedJson{
+		ArtistId: self.ArtistId,
+		AlbumIds: self.AlbumIds,
+		Error:    message,
+	})
+}
+
+func (self *Skipped) UnmarshalJSON(data []byte) error {
+	skipped := skippedJson{}
+	err := json.Unmarshal(data, &skipped)
+	if err != nil {
+		return err
+	}
+
+	*self = Skipped{
+		ArtistId: skipped.ArtistId,
+		AlbumIds: skipped.AlbumIds,
+		Err:      errors.New(skipped.Error),
+	}
+	return nil
+}
+
+// WriteText writes the report in a human readable form.
+func (self RunReport) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, `Playlist "%s": %d tracks added
+Artists scanned:       %d
+Albums found:          %d
+Removed as saved:      %d
+Skipped albums:        %d
+Without tracks:        %d
+Filtered by date:      %d
+Removed as duplicates: %d
+API requests:          %d (%d retries, %d rate limited)
+Cache:                 %d hits, %d misses, %d revalidated
+Durations:             artists %s, artist albums %s, album details %s, playlist %s, total %s
+`,
+		self.PlaylistName, self.TracksAdded,
+		self.ArtistsScanned,
+		self.AlbumsFound,
+		self.RemovedAsSaved,
+		self.SkippedAlbums,
+		self.WithoutTracks,
+		self.FilteredByDate,
+		self.RemovedAsDuplicates,
+		self.Api.Requests, self.Api.Retries, self.Api.RateLimited,
+		self.Api.CacheHits, self.Api.CacheMisses, self.Api.Revalidated,
+		self.Durations.Artists, self.Durations.ArtistAlbums, self.Durations.AlbumDetails, self.Durations.Playlist, self.Durations.Total)
+	if err != nil {
+		return err
+	}
+
+	if len(self.Skipped) > 0 {
+		_, err = fmt.Fprintf(w, "Skipped:               %d\n", len(self.Skipped))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, skipped := range self.Skipped {
+		_, err = fmt.Fprintf(w, "  %s\n", skipped)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
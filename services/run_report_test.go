@@ -0,0 +1,93 @@
+package services_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/services"
+
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/andreasf/spotify-weekly-releases/api"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("RunReport", func() {
+	var report RunReport
+
+	BeforeEach(func() {
+		report = RunReport{
+			StartedAt:           time.Date(2017, 1, 1, 6, 0, 0, 0, time.UTC),
+			PlaylistId:          "playlist-id",
+			PlaylistName:        "Weekly Releases - 2017-01-01",
+			ArtistsScanned:      120,
+			AlbumsFound:         802,
+			RemovedAsSaved:      30,
+			SkippedAlbums:       2,
+			WithoutTracks:       2,
+			FilteredByDate:      750,
+			RemovedAsDuplicates: 2,
+			TracksAdded:         16,
+			Skipped: []Skipped{
+				{ArtistId: "artist-id", Err: errors.New("received 404 for GET /artists/artist-id/albums")},
+				{AlbumIds: []string{"album-1", "album-2"}, Err: errors.New("received 500 for GET /albums")},
+			},
+			Api: api.Stats{Requests: 130, Retries: 2, RateLimited: 1, CacheHits: 700, CacheMisses: 120, Revalidated: 90},
+			Durations: Durations{
+				Artists:      Duration(2 * time.Second),
+				ArtistAlbums: Duration(90 * time.Second),
+				AlbumDetails: Duration(5 * time.Second),
+				Playlist:     Duration(time.Second),
+				Total:        Duration(98 * time.Second),
+			},
+		}
+	})
+
+	It("Can be stored as JSON", func() {
+		data, err := json.Marshal(report)
+		Expect(err).To(BeNil())
+		Expect(string(data)).To(ContainSubstring(`"total":"1m38s"`))
+		Expect(string(data)).To(ContainSubstring(`"error":"received 500 for GET /albums"`))
+
+		restored := RunReport{}
+		Expect(json.Unmarshal(data, &restored)).To(Succeed())
+
+		Expect(restored.Durations).To(Equal(report.Durations))
+		Expect(restored.Api).To(Equal(report.Api))
+		Expect(restored.TracksAdded).To(Equal(16))
+		Expect(restored.Skipped).To(HaveLen(2))
+		Expect(restored.Skipped[1].AlbumIds).To(Equal([]string{"album-1", "album-2"}))
+		Expect(restored.Skipped[1].Err).To(MatchError("received 500 for GET /albums"))
+	})
+
+	It("Rejects invalid durations", func() {
+		restored := RunReport{}
+		err := json.Unmarshal([]byte(`{"durations":{"total":"forever"}}`), &restored)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("Writes the statistics and skipped items as text", func() {
+		buffer := &bytes.Buffer{}
+		Expect(report.WriteText(buffer)).To(Succeed())
+
+		text := buffer.String()
+		Expect(text).To(ContainSubstring(`Playlist "Weekly Releases - 2017-01-01": 16 tracks added`))
+		Expect(text).To(ContainSubstring("Skipped albums:        2\n"))
+		Expect(text).To(ContainSubstring("Without tracks:        2\n"))
+		Expect(text).To(ContainSubstring("Filtered by date:      750\n"))
+		Expect(text).To(ContainSubstring("API requests:          130 (2 retries, 1 rate limited)\n"))
+		Expect(text).To(ContainSubstring("Cache:                 700 hits, 120 misses, 90 revalidated\n"))
+		Expect(text).To(ContainSubstring("artist albums 1m30s"))
+		Expect(text).To(ContainSubstring("Skipped:               2\n"))
+		Expect(text).To(ContainSubstring("  albums album-1,album-2: received 500 for GET /albums\n"))
+	})
+
+	It("Omits the skipped items if there are none", func() {
+		report.Skipped = nil
+
+		buffer := &bytes.Buffer{}
+		Expect(report.WriteText(buffer)).To(Succeed())
+
+		Expect(buffer.String()).NotTo(ContainSubstring("Skipped:"))
+	})
+})
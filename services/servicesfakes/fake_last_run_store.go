@@ -0,0 +1,122 @@
+// This file was generated by counterfeiter
+package servicesfakes
+
+import (
+	"sync"
+	"time"
+
+	"github.com/andreasf/spotify-weekly-releases/services"
+)
+
+type FakeLastRunStore struct {
+	GetLastRunStub        func(userId string) (time.Time, error)
+	getLastRunMutex       sync.RWMutex
+	getLastRunArgsForCall []struct {
+		userId string
+	}
+	getLastRunReturns struct {
+		result1 time.Time
+		result2 error
+	}
+	SetLastRunStub        func(userId string, lastRun time.Time) error
+	setLastRunMutex       sync.RWMutex
+	setLastRunArgsForCall []struct {
+		userId  string
+		lastRun time.Time
+	}
+	setLastRunReturns struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeLastRunStore) GetLastRun(userId string) (time.Time, error) {
+	fake.getLastRunMutex.Lock()
+	fake.getLastRunArgsForCall = append(fake.getLastRunArgsForCall, struct {
+		userId string
+	}{userId})
+	fake.recordInvocation("GetLastRun", []interface{}{userId})
+	fake.getLastRunMutex.Unlock()
+	if fake.GetLastRunStub != nil {
+		return fake.GetLastRunStub(userId)
+	}
+	return fake.getLastRunReturns.result1, fake.getLastRunReturns.result2
+}
+
+func (fake *FakeLastRunStore) GetLastRunCallCount() int {
+	fake.getLastRunMutex.RLock()
+	defer fake.getLastRunMutex.RUnlock()
+	return len(fake.getLastRunArgsForCall)
+}
+
+func (fake *FakeLastRunStore) GetLastRunArgsForCall(i int) string {
+	fake.getLastRunMutex.RLock()
+	defer fake.getLastRunMutex.RUnlock()
+	return fake.getLastRunArgsForCall[i].userId
+}
+
+func (fake *FakeLastRunStore) GetLastRunReturns(result1 time.Time, result2 error) {
+	fake.GetLastRunStub = nil
+	fake.getLastRunReturns = struct {
+		result1 time.Time
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLastRunStore) SetLastRun(userId string, lastRun time.Time) error {
+	fake.setLastRunMutex.Lock()
+	fake.setLastRunArgsForCall = append(fake.setLastRunArgsForCall, struct {
+		userId  string
+		lastRun time.Time
+	}{userId, lastRun})
+	fake.recordInvocation("SetLastRun", []interface{}{userId, lastRun})
+	fake.setLastRunMutex.Unlock()
+	if fake.SetLastRunStub != nil {
+		return fake.SetLastRunStub(userId, lastRun)
+	}
+	return fake.setLastRunReturns.result1
+}
+
+func (fake *FakeLastRunStore) SetLastRunCallCount() int {
+	fake.setLastRunMutex.RLock()
+	defer fake.setLastRunMutex.RUnlock()
+	return len(fake.setLastRunArgsForCall)
+}
+
+func (fake *FakeLastRunStore) SetLastRunArgsForCall(i int) (string, time.Time) {
+	fake.setLastRunMutex.RLock()
+	defer fake.setLastRunMutex.RUnlock()
+	return fake.setLastRunArgsForCall[i].userId, fake.setLastRunArgsForCall[i].lastRun
+}
+
+func (fake *FakeLastRunStore) SetLastRunReturns(result1 error) {
+	fake.SetLastRunStub = nil
+	fake.setLastRunReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLastRunStore) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getLastRunMutex.RLock()
+	defer fake.getLastRunMutex.RUnlock()
+	fake.setLastRunMutex.RLock()
+	defer fake.setLastRunMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeLastRunStore) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ services.LastRunStore = new(FakeLastRunStore)
@@ -0,0 +1,325 @@
+// This file was generated by counterfeiter
+package servicesfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/andreasf/spotify-weekly-releases/model"
+	"github.com/andreasf/spotify-weekly-releases/services"
+)
+
+type FakeSpotifyService struct {
+	GetUserProfileStub        func(ctx context.Context) (model.UserProfile, error)
+	getUserProfileMutex       sync.RWMutex
+	getUserProfileArgsForCall []struct {
+		ctx context.Context
+	}
+	getUserProfileReturns struct {
+		result1 model.UserProfile
+		result2 error
+	}
+	GetRecentReleasesStub        func(ctx context.Context) (services.Releases, error)
+	getRecentReleasesMutex       sync.RWMutex
+	getRecentReleasesArgsForCall []struct {
+		ctx context.Context
+	}
+	getRecentReleasesReturns struct {
+		result1 services.Releases
+		result2 error
+	}
+	CreatePlaylistStub        func(ctx context.Context, name string, tracks []model.Track) (string, error)
+	createPlaylistMutex       sync.RWMutex
+	createPlaylistArgsForCall []struct {
+		ctx    context.Context
+		name   string
+		tracks []model.Track
+	}
+	createPlaylistReturns struct {
+		result1 string
+		result2 error
+	}
+	CreateWeeklyPlaylistStub        func(ctx context.Context, name string) (services.RunReport, error)
+	createWeeklyPlaylistMutex       sync.RWMutex
+	createWeeklyPlaylistArgsForCall []struct {
+		ctx  context.Context
+		name string
+	}
+	createWeeklyPlaylistReturns struct {
+		result1 services.RunReport
+		result2 error
+	}
+	UpdatePlaylistStub        func(ctx context.Context, playlistId string, name string, tracks []model.Track) (string, error)
+	updatePlaylistMutex       sync.RWMutex
+	updatePlaylistArgsForCall []struct {
+		ctx        context.Context
+		playlistId string
+		name       string
+		tracks     []model.Track
+	}
+	updatePlaylistReturns struct {
+		result1 string
+		result2 error
+	}
+	UpdateWeeklyPlaylistStub        func(ctx context.Context, playlistId string, name string) (services.RunReport, error)
+	updateWeeklyPlaylistMutex       sync.RWMutex
+	updateWeeklyPlaylistArgsForCall []struct {
+		ctx        context.Context
+		playlistId string
+		name       string
+	}
+	updateWeeklyPlaylistReturns struct {
+		result1 services.RunReport
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeSpotifyService) GetUserProfile(ctx context.Context) (model.UserProfile, error) {
+	fake.getUserProfileMutex.Lock()
+	fake.getUserProfileArgsForCall = append(fake.getUserProfileArgsForCall, struct {
+		ctx context.Context
+	}{ctx})
+	fake.recordInvocation("GetUserProfile", []interface{}{ctx})
+	fake.getUserProfileMutex.Unlock()
+	if fake.GetUserProfileStub != nil {
+		return fake.GetUserProfileStub(ctx)
+	}
+	return fake.getUserProfileReturns.result1, fake.getUserProfileReturns.result2
+}
+
+func (fake *FakeSpotifyService) GetUserProfileCallCount() int {
+	fake.getUserProfileMutex.RLock()
+	defer fake.getUserProfileMutex.RUnlock()
+	return len(fake.getUserProfileArgsForCall)
+}
+
+func (fake *FakeSpotifyService) GetUserProfileArgsForCall(i int) context.Context {
+	fake.getUserProfileMutex.RLock()
+	defer fake.getUserProfileMutex.RUnlock()
+	return fake.getUserProfileArgsForCall[i].ctx
+}
+
+func (fake *FakeSpotifyService) GetUserProfileReturns(result1 model.UserProfile, result2 error) {
+	fake.GetUserProfileStub = nil
+	fake.getUserProfileReturns = struct {
+		result1 model.UserProfile
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSpotifyService) GetRecentReleases(ctx context.Context) (services.Releases, error) {
+	fake.getRecentReleasesMutex.Lock()
+	fake.getRecentReleasesArgsForCall = append(fake.getRecentReleasesArgsForCall, struct {
+		ctx context.Context
+	}{ctx})
+	fake.recordInvocation("GetRecentReleases", []interface{}{ctx})
+	fake.getRecentReleasesMutex.Unlock()
+	if fake.GetRecentReleasesStub != nil {
+		return fake.GetRecentReleasesStub(ctx)
+	}
+	return fake.getRecentReleasesReturns.result1, fake.getRecentReleasesReturns.result2
+}
+
+func (fake *FakeSpotifyService) GetRecentReleasesCallCount() int {
+	fake.getRecentReleasesMutex.RLock()
+	defer fake.getRecentReleasesMutex.RUnlock()
+	return len(fake.getRecentReleasesArgsForCall)
+}
+
+func (fake *FakeSpotifyService) GetRecentReleasesArgsForCall(i int) context.Context {
+	fake.getRecentReleasesMutex.RLock()
+	defer fake.getRecentReleasesMutex.RUnlock()
+	return fake.getRecentReleasesArgsForCall[i].ctx
+}
+
+func (fake *FakeSpotifyService) GetRecentReleasesReturns(result1 services.Releases, result2 error) {
+	fake.GetRecentReleasesStub = nil
+	fake.getRecentReleasesReturns = struct {
+		result1 services.Releases
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSpotifyService) CreatePlaylist(ctx context.Context, name string, tracks []model.Track) (string, error) {
+	var tracksCopy []model.Track
+	if tracks != nil {
+		tracksCopy = make([]model.Track, len(tracks))
+		copy(tracksCopy, tracks)
+	}
+	fake.createPlaylistMutex.Lock()
+	fake.createPlaylistArgsForCall = append(fake.createPlaylistArgsForCall, struct {
+		ctx    context.Context
+		name   string
+		tracks []model.Track
+	}{ctx, name, tracksCopy})
+	fake.recordInvocation("CreatePlaylist", []interface{}{ctx, name, tracksCopy})
+	fake.createPlaylistMutex.Unlock()
+	if fake.CreatePlaylistStub != nil {
+		return fake.CreatePlaylistStub(ctx, name, tracks)
+	}
+	return fake.createPlaylistReturns.result1, fake.createPlaylistReturns.result2
+}
+
+func (fake *FakeSpotifyService) CreatePlaylistCallCount() int {
+	fake.createPlaylistMutex.RLock()
+	defer fake.createPlaylistMutex.RUnlock()
+	return len(fake.createPlaylistArgsForCall)
+}
+
+func (fake *FakeSpotifyService) CreatePlaylistArgsForCall(i int) (context.Context, string, []model.Track) {
+	fake.createPlaylistMutex.RLock()
+	defer fake.createPlaylistMutex.RUnlock()
+	return fake.createPlaylistArgsForCall[i].ctx, fake.createPlaylistArgsForCall[i].name, fake.createPlaylistArgsForCall[i].tracks
+}
+
+func (fake *FakeSpotifyService) CreatePlaylistReturns(result1 string, result2 error) {
+	fake.CreatePlaylistStub = nil
+	fake.createPlaylistReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSpotifyService) CreateWeeklyPlaylist(ctx context.Context, name string) (services.RunReport, error) {
+	fake.createWeeklyPlaylistMutex.Lock()
+	fake.createWeeklyPlaylistArgsForCall = append(fake.createWeeklyPlaylistArgsForCall, struct {
+		ctx  context.Context
+		name string
+	}{ctx, name})
+	fake.recordInvocation("CreateWeeklyPlaylist", []interface{}{ctx, name})
+	fake.createWeeklyPlaylistMutex.Unlock()
+	if fake.CreateWeeklyPlaylistStub != nil {
+		return fake.CreateWeeklyPlaylistStub(ctx, name)
+	}
+	return fake.createWeeklyPlaylistReturns.result1, fake.createWeeklyPlaylistReturns.result2
+}
+
+func (fake *FakeSpotifyService) CreateWeeklyPlaylistCallCount() int {
+	fake.createWeeklyPlaylistMutex.RLock()
+	defer fake.createWeeklyPlaylistMutex.RUnlock()
+	return len(fake.createWeeklyPlaylistArgsForCall)
+}
+
+func (fake *FakeSpotifyService) CreateWeeklyPlaylistArgsForCall(i int) (context.Context, string) {
+	fake.createWeeklyPlaylistMutex.RLock()
+	defer fake.createWeeklyPlaylistMutex.RUnlock()
+	return fake.createWeeklyPlaylistArgsForCall[i].ctx, fake.createWeeklyPlaylistArgsForCall[i].name
+}
+
+func (fake *FakeSpotifyService) CreateWeeklyPlaylistReturns(result1 services.RunReport, result2 error) {
+	fake.CreateWeeklyPlaylistStub = nil
+	fake.createWeeklyPlaylistReturns = struct {
+		result1 services.RunReport
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSpotifyService) UpdatePlaylist(ctx context.Context, playlistId string, name string, tracks []model.Track) (string, error) {
+	var tracksCopy []model.Track
+	if tracks != nil {
+		tracksCopy = make([]model.Track, len(tracks))
+		copy(tracksCopy, tracks)
+	}
+	fake.updatePlaylistMutex.Lock()
+	fake.updatePlaylistArgsForCall = append(fake.updatePlaylistArgsForCall, struct {
+		ctx        context.Context
+		playlistId string
+		name       string
+		tracks     []model.Track
+	}{ctx, playlistId, name, tracksCopy})
+	fake.recordInvocation("UpdatePlaylist", []interface{}{ctx, playlistId, name, tracksCopy})
+	fake.updatePlaylistMutex.Unlock()
+	if fake.UpdatePlaylistStub != nil {
+		return fake.UpdatePlaylistStub(ctx, playlistId, name, tracks)
+	}
+	return fake.updatePlaylistReturns.result1, fake.updatePlaylistReturns.result2
+}
+
+func (fake *FakeSpotifyService) UpdatePlaylistCallCount() int {
+	fake.updatePlaylistMutex.RLock()
+	defer fake.updatePlaylistMutex.RUnlock()
+	return len(fake.updatePlaylistArgsForCall)
+}
+
+func (fake *FakeSpotifyService) UpdatePlaylistArgsForCall(i int) (context.Context, string, string, []model.Track) {
+	fake.updatePlaylistMutex.RLock()
+	defer fake.updatePlaylistMutex.RUnlock()
+	return fake.updatePlaylistArgsForCall[i].ctx, fake.updatePlaylistArgsForCall[i].playlistId, fake.updatePlaylistArgsForCall[i].name, fake.updatePlaylistArgsForCall[i].tracks
+}
+
+func (fake *FakeSpotifyService) UpdatePlaylistReturns(result1 string, result2 error) {
+	fake.UpdatePlaylistStub = nil
+	fake.updatePlaylistReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSpotifyService) UpdateWeeklyPlaylist(ctx context.Context, playlistId string, name string) (services.RunReport, error) {
+	fake.updateWeeklyPlaylistMutex.Lock()
+	fake.updateWeeklyPlaylistArgsForCall = append(fake.updateWeeklyPlaylistArgsForCall, struct {
+		ctx        context.Context
+		playlistId string
+		name       string
+	}{ctx, playlistId, name})
+	fake.recordInvocation("UpdateWeeklyPlaylist", []interface{}{ctx, playlistId, name})
+	fake.updateWeeklyPlaylistMutex.Unlock()
+	if fake.UpdateWeeklyPlaylistStub != nil {
+		return fake.UpdateWeeklyPlaylistStub(ctx, playlistId, name)
+	}
+	return fake.updateWeeklyPlaylistReturns.result1, fake.updateWeeklyPlaylistReturns.result2
+}
+
+func (fake *FakeSpotifyService) UpdateWeeklyPlaylistCallCount() int {
+	fake.updateWeeklyPlaylistMutex.RLock()
+	defer fake.updateWeeklyPlaylistMutex.RUnlock()
+	return len(fake.updateWeeklyPlaylistArgsForCall)
+}
+
+func (fake *FakeSpotifyService) UpdateWeeklyPlaylistArgsForCall(i int) (context.Context, string, string) {
+	fake.updateWeeklyPlaylistMutex.RLock()
+	defer fake.updateWeeklyPlaylistMutex.RUnlock()
+	return fake.updateWeeklyPlaylistArgsForCall[i].ctx, fake.updateWeeklyPlaylistArgsForCall[i].playlistId, fake.updateWeeklyPlaylistArgsForCall[i].name
+}
+
+func (fake *FakeSpotifyService) UpdateWeeklyPlaylistReturns(result1 services.RunReport, result2 error) {
+	fake.UpdateWeeklyPlaylistStub = nil
+	fake.updateWeeklyPlaylistReturns = struct {
+		result1 services.RunReport
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSpotifyService) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getUserProfileMutex.RLock()
+	defer fake.getUserProfileMutex.RUnlock()
+	fake.getRecentReleasesMutex.RLock()
+	defer fake.getRecentReleasesMutex.RUnlock()
+	fake.createPlaylistMutex.RLock()
+	defer fake.createPlaylistMutex.RUnlock()
+	fake.createWeeklyPlaylistMutex.RLock()
+	defer fake.createWeeklyPlaylistMutex.RUnlock()
+	fake.updatePlaylistMutex.RLock()
+	defer fake.updatePlaylistMutex.RUnlock()
+	fake.updateWeeklyPlaylistMutex.RLock()
+	defer fake.updateWeeklyPlaylistMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeSpotifyService) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ services.SpotifyService = new(FakeSpotifyService)
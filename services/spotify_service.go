@@ -1,87 +1,269 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/andreasf/spotify-weekly-releases/api"
 	"github.com/andreasf/spotify-weekly-releases/model"
 	"github.com/andreasf/spotify-weekly-releases/platform"
+	"log"
+	"sync"
 	"time"
 )
 
+//go:generate counterfeiter . SpotifyService
 type SpotifyService interface {
-	GetRecentReleases(accessToken string) ([]model.Album, error)
-	CreatePlaylist(accessToken string, name string, tracks []model.Track) error
+	GetUserProfile(ctx context.Context) (model.UserProfile, error)
+	GetRecentReleases(ctx context.Context) (Releases, error)
+	CreatePlaylist(ctx context.Context, name string, tracks []model.Track) (string, error)
+	CreateWeeklyPlaylist(ctx context.Context, name string) (RunReport, error)
+	UpdatePlaylist(ctx context.Context, playlistId string, name string, tracks []model.Track) (string, error)
+	UpdateWeeklyPlaylist(ctx context.Context, playlistId string, name string) (RunReport, error)
 }
 
 type SpotifyServiceImpl struct {
 	apiClient   api.SpotifyConnector
+	lastRuns    LastRunStore
 	timeWrapper platform.Time
+	options     Options
 }
 
+// Options configures a SpotifyServiceImpl. Zero values select the defaults.
+type Options struct {
+	// FallbackWindow is how far back releases are included when there has
+	// been no successful run for the user yet.
+	FallbackWindow time.Duration
+
+	// AlbumGroups selects which releases of an artist are included, see
+	// model.ALBUM_GROUPS. Defaults to model.DEFAULT_ALBUM_GROUPS.
+	AlbumGroups []string
+
+	// Workers is the number of artists whose albums are retrieved
+	// concurrently. Defaults to DEFAULT_WORKERS.
+	Workers int
+
+	// Tolerant skips artists and batches of albums that cannot be retrieved,
+	// instead of failing. The skipped requests are listed in Releases. Runs
+	// that skipped anything are not recorded as the last successful run, so
+	// that the next run includes the skipped releases.
+	Tolerant bool
+}
+
+// ErrNoCountry is returned for users whose profile has no country, because
+// they logged in before the user-read-private scope was requested. Without
+// it, releases that cannot be played in the user's country would be
+// included.
+var ErrNoCountry = errors.New("user profile has no country, log in again to grant access to it")
+
 const ALBUMS_PER_REQUEST int = 20
+const PLAYLIST_NAME_PREFIX string = "Weekly Releases - "
+const PERSISTENT_PLAYLIST_NAME string = "Weekly Releases"
+const DEFAULT_FALLBACK_WINDOW time.Duration = 365 * 24 * time.Hour
+const DEFAULT_WORKERS int = 4
+
+func NewSpotifyService(apiClient api.SpotifyConnector, lastRuns LastRunStore, timeWrapper platform.Time, options Options) *SpotifyServiceImpl {
+	if options.FallbackWindow <= 0 {
+		options.FallbackWindow = DEFAULT_FALLBACK_WINDOW
+	}
+	if len(options.AlbumGroups) == 0 {
+		options.AlbumGroups = model.DEFAULT_ALBUM_GROUPS
+	}
+	if options.Workers <= 0 {
+		options.Workers = DEFAULT_WORKERS
+	}
 
-func NewSpotifyService(apiClient api.SpotifyConnector, timeWrapper platform.Time) *SpotifyServiceImpl {
 	return &SpotifyServiceImpl{
 		apiClient:   apiClient,
+		lastRuns:    lastRuns,
 		timeWrapper: timeWrapper,
+		options:     options,
 	}
 }
 
-func (self *SpotifyServiceImpl) GetRecentReleases(accessToken string) ([]model.Album, error) {
-	profile, err := self.apiClient.GetUserProfile(accessToken)
+func WeeklyPlaylistName(date time.Time) string {
+	return PLAYLIST_NAME_PREFIX + date.Format("2006-01-02")
+}
+
+func (self *SpotifyServiceImpl) GetUserProfile(ctx context.Context) (model.UserProfile, error) {
+	profile, err := self.apiClient.GetUserProfile(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("GetRecentReleases: error retrieving user profile: %v", err)
+		return model.UserProfile{}, fmt.Errorf("GetUserProfile: %w", err)
 	}
 
+	return profile, nil
+}
+
+// GetRecentReleases returns the releases since the last successful run, or
+// within the fallback window if there has been none.
+func (self *SpotifyServiceImpl) GetRecentReleases(ctx context.Context) (Releases, error) {
+	profile, err := self.apiClient.GetUserProfile(ctx)
+	if err != nil {
+		return Releases{}, fmt.Errorf("GetRecentReleases: error retrieving user profile: %w", err)
+	}
+
+	releases, err := self.getRecentReleases(ctx, profile, &RunReport{})
+	if err != nil {
+		return Releases{}, fmt.Errorf("GetRecentReleases: %w", err)
+	}
+
+	return releases, nil
+}
+
+// getRecentReleases adds its counts and durations to report.
+func (self *SpotifyServiceImpl) getRecentReleases(ctx context.Context, profile model.UserProfile, report *RunReport) (Releases, error) {
+	if profile.Country == "" {
+		return Releases{}, ErrNoCountry
+	}
+
+	since, err := self.releasesSince(profile.Id)
+	if err != nil {
+		return Releases{}, err
+	}
+
+	startedAt := self.timeWrapper.Now()
+
 	var artists model.ArtistList
-	artists, err = self.apiClient.GetFollowedArtists(accessToken)
+	artists, err = self.apiClient.GetFollowedArtists(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("GetRecentReleases: error retrieving followed artists: %v", err)
+		return Releases{}, fmt.Errorf("error retrieving followed artists: %w", err)
 	}
 
 	artistIds := artists.GetIds()
 
 	var savedAlbums model.AlbumList
-	savedAlbums, err = self.apiClient.GetSavedAlbums(accessToken)
+	savedAlbums, err = self.apiClient.GetSavedAlbums(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("GetRecentReleases: error retrieving saved albums: %v", err)
+		return Releases{}, fmt.Errorf("error retrieving saved albums: %w", err)
 	}
 
-	artistIds = append(artistIds, savedAlbums.GetArtistIds()...)
+	artistIds = uniqueIds(append(artistIds, savedAlbums.GetArtistIds()...))
+	report.ArtistsScanned = len(artistIds)
+	startedAt = self.measure(&report.Durations.Artists, startedAt)
 
 	var albums model.AlbumList
-	albums, err = self.getAlbumsForArtists(accessToken, profile.Country, artistIds)
+	albums, skippedArtists, err := self.getAlbumsForArtists(ctx, profile.Country, artistIds)
+	startedAt = self.measure(&report.Durations.ArtistAlbums, startedAt)
 	if err != nil {
-		return nil, fmt.Errorf("GetRecentReleases: %v", err)
+		return Releases{}, err
 	}
+	report.AlbumsFound = len(albums)
+	report.Skipped = append(report.Skipped, skippedArtists...)
 
 	albums = albums.Remove(savedAlbums)
+	report.RemovedAsSaved = report.AlbumsFound - len(albums)
 
-	albumDetails, err := self.getAlbumDetails(accessToken, albums)
+	albumDetails, skippedAlbums, err := self.getAlbumDetails(ctx, albums)
+	self.measure(&report.Durations.AlbumDetails, startedAt)
 	if err != nil {
-		return nil, fmt.Errorf("GetRecentReleases: %v", err)
+		return Releases{}, err
 	}
+	report.Skipped = append(report.Skipped, skippedAlbums...)
+	for _, skipped := range skippedAlbums {
+		report.SkippedAlbums += len(skipped.AlbumIds)
+	}
+	report.WithoutTracks = len(albums) - report.SkippedAlbums - len(albumDetails)
+
+	recentAlbums := filterByReleaseDate(albumDetails, since)
+	report.FilteredByDate = len(albumDetails) - len(recentAlbums)
 
-	return self.filterByReleaseDate(albumDetails), nil
+	return Releases{
+		Albums:  recentAlbums,
+		Skipped: append(skippedArtists, skippedAlbums...),
+	}, nil
 }
 
-func (self *SpotifyServiceImpl) getAlbumsForArtists(accessToken string, country string, artistIds []string) ([]model.Album, error) {
-	visitedArtists := make(map[string]bool)
-	visitedAlbums := make(map[string]bool)
-	albums := []model.Album{}
+// measure sets d to the time since startedAt and returns the current time.
+func (self *SpotifyServiceImpl) measure(d *Duration, startedAt time.Time) time.Time {
+	now := self.timeWrapper.Now()
+	*d = Duration(now.Sub(startedAt))
+	return now
+}
 
-	for _, artistId := range artistIds {
-		_, visited := visitedArtists[artistId]
-		if visited {
-			continue
+func (self *SpotifyServiceImpl) releasesSince(userId string) (time.Time, error) {
+	lastRun, err := self.lastRuns.GetLastRun(userId)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("releasesSince: error retrieving last run: %w", err)
+	}
+
+	if lastRun.IsZero() {
+		return self.timeWrapper.Now().Add(-self.options.FallbackWindow), nil
+	}
+
+	return lastRun, nil
+}
+
+// getAlbumsForArtists retrieves the albums of each artist once, using up to
+// Options.Workers concurrent requests. The result is in the order of the
+// artists, with each album only included the first time it is found. No
+// further artists are requested after an error or once ctx is done. In
+// tolerant mode, artists that can be skipped are returned instead of an
+// error, in the same order.
+func (self *SpotifyServiceImpl) getAlbumsForArtists(ctx context.Context, country string, artistIds []string) ([]model.Album, []Skipped, error) {
+	artistIds = uniqueIds(artistIds)
+	artistAlbums := make([][]model.Album, len(artistIds))
+	artistErrs := make([]error, len(artistIds))
+
+	var mutex sync.Mutex
+	var firstErr error
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+
+	for worker := 0; worker < min(self.options.Workers, len(artistIds)); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indices {
+				albums, err := self.apiClient.GetArtistAlbums(ctx, artistIds[i], country, self.options.AlbumGroups)
+
+				mutex.Lock()
+				if err != nil && self.options.Tolerant && canSkip(err) {
+					artistErrs[i] = err
+				} else if err != nil && firstErr == nil {
+					firstErr = fmt.Errorf("getAlbumsForArtists: error retrieving artistAlbums for %s: %w", artistIds[i], err)
+				}
+				artistAlbums[i] = albums
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for i := range artistIds {
+		mutex.Lock()
+		if firstErr == nil && ctx.Err() != nil {
+			firstErr = fmt.Errorf("getAlbumsForArtists: %w", ctx.Err())
+		}
+		failed := firstErr != nil
+		mutex.Unlock()
+
+		if failed {
+			break
 		}
 
-		artistAlbums, err := self.apiClient.GetArtistAlbums(accessToken, artistId, country)
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+
+	skipped := []Skipped{}
+	for i, err := range artistErrs {
 		if err != nil {
-			return nil, fmt.Errorf("getAlbumsForArtists: error retrieving artistAlbums for %s: %v", artistId, err)
+			log.Printf("getAlbumsForArtists: skipping artist %s: %v", artistIds[i], err)
+			skipped = append(skipped, Skipped{ArtistId: artistIds[i], Err: err})
 		}
+	}
 
-		for _, album := range artistAlbums {
+	visitedAlbums := make(map[string]bool)
+	albums := []model.Album{}
+
+	for _, albumsOfArtist := range artistAlbums {
+		for _, album := range albumsOfArtist {
 			_, visited := visitedAlbums[album.Id]
 			if visited {
 				continue
@@ -90,15 +272,16 @@ func (self *SpotifyServiceImpl) getAlbumsForArtists(accessToken string, country
 			albums = append(albums, album)
 			visitedAlbums[album.Id] = true
 		}
-
-		visitedArtists[artistId] = true
 	}
 
-	return albums, nil
+	return albums, skipped, nil
 }
 
-func (self *SpotifyServiceImpl) getAlbumDetails(accessToken string, albums []model.Album) ([]model.Album, error) {
+// getAlbumDetails retrieves the albums in batches. In tolerant mode, batches
+// that can be skipped are returned instead of an error.
+func (self *SpotifyServiceImpl) getAlbumDetails(ctx context.Context, albums []model.Album) ([]model.Album, []Skipped, error) {
 	albumDetails := make([]model.Album, 0, len(albums))
+	skipped := []Skipped{}
 
 	numberOfRequests := len(albums) / ALBUMS_PER_REQUEST
 	if len(albums)%ALBUMS_PER_REQUEST > 0 {
@@ -111,25 +294,30 @@ func (self *SpotifyServiceImpl) getAlbumDetails(accessToken string, albums []mod
 		albumSlice := albums[from:to]
 		albumIds := getAlbumIds(albumSlice)
 
-		albumInfos, err := self.apiClient.GetAlbumInfo(accessToken, albumIds)
+		albumInfos, err := self.apiClient.GetAlbumInfo(ctx, albumIds)
+		if err != nil && self.options.Tolerant && canSkip(err) {
+			log.Printf("getAlbumDetails: skipping albums %s: %v", albumIds, err)
+			skipped = append(skipped, Skipped{AlbumIds: albumIds, Err: err})
+			continue
+		}
 		if err != nil {
-			return nil, fmt.Errorf("getAlbumDetails: error retrieving album infos for %s: %v", albumIds, err)
+			return nil, nil, fmt.Errorf("getAlbumDetails: error retrieving album infos for %s: %w", albumIds, err)
 		}
 
-		albumDetails = append(albumDetails, albumInfos...)
+		albumDetails = append(albumDetails, withAlbumGroups(albumInfos, albumSlice)...)
 	}
 
-	return albumDetails, nil
+	return albumDetails, skipped, nil
 }
 
-func (self *SpotifyServiceImpl) filterByReleaseDate(albums []model.Album) []model.Album {
+// filterByReleaseDate keeps albums that may have been released after since,
+// i.e. whose release date interval ends after it. This includes releases from
+// the day of since, as well as releases known only by year or month.
+func filterByReleaseDate(albums []model.Album, since time.Time) []model.Album {
 	filteredAlbums := make([]model.Album, 0, len(albums))
 
-	oneYearAgoDate := self.timeWrapper.Now().Add(time.Hour * 24 * (-365))
-	oneYearAgo := oneYearAgoDate.Format("2006-01-02")
-
 	for _, album := range albums {
-		if album.ReleaseDate >= oneYearAgo {
+		if album.ReleaseDate.ReleasedAfter(since) {
 			filteredAlbums = append(filteredAlbums, album)
 		}
 	}
@@ -137,23 +325,231 @@ func (self *SpotifyServiceImpl) filterByReleaseDate(albums []model.Album) []mode
 	return filteredAlbums
 }
 
-func (self *SpotifyServiceImpl) CreatePlaylist(accessToken string, name string, tracks []model.Track) error {
-	userProfile, err := self.apiClient.GetUserProfile(accessToken)
+func (self *SpotifyServiceImpl) CreatePlaylist(ctx context.Context, name string, tracks []model.Track) (string, error) {
+	userProfile, err := self.apiClient.GetUserProfile(ctx)
 	if err != nil {
-		return fmt.Errorf("CreatePlaylist: error retrieving user profile: %v", err)
+		return "", fmt.Errorf("CreatePlaylist: error retrieving user profile: %w", err)
 	}
 
-	playlistId, err := self.apiClient.CreatePlaylist(accessToken, userProfile.Id, name)
+	playlistId, err := self.createPlaylist(ctx, userProfile.Id, name, tracks)
 	if err != nil {
-		return fmt.Errorf("CreatePlaylist: error creating playlist: %v", err)
+		return "", fmt.Errorf("CreatePlaylist: %w", err)
 	}
 
-	err = self.apiClient.AddTracksToPlaylist(accessToken, userProfile.Id, playlistId, tracks)
+	return playlistId, nil
+}
+
+func (self *SpotifyServiceImpl) createPlaylist(ctx context.Context, userId string, name string, tracks []model.Track) (string, error) {
+	playlistId, err := self.apiClient.CreatePlaylist(ctx, userId, name)
 	if err != nil {
-		return fmt.Errorf("CreatePlaylist: error adding tracks: %v", err)
+		return "", fmt.Errorf("error creating playlist: %w", err)
+	}
+
+	err = self.apiClient.AddTracksToPlaylist(ctx, userId, playlistId, tracks)
+	if err != nil {
+		return "", fmt.Errorf("error adding tracks: %w", err)
+	}
+
+	return playlistId, nil
+}
+
+// UpdatePlaylist replaces the tracks of an existing playlist and records the
+// date of the update in its description. The playlist is identified by id or,
+// if the id is empty or no longer among the user's playlists, by name. A new
+// playlist is created if neither matches.
+func (self *SpotifyServiceImpl) UpdatePlaylist(ctx context.Context, playlistId string, name string, tracks []model.Track) (string, error) {
+	userProfile, err := self.apiClient.GetUserProfile(ctx)
+	if err != nil {
+		return "", fmt.Errorf("UpdatePlaylist: error retrieving user profile: %w", err)
+	}
+
+	playlistId, err = self.updatePlaylist(ctx, userProfile.Id, playlistId, name, tracks)
+	if err != nil {
+		return "", fmt.Errorf("UpdatePlaylist: %w", err)
+	}
+
+	return playlistId, nil
+}
+
+func (self *SpotifyServiceImpl) updatePlaylist(ctx context.Context, userId string, playlistId string, name string, tracks []model.Track) (string, error) {
+	playlists, err := self.apiClient.GetUserPlaylists(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error retrieving playlists: %w", err)
+	}
+
+	playlistId = findPlaylist(playlists, userId, playlistId, name)
+	if playlistId == "" {
+		playlistId, err = self.apiClient.CreatePlaylist(ctx, userId, name)
+		if err != nil {
+			return "", fmt.Errorf("error creating playlist: %w", err)
+		}
+	}
+
+	err = self.apiClient.ReplacePlaylistTracks(ctx, playlistId, tracks)
+	if err != nil {
+		return "", fmt.Errorf("error replacing tracks: %w", err)
+	}
+
+	description := "Updated on " + self.timeWrapper.Now().Format("2006-01-02")
+	err = self.apiClient.ChangePlaylistDetails(ctx, playlistId, name, description)
+	if err != nil {
+		return "", fmt.Errorf("error changing playlist details: %w", err)
+	}
+
+	return playlistId, nil
+}
+
+// CreateWeeklyPlaylist creates a playlist with one sample track from each
+// recent release and records the run as the user's last successful one. The
+// report is returned even if the run fails, as far as it got.
+func (self *SpotifyServiceImpl) CreateWeeklyPlaylist(ctx context.Context, name string) (RunReport, error) {
+	report, apiStats := self.startReport(name)
+
+	profile, tracks, err := self.getWeeklyTracks(ctx, &report)
+	if err != nil {
+		return self.finishReport(report, apiStats), fmt.Errorf("CreateWeeklyPlaylist: %w", err)
+	}
+
+	startedAt := self.timeWrapper.Now()
+	playlistId, err := self.createPlaylist(ctx, profile.Id, name, tracks)
+	self.measure(&report.Durations.Playlist, startedAt)
+	if err != nil {
+		return self.finishReport(report, apiStats), fmt.Errorf("CreateWeeklyPlaylist: %w", err)
+	}
+	report.PlaylistId = playlistId
+	report.TracksAdded = len(tracks)
+
+	err = self.recordRun(profile.Id, report)
+	if err != nil {
+		return self.finishReport(report, apiStats), fmt.Errorf("CreateWeeklyPlaylist: error saving last run: %w", err)
+	}
+
+	return self.finishReport(report, apiStats), nil
+}
+
+// UpdateWeeklyPlaylist is like CreateWeeklyPlaylist, but replaces the
+// contents of a single persistent playlist (see UpdatePlaylist).
+func (self *SpotifyServiceImpl) UpdateWeeklyPlaylist(ctx context.Context, playlistId string, name string) (RunReport, error) {
+	report, apiStats := self.startReport(name)
+
+	profile, tracks, err := self.getWeeklyTracks(ctx, &report)
+	if err != nil {
+		return self.finishReport(report, apiStats), fmt.Errorf("UpdateWeeklyPlaylist: %w", err)
+	}
+
+	startedAt := self.timeWrapper.Now()
+	playlistId, err = self.updatePlaylist(ctx, profile.Id, playlistId, name, tracks)
+	self.measure(&report.Durations.Playlist, startedAt)
+	if err != nil {
+		return self.finishReport(report, apiStats), fmt.Errorf("UpdateWeeklyPlaylist: %w", err)
+	}
+	report.PlaylistId = playlistId
+	report.TracksAdded = len(tracks)
+
+	err = self.recordRun(profile.Id, report)
+	if err != nil {
+		return self.finishReport(report, apiStats), fmt.Errorf("UpdateWeeklyPlaylist: error saving last run: %w", err)
+	}
+
+	return self.finishReport(report, apiStats), nil
+}
+
+// recordRun records the run as the user's last successful one, unless it
+// skipped anything. Releases of skipped artists and albums would otherwise be
+// older than the last run next time, and never be included.
+func (self *SpotifyServiceImpl) recordRun(userId string, report RunReport) error {
+	if len(report.Skipped) > 0 {
+		log.Printf("recordRun: not recording the run as the last successful one, %d requests were skipped", len(report.Skipped))
+		return nil
+	}
+
+	return self.lastRuns.SetLastRun(userId, report.StartedAt)
+}
+
+// startReport returns a new report and the statistics of the API client at
+// its start.
+func (self *SpotifyServiceImpl) startReport(name string) (RunReport, api.Stats) {
+	return RunReport{
+		StartedAt:    self.timeWrapper.Now(),
+		PlaylistName: name,
+	}, self.apiClient.Stats()
+}
+
+func (self *SpotifyServiceImpl) finishReport(report RunReport, apiStats api.Stats) RunReport {
+	report.Api = self.apiClient.Stats().Sub(apiStats)
+	self.measure(&report.Durations.Total, report.StartedAt)
+	return report
+}
+
+func (self *SpotifyServiceImpl) getWeeklyTracks(ctx context.Context, report *RunReport) (model.UserProfile, model.TrackList, error) {
+	profile, err := self.apiClient.GetUserProfile(ctx)
+	if err != nil {
+		return model.UserProfile{}, nil, fmt.Errorf("error retrieving user profile: %w", err)
+	}
+
+	releases, err := self.getRecentReleases(ctx, profile, report)
+	if err != nil {
+		return model.UserProfile{}, nil, err
+	}
+
+	albums := model.AlbumList(releases.Albums).RemoveDuplicates()
+	sampleTracks := albums.GetSampleTracks()
+	tracks := sampleTracks.RemoveDuplicates()
+	report.WithoutTracks += len(albums) - len(sampleTracks)
+	report.RemovedAsDuplicates = len(releases.Albums) - len(albums) + len(sampleTracks) - len(tracks)
+
+	return profile, tracks, nil
+}
+
+func findPlaylist(playlists []model.Playlist, userId string, playlistId string, name string) string {
+	if playlistId != "" {
+		for _, playlist := range playlists {
+			if playlist.Id == playlistId && playlist.OwnerId == userId {
+				return playlist.Id
+			}
+		}
+	}
+
+	for _, playlist := range playlists {
+		if playlist.Name == name && playlist.OwnerId == userId {
+			return playlist.Id
+		}
+	}
+
+	return ""
+}
+
+// withAlbumGroups copies the album groups from the artist albums to the album
+// details, which are retrieved without them.
+func withAlbumGroups(albumInfos []model.Album, artistAlbums []model.Album) []model.Album {
+	albumGroups := make(map[string]string)
+	for _, album := range artistAlbums {
+		albumGroups[album.Id] = album.AlbumGroup
+	}
+
+	for i := range albumInfos {
+		if albumInfos[i].AlbumGroup == "" {
+			albumInfos[i].AlbumGroup = albumGroups[albumInfos[i].Id]
+		}
+	}
+
+	return albumInfos
+}
+
+func uniqueIds(ids []string) []string {
+	visited := make(map[string]bool)
+	unique := make([]string, 0, len(ids))
+
+	for _, id := range ids {
+		if visited[id] {
+			continue
+		}
+
+		unique = append(unique, id)
+		visited[id] = true
 	}
 
-	return nil
+	return unique
 }
 
 func getAlbumIds(albums []model.Album) []string {
@@ -3,16 +3,31 @@ package services_test
 import (
 	. "github.com/andreasf/spotify-weekly-releases/services"
 
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andreasf/spotify-weekly-releases/api"
 	"github.com/andreasf/spotify-weekly-releases/api/apifakes"
+	"github.com/andreasf/spotify-weekly-releases/auth"
 	"github.com/andreasf/spotify-weekly-releases/model"
 	"github.com/andreasf/spotify-weekly-releases/platform/platformfakes"
+	"github.com/andreasf/spotify-weekly-releases/services/servicesfakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"strconv"
+	"sync"
 	"time"
 )
 
 var _ = Describe("SpotifyService", func() {
+	var ctx context.Context
+	var lastRuns *servicesfakes.FakeLastRunStore
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		lastRuns = &servicesfakes.FakeLastRunStore{}
+	})
+
 	Describe("GetRecentReleases", func() {
 		var expectedAlbums []model.Album
 		var allArtistAlbums []model.Album
@@ -29,19 +44,19 @@ var _ = Describe("SpotifyService", func() {
 				{
 					Name:        "foo-album",
 					Id:          "foo-album-id",
-					ReleaseDate: "2017-01-01",
+					ReleaseDate: releaseDate("2017-01-01"),
 				},
 			}
 			allArtistAlbums = []model.Album{
 				{
 					Name:        "foo-album",
 					Id:          "foo-album-id",
-					ReleaseDate: "2017-01-01",
+					ReleaseDate: releaseDate("2017-01-01"),
 				},
 				{
 					Name:        "saved album",
 					Id:          "saved-album-id",
-					ReleaseDate: "2017-01-01",
+					ReleaseDate: releaseDate("2017-01-01"),
 					ArtistIds:   []string{"saved-artist-id"},
 				},
 			}
@@ -49,7 +64,7 @@ var _ = Describe("SpotifyService", func() {
 				{
 					Name:        "saved album",
 					Id:          "saved-album-id",
-					ReleaseDate: "2017-01-01",
+					ReleaseDate: releaseDate("2017-01-01"),
 					ArtistIds:   []string{"saved-artist-id"},
 				},
 			}
@@ -57,17 +72,17 @@ var _ = Describe("SpotifyService", func() {
 				{
 					Name:        "just 1 day too old. 2016 was a leap year.",
 					Id:          "baz-album-id",
-					ReleaseDate: "2016-01-01",
+					ReleaseDate: releaseDate("2016-01-01"),
 				},
 				{
 					Name:        "one year ago",
 					Id:          "foo-album-id",
-					ReleaseDate: "2016-01-02",
+					ReleaseDate: releaseDate("2016-01-02"),
 				},
 				{
 					Name:        "way too old",
 					Id:          "bar-album-id",
-					ReleaseDate: "2013-05-23",
+					ReleaseDate: releaseDate("2013-05-23"),
 				},
 			}
 			followedArtists = []model.Artist{
@@ -80,7 +95,7 @@ var _ = Describe("SpotifyService", func() {
 			fooAlbumInfo = model.Album{
 				Name:        "foo-album",
 				Id:          "foo-album-id",
-				ReleaseDate: "2017-01-01",
+				ReleaseDate: releaseDate("2017-01-01"),
 			}
 
 			client = &apifakes.FakeSpotifyConnector{}
@@ -99,31 +114,30 @@ var _ = Describe("SpotifyService", func() {
 			Expect(err).To(BeNil())
 			timeWrapper.NowReturns(now)
 
-			service = NewSpotifyService(client, timeWrapper)
+			service = NewSpotifyService(client, lastRuns, timeWrapper, Options{})
 		})
 
 		It("Gets the user profile in order to filter by country", func() {
-			_, err := service.GetRecentReleases("access-token")
+			_, err := service.GetRecentReleases(ctx)
 
 			Expect(err).To(BeNil())
 
 			Expect(client.GetUserProfileCallCount()).To(Equal(1))
-			Expect(client.GetUserProfileArgsForCall(0)).To(Equal("access-token"))
 		})
 
 		It("Gets the user's saved albums", func() {
-			_, err := service.GetRecentReleases("access-token")
+			_, err := service.GetRecentReleases(ctx)
 
 			Expect(err).To(BeNil())
 
 			Expect(client.GetSavedAlbumsCallCount()).To(Equal(1))
-			Expect(client.GetSavedAlbumsArgsForCall(0)).To(Equal("access-token"))
 		})
 
 		It("Returns a list of recent releases for the user's market", func() {
-			service := NewSpotifyService(client, timeWrapper)
+			service := NewSpotifyService(client, lastRuns, timeWrapper, Options{})
 
-			albums, err := service.GetRecentReleases("access-token")
+			releases, err := service.GetRecentReleases(ctx)
+			albums := releases.Albums
 
 			Expect(err).To(BeNil())
 			Expect(albums).To(Equal(expectedAlbums))
@@ -131,20 +145,18 @@ var _ = Describe("SpotifyService", func() {
 			Expect(client.GetFollowedArtistsCallCount()).To(Equal(1))
 			Expect(client.GetArtistAlbumsCallCount()).To(Equal(2))
 
-			token, artistId1, market1 := client.GetArtistAlbumsArgsForCall(0)
-			Expect(token).To(Equal("access-token"))
-			Expect(artistId1).To(Equal("foo-id"))
-			Expect(market1).To(Equal("market-id"))
-
-			token2, artistId2, market2 := client.GetArtistAlbumsArgsForCall(1)
-			Expect(token2).To(Equal("access-token"))
-			Expect(artistId2).To(Equal("saved-artist-id"))
-			Expect(market2).To(Equal("market-id"))
+			artistIds := []string{}
+			for i := 0; i < client.GetArtistAlbumsCallCount(); i++ {
+				_, artistId, market, albumGroups := client.GetArtistAlbumsArgsForCall(i)
+				Expect(market).To(Equal("market-id"))
+				Expect(albumGroups).To(Equal([]string{"album", "single"}))
+				artistIds = append(artistIds, artistId)
+			}
+			Expect(artistIds).To(ConsistOf("foo-id", "saved-artist-id"))
 
 			Expect(client.GetAlbumInfoCallCount()).To(Equal(1))
 
-			token1, id1 := client.GetAlbumInfoArgsForCall(0)
-			Expect(token1).To(Equal("access-token"))
+			_, id1 := client.GetAlbumInfoArgsForCall(0)
 			Expect(id1).To(Equal([]string{"foo-album-id"}))
 		})
 
@@ -161,7 +173,7 @@ var _ = Describe("SpotifyService", func() {
 				albumInfos = append(albumInfos, model.Album{
 					Name:        "album-" + strconv.Itoa(i),
 					Id:          "id-" + strconv.Itoa(i),
-					ReleaseDate: "2017-01-01",
+					ReleaseDate: releaseDate("2017-01-01"),
 				})
 
 				albumIds = append(albumIds, "id-"+strconv.Itoa(i))
@@ -170,7 +182,7 @@ var _ = Describe("SpotifyService", func() {
 			client.GetArtistAlbumsReturns(albums, nil)
 			client.GetAlbumInfoReturns(albumInfos, nil)
 
-			albums, err := service.GetRecentReleases("access-token")
+			_, err := service.GetRecentReleases(ctx)
 
 			Expect(err).To(BeNil())
 			Expect(client.GetAlbumInfoCallCount()).To(Equal(2))
@@ -185,13 +197,264 @@ var _ = Describe("SpotifyService", func() {
 			client.GetArtistAlbumsReturns(oldAlbumList, nil)
 			client.GetAlbumInfoReturns(oldAlbumList, nil)
 
-			albums, err := service.GetRecentReleases("access-token")
+			releases, err := service.GetRecentReleases(ctx)
+			albums := releases.Albums
 			Expect(err).To(BeNil())
 
 			Expect(albums).To(HaveLen(1))
 			Expect(albums[0].Id).To(Equal("foo-album-id"))
+		})
+
+		It("Includes releases known only by year or month if they overlap the window", func() {
+			albumList := []model.Album{
+				{Id: "this-year-id", ReleaseDate: releaseDate("2016")},
+				{Id: "last-month-id", ReleaseDate: releaseDate("2016-01")},
+				{Id: "too-old-id", ReleaseDate: releaseDate("2015-12")},
+				{Id: "no-date-id"},
+			}
+			client.GetArtistAlbumsReturns(albumList, nil)
+			client.GetAlbumInfoReturns(albumList, nil)
+
+			releases, err := service.GetRecentReleases(ctx)
+			albums := releases.Albums
+			Expect(err).To(BeNil())
+
+			Expect(getAlbumIds(albums)).To(Equal([]string{"this-year-id", "last-month-id"}))
+		})
+
+		It("Requests the configured album groups and keeps each release's group", func() {
+			client.GetArtistAlbumsReturns([]model.Album{
+				{Id: "foo-album-id", AlbumGroup: "appears_on"},
+			}, nil)
+			service := NewSpotifyService(client, lastRuns, timeWrapper, Options{
+				AlbumGroups: []string{"single", "appears_on"},
+			})
+
+			releases, err := service.GetRecentReleases(ctx)
+			albums := releases.Albums
+			Expect(err).To(BeNil())
+
+			_, _, _, albumGroups := client.GetArtistAlbumsArgsForCall(0)
+			Expect(albumGroups).To(Equal([]string{"single", "appears_on"}))
+
+			Expect(albums).To(HaveLen(1))
+			Expect(albums[0].AlbumGroup).To(Equal("appears_on"))
+		})
+
+		It("Retrieves the albums of several artists concurrently, keeping their order", func() {
+			artists := []model.Artist{}
+			for i := 0; i < 6; i++ {
+				artists = append(artists, model.Artist{Id: "artist-" + strconv.Itoa(i)})
+			}
+			artists = append(artists, model.Artist{Id: "artist-0"})
+			client.GetFollowedArtistsReturns(artists, nil)
+			client.GetSavedAlbumsReturns([]model.Album{}, nil)
+
+			var mutex sync.Mutex
+			running := 0
+			maxRunning := 0
+			client.GetArtistAlbumsStub = func(_ context.Context, artistId string, market string, albumGroups []string) ([]model.Album, error) {
+				mutex.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mutex.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+
+				mutex.Lock()
+				running--
+				mutex.Unlock()
+
+				return []model.Album{
+					{Id: artistId + "-album", ReleaseDate: releaseDate("2017-01-01")},
+					{Id: "shared-album", ReleaseDate: releaseDate("2017-01-01")},
+				}, nil
+			}
+			client.GetAlbumInfoStub = func(_ context.Context, albumIds []string) ([]model.Album, error) {
+				albums := []model.Album{}
+				for _, albumId := range albumIds {
+					albums = append(albums, model.Album{Id: albumId, ReleaseDate: releaseDate("2017-01-01")})
+				}
+				return albums, nil
+			}
+			service := NewSpotifyService(client, lastRuns, timeWrapper, Options{Workers: 3})
+
+			releases, err := service.GetRecentReleases(ctx)
+			albums := releases.Albums
+			Expect(err).To(BeNil())
+
+			Expect(client.GetArtistAlbumsCallCount()).To(Equal(6))
+			Expect(maxRunning).To(Equal(3))
+			Expect(getAlbumIds(albums)).To(Equal([]string{
+				"artist-0-album",
+				"shared-album",
+				"artist-1-album",
+				"artist-2-album",
+				"artist-3-album",
+				"artist-4-album",
+				"artist-5-album",
+			}))
+		})
+
+		It("Stops requesting artists once the context is cancelled", func() {
+			artists := []model.Artist{}
+			for i := 0; i < 6; i++ {
+				artists = append(artists, model.Artist{Id: "artist-" + strconv.Itoa(i)})
+			}
+			client.GetFollowedArtistsReturns(artists, nil)
+			client.GetSavedAlbumsReturns([]model.Album{}, nil)
+
+			ctx, cancel := context.WithCancel(ctx)
+			client.GetArtistAlbumsStub = func(_ context.Context, artistId string, market string, albumGroups []string) ([]model.Album, error) {
+				cancel()
+				return []model.Album{}, nil
+			}
+			service := NewSpotifyService(client, lastRuns, timeWrapper, Options{Workers: 1})
 
-			Expect(timeWrapper.NowCallCount()).To(Equal(1))
+			_, err := service.GetRecentReleases(ctx)
+			Expect(err).To(MatchError(ContainSubstring("context canceled")))
+
+			Expect(client.GetArtistAlbumsCallCount()).To(BeNumerically("<", 6))
+			Expect(client.GetAlbumInfoCallCount()).To(Equal(0))
+		})
+
+		It("Returns an error if the albums of an artist cannot be retrieved", func() {
+			client.GetArtistAlbumsReturns(nil, errors.New("nope"))
+
+			_, err := service.GetRecentReleases(ctx)
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("Wraps API errors, so that callers can inspect them", func() {
+			client.GetArtistAlbumsReturns(nil, fmt.Errorf("GetArtistAlbums: request error: %w", &api.Error{Method: "GET", StatusCode: 404}))
+
+			_, err := service.GetRecentReleases(ctx)
+
+			var apiErr *api.Error
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.IsNotFound()).To(BeTrue())
+		})
+
+		It("Fails if the user profile has no country", func() {
+			client.GetUserProfileReturns(model.UserProfile{Id: "user-id"}, nil)
+
+			_, err := service.GetRecentReleases(ctx)
+
+			Expect(errors.Is(err, ErrNoCountry)).To(BeTrue())
+			Expect(client.GetArtistAlbumsCallCount()).To(Equal(0))
+		})
+
+		Describe("Tolerant mode", func() {
+			BeforeEach(func() {
+				service = NewSpotifyService(client, lastRuns, timeWrapper, Options{Tolerant: true})
+			})
+
+			It("Skips artists whose albums cannot be retrieved", func() {
+				notFound := &api.Error{Method: "GET", StatusCode: 404}
+				client.GetArtistAlbumsStub = func(_ context.Context, artistId string, market string, albumGroups []string) ([]model.Album, error) {
+					if artistId == "saved-artist-id" {
+						return nil, notFound
+					}
+					return allArtistAlbums, nil
+				}
+
+				releases, err := service.GetRecentReleases(ctx)
+
+				Expect(err).To(BeNil())
+				Expect(releases.Albums).To(Equal(expectedAlbums))
+				Expect(releases.Skipped).To(Equal([]Skipped{{ArtistId: "saved-artist-id", Err: notFound}}))
+			})
+
+			It("Skips batches of albums that cannot be retrieved", func() {
+				unavailable := &api.Error{Method: "GET", StatusCode: 503, Retryable: true}
+				client.GetAlbumInfoReturns(nil, unavailable)
+
+				releases, err := service.GetRecentReleases(ctx)
+
+				Expect(err).To(BeNil())
+				Expect(releases.Albums).To(BeEmpty())
+				Expect(releases.Skipped).To(Equal([]Skipped{{AlbumIds: []string{"foo-album-id"}, Err: unavailable}}))
+			})
+
+			It("Fails if the access token is rejected", func() {
+				client.GetArtistAlbumsReturns(nil, &api.Error{Method: "GET", StatusCode: 401})
+
+				_, err := service.GetRecentReleases(ctx)
+
+				Expect(err).NotTo(BeNil())
+			})
+
+			It("Fails if the access token cannot be refreshed", func() {
+				revoked := &auth.Error{StatusCode: 400, Code: auth.INVALID_GRANT}
+				client.GetArtistAlbumsReturns(nil, fmt.Errorf("requestWithRateLimiting: error refreshing access token: %w", revoked))
+
+				_, err := service.GetRecentReleases(ctx)
+
+				var authErr *auth.Error
+				Expect(errors.As(err, &authErr)).To(BeTrue())
+				Expect(authErr.IsInvalidGrant()).To(BeTrue())
+			})
+
+			It("Fails if Spotify asks to wait longer than allowed", func() {
+				client.GetArtistAlbumsReturns(nil, &api.RetryBudgetError{
+					Limit:    api.MAX_RETRY_AFTER,
+					Duration: time.Hour,
+					Max:      2 * time.Minute,
+				})
+
+				_, err := service.GetRecentReleases(ctx)
+
+				var budgetErr *api.RetryBudgetError
+				Expect(errors.As(err, &budgetErr)).To(BeTrue())
+				Expect(budgetErr.Limit).To(Equal(api.MAX_RETRY_AFTER))
+				Expect(client.GetAlbumInfoCallCount()).To(Equal(0))
+			})
+
+			It("Fails once the rate limiting budget of the run is used up", func() {
+				client.GetArtistAlbumsReturns(nil, &api.RetryBudgetError{Limit: api.MAX_RETRY_AFTER_PER_CLIENT})
+
+				_, err := service.GetRecentReleases(ctx)
+
+				Expect(err).NotTo(BeNil())
+			})
+		})
+
+		It("Uses the configured fallback window for the first run", func() {
+			client.GetArtistAlbumsReturns(oldAlbumList, nil)
+			client.GetAlbumInfoReturns(oldAlbumList, nil)
+			service := NewSpotifyService(client, lastRuns, timeWrapper, Options{
+				FallbackWindow: 4 * 365 * 24 * time.Hour,
+			})
+
+			releases, err := service.GetRecentReleases(ctx)
+			albums := releases.Albums
+			Expect(err).To(BeNil())
+
+			Expect(albums).To(HaveLen(3))
+		})
+
+		It("Only returns releases since the last successful run", func() {
+			lastRuns.GetLastRunReturns(time.Date(2016, 1, 2, 6, 0, 0, 0, time.UTC), nil)
+			albumList := append(oldAlbumList, model.Album{Id: "new-album-id", ReleaseDate: releaseDate("2016-01-03")})
+			client.GetArtistAlbumsReturns(albumList, nil)
+			client.GetAlbumInfoReturns(albumList, nil)
+
+			releases, err := service.GetRecentReleases(ctx)
+			albums := releases.Albums
+			Expect(err).To(BeNil())
+
+			Expect(lastRuns.GetLastRunCallCount()).To(Equal(1))
+			Expect(lastRuns.GetLastRunArgsForCall(0)).To(Equal("user-id"))
+			Expect(getAlbumIds(albums)).To(Equal([]string{"foo-album-id", "new-album-id"}))
+		})
+
+		It("Returns an error if the last run cannot be retrieved", func() {
+			lastRuns.GetLastRunReturns(time.Time{}, errors.New("nope"))
+
+			_, err := service.GetRecentReleases(ctx)
+			Expect(err).NotTo(BeNil())
 		})
 	})
 
@@ -216,7 +479,7 @@ var _ = Describe("SpotifyService", func() {
 
 			client = &apifakes.FakeSpotifyConnector{}
 			timeWrapper = &platformfakes.FakeTime{}
-			service = NewSpotifyService(client, timeWrapper)
+			service = NewSpotifyService(client, lastRuns, timeWrapper, Options{})
 
 			user := model.UserProfile{
 				Id: "my-user-id",
@@ -226,39 +489,441 @@ var _ = Describe("SpotifyService", func() {
 		})
 
 		It("Gets the current user's id", func() {
-			err := service.CreatePlaylist("access-token", "playlist name", tracks)
+			_, err := service.CreatePlaylist(ctx, "playlist name", tracks)
 
 			Expect(err).To(BeNil())
 
 			Expect(client.GetUserProfileCallCount()).To(Equal(1))
-			Expect(client.GetUserProfileArgsForCall(0)).To(Equal("access-token"))
 		})
 
 		It("Creates a new playlist", func() {
-			err := service.CreatePlaylist("access-token", "playlist name", tracks)
+			_, err := service.CreatePlaylist(ctx, "playlist name", tracks)
 
 			Expect(err).To(BeNil())
 
 			Expect(client.CreatePlaylistCallCount()).To(Equal(1))
 
-			token, userId, name := client.CreatePlaylistArgsForCall(0)
-			Expect(token).To(Equal("access-token"))
+			_, userId, name := client.CreatePlaylistArgsForCall(0)
 			Expect(userId).To(Equal("my-user-id"))
 			Expect(name).To(Equal("playlist name"))
 		})
 
+		It("Returns the playlist id", func() {
+			playlistId, err := service.CreatePlaylist(ctx, "playlist name", tracks)
+
+			Expect(err).To(BeNil())
+			Expect(playlistId).To(Equal("playlist-id"))
+		})
+
 		It("Adds all tracks to the playlist", func() {
-			err := service.CreatePlaylist("access-token", "playlist name", tracks)
+			_, err := service.CreatePlaylist(ctx, "playlist name", tracks)
 
 			Expect(err).To(BeNil())
 
 			Expect(client.AddTracksToPlaylistCallCount()).To(Equal(1))
 
-			token, userId, playlistId, actualTracks := client.AddTracksToPlaylistArgsForCall(0)
-			Expect(token).To(Equal("access-token"))
+			_, userId, playlistId, actualTracks := client.AddTracksToPlaylistArgsForCall(0)
 			Expect(userId).To(Equal("my-user-id"))
 			Expect(playlistId).To(Equal("playlist-id"))
 			Expect(actualTracks).To(Equal(tracks))
 		})
 	})
+
+	Describe("CreateWeeklyPlaylist", func() {
+		var client *apifakes.FakeSpotifyConnector
+		var service *SpotifyServiceImpl
+		var timeWrapper *platformfakes.FakeTime
+
+		BeforeEach(func() {
+			client = &apifakes.FakeSpotifyConnector{}
+			timeWrapper = &platformfakes.FakeTime{}
+			now, err := time.Parse("2006-01-02", "2017-01-01")
+			Expect(err).To(BeNil())
+			timeWrapper.NowReturns(now)
+			service = NewSpotifyService(client, lastRuns, timeWrapper, Options{})
+
+			albums := []model.Album{
+				{
+					Name:        "foo-album",
+					Id:          "foo-album-id",
+					ArtistIds:   []string{"foo-id"},
+					ReleaseDate: releaseDate("2017-01-01"),
+					Tracks:      []model.Track{{Id: "foo-track", Name: "foo", ArtistId: "foo-id"}},
+				},
+				{
+					Name:        "foo-album",
+					Id:          "foo-album-deluxe-id",
+					ArtistIds:   []string{"foo-id"},
+					ReleaseDate: releaseDate("2017-01-01"),
+					Tracks:      []model.Track{{Id: "foo-track-2", Name: "foo", ArtistId: "foo-id"}},
+				},
+				{
+					Name:        "bar-album",
+					Id:          "bar-album-id",
+					ArtistIds:   []string{"bar-id"},
+					ReleaseDate: releaseDate("2017-01-01"),
+					Tracks:      []model.Track{{Id: "bar-track", Name: "bar", ArtistId: "bar-id"}},
+				},
+			}
+
+			client.GetUserProfileReturns(model.UserProfile{Id: "user-id", Country: "market-id"}, nil)
+			client.GetFollowedArtistsReturns([]model.Artist{{Id: "foo-id"}}, nil)
+			client.GetArtistAlbumsReturns(albums, nil)
+			client.GetAlbumInfoReturns(albums, nil)
+			client.CreatePlaylistReturns("playlist-id", nil)
+		})
+
+		It("Creates a playlist with one track per unique release", func() {
+			report, err := service.CreateWeeklyPlaylist(ctx, "Weekly Releases - 2017-01-01")
+
+			Expect(err).To(BeNil())
+			Expect(report.PlaylistId).To(Equal("playlist-id"))
+			Expect(report.PlaylistName).To(Equal("Weekly Releases - 2017-01-01"))
+			Expect(report.TracksAdded).To(Equal(2))
+
+			Expect(client.AddTracksToPlaylistCallCount()).To(Equal(1))
+			_, _, _, tracks := client.AddTracksToPlaylistArgsForCall(0)
+			Expect(model.TrackList(tracks).GetUris()).To(Equal([]string{
+				"spotify:track:foo-track",
+				"spotify:track:bar-track",
+			}))
+		})
+
+		It("Reports what was found and removed", func() {
+			client.GetSavedAlbumsReturns([]model.Album{{Id: "bar-album-id", ArtistIds: []string{"bar-id"}}}, nil)
+			client.GetAlbumInfoReturns([]model.Album{
+				{
+					Name:        "foo-album",
+					Id:          "foo-album-id",
+					ArtistIds:   []string{"foo-id"},
+					ReleaseDate: releaseDate("2017-01-01"),
+					Tracks:      []model.Track{{Id: "foo-track", Name: "foo", ArtistId: "foo-id"}},
+				},
+				{
+					Name:        "foo-album",
+					Id:          "foo-album-deluxe-id",
+					ArtistIds:   []string{"foo-id"},
+					ReleaseDate: releaseDate("2016-01-01"),
+					Tracks:      []model.Track{{Id: "foo-track-2", Name: "foo", ArtistId: "foo-id"}},
+				},
+			}, nil)
+
+			report, err := service.CreateWeeklyPlaylist(ctx, "Weekly Releases - 2017-01-01")
+
+			Expect(err).To(BeNil())
+			Expect(report.StartedAt).To(Equal(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)))
+			Expect(report.ArtistsScanned).To(Equal(2))
+			Expect(report.AlbumsFound).To(Equal(3))
+			Expect(report.RemovedAsSaved).To(Equal(1))
+			Expect(report.FilteredByDate).To(Equal(1))
+			Expect(report.RemovedAsDuplicates).To(Equal(0))
+			Expect(report.TracksAdded).To(Equal(1))
+		})
+
+		It("Reports albums without tracks, so that the totals add up", func() {
+			found := []model.Album{
+				{Name: "foo-album", Id: "foo-album-id", ArtistIds: []string{"foo-id"}, ReleaseDate: releaseDate("2017-01-01"), Tracks: []model.Track{{Id: "foo-track", Name: "foo"}}},
+				{Name: "foo-album", Id: "foo-album-deluxe-id", ArtistIds: []string{"foo-id"}, ReleaseDate: releaseDate("2017-01-01"), Tracks: []model.Track{{Id: "foo-track-2", Name: "foo"}}},
+				{Name: "old-album", Id: "old-album-id", ArtistIds: []string{"foo-id"}, ReleaseDate: releaseDate("2015-01-01"), Tracks: []model.Track{{Id: "old-track", Name: "old"}}},
+				{Name: "saved-album", Id: "saved-album-id", ArtistIds: []string{"foo-id"}, ReleaseDate: releaseDate("2017-01-01")},
+				{Name: "empty-album", Id: "empty-album-id", ArtistIds: []string{"foo-id"}, ReleaseDate: releaseDate("2017-01-01")},
+				{Name: "missing-album", Id: "missing-album-id", ArtistIds: []string{"foo-id"}, ReleaseDate: releaseDate("2017-01-01")},
+			}
+			client.GetArtistAlbumsReturns(found, nil)
+			client.GetSavedAlbumsReturns([]model.Album{found[3]}, nil)
+			client.GetAlbumInfoReturns([]model.Album{found[0], found[1], found[2], found[4]}, nil)
+
+			report, err := service.CreateWeeklyPlaylist(ctx, "Weekly Releases - 2017-01-01")
+
+			Expect(err).To(BeNil())
+			Expect(report.AlbumsFound).To(Equal(6))
+			Expect(report.RemovedAsSaved).To(Equal(1))
+			Expect(report.SkippedAlbums).To(Equal(0))
+			Expect(report.WithoutTracks).To(Equal(2))
+			Expect(report.FilteredByDate).To(Equal(1))
+			Expect(report.RemovedAsDuplicates).To(Equal(1))
+			Expect(report.TracksAdded).To(Equal(1))
+			Expect(report.AlbumsFound - report.RemovedAsSaved - report.SkippedAlbums - report.WithoutTracks - report.FilteredByDate - report.RemovedAsDuplicates).To(Equal(report.TracksAdded))
+		})
+
+		It("Reports the albums of skipped batches apart from albums without tracks", func() {
+			service = NewSpotifyService(client, lastRuns, timeWrapper, Options{Tolerant: true})
+			found := []model.Album{
+				{Name: "foo-album", Id: "foo-album-id", ArtistIds: []string{"foo-id"}, ReleaseDate: releaseDate("2017-01-01"), Tracks: []model.Track{{Id: "foo-track", Name: "foo"}}},
+				{Name: "saved-album", Id: "saved-album-id", ArtistIds: []string{"foo-id"}, ReleaseDate: releaseDate("2017-01-01")},
+				{Name: "empty-album", Id: "empty-album-id", ArtistIds: []string{"foo-id"}, ReleaseDate: releaseDate("2017-01-01")},
+			}
+			client.GetArtistAlbumsReturns(found, nil)
+			client.GetSavedAlbumsReturns([]model.Album{found[1]}, nil)
+			client.GetAlbumInfoReturns(nil, &api.Error{Method: "GET", StatusCode: 503, Retryable: true})
+
+			report, err := service.CreateWeeklyPlaylist(ctx, "Weekly Releases - 2017-01-01")
+
+			Expect(err).To(BeNil())
+			Expect(report.AlbumsFound).To(Equal(3))
+			Expect(report.RemovedAsSaved).To(Equal(1))
+			Expect(report.SkippedAlbums).To(Equal(2))
+			Expect(report.WithoutTracks).To(Equal(0))
+			Expect(report.TracksAdded).To(Equal(0))
+			Expect(report.AlbumsFound - report.RemovedAsSaved - report.SkippedAlbums - report.WithoutTracks - report.FilteredByDate - report.RemovedAsDuplicates).To(Equal(report.TracksAdded))
+		})
+
+		It("Reports duplicates", func() {
+			report, err := service.CreateWeeklyPlaylist(ctx, "Weekly Releases - 2017-01-01")
+
+			Expect(err).To(BeNil())
+			Expect(report.RemovedAsDuplicates).To(Equal(1))
+		})
+
+		It("Reports the API requests of the run", func() {
+			client.StatsStub = func() api.Stats {
+				if client.StatsCallCount() == 1 {
+					return api.Stats{Requests: 10, CacheHits: 3}
+				}
+				return api.Stats{Requests: 25, Retries: 1, CacheHits: 7, CacheMisses: 2}
+			}
+
+			report, err := service.CreateWeeklyPlaylist(ctx, "Weekly Releases - 2017-01-01")
+
+			Expect(err).To(BeNil())
+			Expect(report.Api).To(Equal(api.Stats{Requests: 15, Retries: 1, CacheHits: 4, CacheMisses: 2}))
+		})
+
+		It("Reports the durations of the run", func() {
+			now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+			timeWrapper.NowStub = func() time.Time {
+				now = now.Add(time.Second)
+				return now
+			}
+
+			report, err := service.CreateWeeklyPlaylist(ctx, "Weekly Releases - 2017-01-01")
+
+			Expect(err).To(BeNil())
+			durations := report.Durations
+			Expect(durations.Artists).To(BeNumerically(">", 0))
+			Expect(durations.ArtistAlbums).To(BeNumerically(">", 0))
+			Expect(durations.AlbumDetails).To(BeNumerically(">", 0))
+			Expect(durations.Playlist).To(BeNumerically(">", 0))
+			Expect(durations.Total).To(BeNumerically(">", durations.Artists+durations.ArtistAlbums+durations.AlbumDetails+durations.Playlist))
+		})
+
+		It("Retrieves the user profile once", func() {
+			_, err := service.CreateWeeklyPlaylist(ctx, "Weekly Releases - 2017-01-01")
+
+			Expect(err).To(BeNil())
+			Expect(client.GetUserProfileCallCount()).To(Equal(1))
+		})
+
+		It("Retrieves the user profile once when updating the persistent playlist", func() {
+			report, err := service.UpdateWeeklyPlaylist(ctx, "", "Weekly Releases")
+
+			Expect(err).To(BeNil())
+			Expect(report.PlaylistId).To(Equal("playlist-id"))
+			Expect(client.GetUserProfileCallCount()).To(Equal(1))
+		})
+
+		It("Records the start of the run as the last successful run", func() {
+			_, err := service.CreateWeeklyPlaylist(ctx, "Weekly Releases - 2017-01-01")
+			Expect(err).To(BeNil())
+
+			Expect(lastRuns.SetLastRunCallCount()).To(Equal(1))
+			userId, lastRun := lastRuns.SetLastRunArgsForCall(0)
+			Expect(userId).To(Equal("user-id"))
+			Expect(lastRun).To(Equal(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)))
+		})
+
+		It("Does not record runs that skipped anything, so that their releases are included next time", func() {
+			var lastRun time.Time
+			lastRuns.GetLastRunStub = func(userId string) (time.Time, error) {
+				return lastRun, nil
+			}
+			lastRuns.SetLastRunStub = func(userId string, t time.Time) error {
+				lastRun = t
+				return nil
+			}
+			service = NewSpotifyService(client, lastRuns, timeWrapper, Options{Tolerant: true})
+
+			fooAlbum := model.Album{
+				Name:        "foo-album",
+				Id:          "foo-album-id",
+				ArtistIds:   []string{"foo-id"},
+				ReleaseDate: releaseDate("2016-12-30"),
+				Tracks:      []model.Track{{Id: "foo-track", Name: "foo", ArtistId: "foo-id"}},
+			}
+			barAlbum := model.Album{
+				Name:        "bar-album",
+				Id:          "bar-album-id",
+				ArtistIds:   []string{"bar-id"},
+				ReleaseDate: releaseDate("2016-12-31"),
+				Tracks:      []model.Track{{Id: "bar-track", Name: "bar", ArtistId: "bar-id"}},
+			}
+			barAvailable := false
+			client.GetFollowedArtistsReturns([]model.Artist{{Id: "foo-id"}, {Id: "bar-id"}}, nil)
+			client.GetArtistAlbumsStub = func(_ context.Context, artistId string, market string, albumGroups []string) ([]model.Album, error) {
+				if artistId == "foo-id" {
+					return []model.Album{fooAlbum}, nil
+				}
+				if !barAvailable {
+					return nil, &api.Error{Method: "GET", StatusCode: 503, Retryable: true}
+				}
+				return []model.Album{barAlbum}, nil
+			}
+			client.GetAlbumInfoStub = func(_ context.Context, albumIds []string) ([]model.Album, error) {
+				albums := []model.Album{}
+				for _, album := range []model.Album{fooAlbum, barAlbum} {
+					for _, albumId := range albumIds {
+						if album.Id == albumId {
+							albums = append(albums, album)
+						}
+					}
+				}
+				return albums, nil
+			}
+
+			report, err := service.CreateWeeklyPlaylist(ctx, "Weekly Releases - 2017-01-01")
+			Expect(err).To(BeNil())
+			Expect(report.Skipped).To(HaveLen(1))
+			Expect(lastRuns.SetLastRunCallCount()).To(Equal(0))
+
+			barAvailable = true
+			timeWrapper.NowReturns(time.Date(2017, 1, 8, 0, 0, 0, 0, time.UTC))
+
+			report, err = service.CreateWeeklyPlaylist(ctx, "Weekly Releases - 2017-01-08")
+			Expect(err).To(BeNil())
+			Expect(report.Skipped).To(BeEmpty())
+
+			_, _, _, tracks := client.AddTracksToPlaylistArgsForCall(1)
+			Expect(model.TrackList(tracks).GetUris()).To(ContainElement("spotify:track:bar-track"))
+			Expect(lastRun).To(Equal(time.Date(2017, 1, 8, 0, 0, 0, 0, time.UTC)))
+		})
+
+		It("Does not record failed runs", func() {
+			client.CreatePlaylistReturns("", errors.New("nope"))
+
+			report, err := service.CreateWeeklyPlaylist(ctx, "Weekly Releases - 2017-01-01")
+			Expect(err).NotTo(BeNil())
+
+			Expect(lastRuns.SetLastRunCallCount()).To(Equal(0))
+			Expect(report.AlbumsFound).To(Equal(3))
+			Expect(report.PlaylistId).To(BeEmpty())
+			Expect(report.TracksAdded).To(Equal(0))
+		})
+	})
+
+	Describe("UpdatePlaylist", func() {
+		var tracks []model.Track
+		var client *apifakes.FakeSpotifyConnector
+		var service *SpotifyServiceImpl
+
+		BeforeEach(func() {
+			tracks = []model.Track{
+				{
+					Id: "track-1",
+				},
+				{
+					Id: "track-2",
+				},
+			}
+
+			client = &apifakes.FakeSpotifyConnector{}
+			timeWrapper := &platformfakes.FakeTime{}
+			now, err := time.Parse("2006-01-02", "2017-01-01")
+			Expect(err).To(BeNil())
+			timeWrapper.NowReturns(now)
+			service = NewSpotifyService(client, lastRuns, timeWrapper, Options{})
+
+			client.GetUserProfileReturns(model.UserProfile{Id: "my-user-id"}, nil)
+			client.GetUserPlaylistsReturns([]model.Playlist{
+				{Id: "followed-playlist-id", Name: "Weekly Releases", OwnerId: "other-user-id"},
+				{Id: "old-playlist-id", Name: "Weekly Releases - 2016-12-24", OwnerId: "my-user-id"},
+				{Id: "playlist-id", Name: "Weekly Releases", OwnerId: "my-user-id"},
+			}, nil)
+			client.CreatePlaylistReturns("new-playlist-id", nil)
+		})
+
+		It("Replaces the tracks of the remembered playlist", func() {
+			playlistId, err := service.UpdatePlaylist(ctx, "old-playlist-id", "Weekly Releases", tracks)
+
+			Expect(err).To(BeNil())
+			Expect(playlistId).To(Equal("old-playlist-id"))
+			Expect(client.CreatePlaylistCallCount()).To(Equal(0))
+
+			Expect(client.ReplacePlaylistTracksCallCount()).To(Equal(1))
+			_, actualPlaylistId, actualTracks := client.ReplacePlaylistTracksArgsForCall(0)
+			Expect(actualPlaylistId).To(Equal("old-playlist-id"))
+			Expect(actualTracks).To(Equal(tracks))
+		})
+
+		It("Finds the user's own playlist by name", func() {
+			playlistId, err := service.UpdatePlaylist(ctx, "", "Weekly Releases", tracks)
+
+			Expect(err).To(BeNil())
+			Expect(playlistId).To(Equal("playlist-id"))
+			Expect(client.CreatePlaylistCallCount()).To(Equal(0))
+		})
+
+		It("Falls back to the name if the remembered playlist is gone", func() {
+			playlistId, err := service.UpdatePlaylist(ctx, "deleted-playlist-id", "Weekly Releases", tracks)
+
+			Expect(err).To(BeNil())
+			Expect(playlistId).To(Equal("playlist-id"))
+		})
+
+		It("Creates the playlist if it does not exist", func() {
+			playlistId, err := service.UpdatePlaylist(ctx, "", "New Releases", tracks)
+
+			Expect(err).To(BeNil())
+			Expect(playlistId).To(Equal("new-playlist-id"))
+
+			Expect(client.CreatePlaylistCallCount()).To(Equal(1))
+			_, userId, name := client.CreatePlaylistArgsForCall(0)
+			Expect(userId).To(Equal("my-user-id"))
+			Expect(name).To(Equal("New Releases"))
+
+			_, actualPlaylistId, _ := client.ReplacePlaylistTracksArgsForCall(0)
+			Expect(actualPlaylistId).To(Equal("new-playlist-id"))
+		})
+
+		It("Updates the description with the date", func() {
+			_, err := service.UpdatePlaylist(ctx, "", "Weekly Releases", tracks)
+
+			Expect(err).To(BeNil())
+
+			Expect(client.ChangePlaylistDetailsCallCount()).To(Equal(1))
+			_, playlistId, name, description := client.ChangePlaylistDetailsArgsForCall(0)
+			Expect(playlistId).To(Equal("playlist-id"))
+			Expect(name).To(Equal("Weekly Releases"))
+			Expect(description).To(Equal("Updated on 2017-01-01"))
+		})
+
+		It("Returns an error if the tracks cannot be replaced", func() {
+			client.ReplacePlaylistTracksReturns(errors.New("nope"))
+
+			_, err := service.UpdatePlaylist(ctx, "", "Weekly Releases", tracks)
+
+			Expect(err).NotTo(BeNil())
+			Expect(client.ChangePlaylistDetailsCallCount()).To(Equal(0))
+		})
+	})
+
+	It("WeeklyPlaylistName includes the date", func() {
+		date, err := time.Parse("2006-01-02", "2017-03-04")
+		Expect(err).To(BeNil())
+
+		Expect(WeeklyPlaylistName(date)).To(Equal("Weekly Releases - 2017-03-04"))
+	})
 })
+
+func getAlbumIds(albums []model.Album) []string {
+	ids := []string{}
+	for _, album := range albums {
+		ids = append(ids, album.Id)
+	}
+	return ids
+}
+
+func releaseDate(date string) model.ReleaseDate {
+	parsed, err := model.ParseReleaseDate(date, "")
+	Expect(err).To(BeNil())
+	return parsed
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"encoding/json"
+	"github.com/andreasf/spotify-weekly-releases/platform"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+// SubscriberLastRunStore keeps the last successful run of each subscriber in
+// the SubscriberStore, as Subscriber.LastSuccessAt.
+type SubscriberLastRunStore struct {
+	subscribers SubscriberStore
+}
+
+func NewSubscriberLastRunStore(subscribers SubscriberStore) *SubscriberLastRunStore {
+	return &SubscriberLastRunStore{
+		subscribers: subscribers,
+	}
+}
+
+func (self *SubscriberLastRunStore) GetLastRun(userId string) (time.Time, error) {
+	subscriber, err := self.subscribers.Get(userId)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return subscriber.LastSuccessAt, nil
+}
+
+func (self *SubscriberLastRunStore) SetLastRun(userId string, lastRun time.Time) error {
+	return self.subscribers.Update(userId, func(subscriber *Subscriber) error {
+		subscriber.LastSuccessAt = lastRun
+		return nil
+	})
+}
+
+// FileLastRunStore keeps the last successful run of every user in a single
+// JSON file. It is meant for the command line, where there are no subscribers.
+type FileLastRunStore struct {
+	filePath string
+	mutex    sync.Mutex
+}
+
+func NewFileLastRunStore(filePath string) *FileLastRunStore {
+	return &FileLastRunStore{
+		filePath: filePath,
+	}
+}
+
+func (self *FileLastRunStore) GetLastRun(userId string) (time.Time, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	lastRuns, err := self.read()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return lastRuns[userId], nil
+}
+
+func (self *FileLastRunStore) SetLastRun(userId string, lastRun time.Time) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	lastRuns, err := self.read()
+	if err != nil {
+		return err
+	}
+
+	lastRuns[userId] = lastRun
+
+	contents, err := json.Marshal(lastRuns)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(path.Dir(self.filePath), 0770)
+	if err != nil {
+		return err
+	}
+
+	return platform.WriteFileAtomically(self.filePath, contents, 0660)
+}
+
+func (self *FileLastRunStore) read() (map[string]time.Time, error) {
+	lastRuns := make(map[string]time.Time)
+
+	contents, err := ioutil.ReadFile(self.filePath)
+	if os.IsNotExist(err) {
+		return lastRuns, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(contents, &lastRuns)
+	if err != nil {
+		return nil, err
+	}
+
+	return lastRuns, nil
+}
@@ -0,0 +1,89 @@
+package store_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/store"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+)
+
+var _ = Describe("LastRunStore", func() {
+	var tempDir string
+	var lastRun time.Time
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "test")
+		Expect(err).To(BeNil())
+
+		lastRun = time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	Describe("SubscriberLastRunStore", func() {
+		It("Stores the last run as the subscriber's last success", func() {
+			subscribers := NewFileSubscriberStore(tempDir)
+			Expect(subscribers.Save(Subscriber{Id: "foo"})).To(Succeed())
+			lastRuns := NewSubscriberLastRunStore(subscribers)
+
+			actual, err := lastRuns.GetLastRun("foo")
+			Expect(err).To(BeNil())
+			Expect(actual.IsZero()).To(BeTrue())
+
+			Expect(lastRuns.SetLastRun("foo", lastRun)).To(Succeed())
+
+			subscriber, err := subscribers.Get("foo")
+			Expect(err).To(BeNil())
+			Expect(subscriber.LastSuccessAt).To(Equal(lastRun))
+
+			actual, err = lastRuns.GetLastRun("foo")
+			Expect(err).To(BeNil())
+			Expect(actual).To(Equal(lastRun))
+		})
+	})
+
+	Describe("FileLastRunStore", func() {
+		It("Replaces the file without leaving temporary files behind", func() {
+			lastRuns := NewFileLastRunStore(path.Join(tempDir, "last_run.json"))
+			Expect(lastRuns.SetLastRun("foo", lastRun)).To(Succeed())
+			Expect(lastRuns.SetLastRun("bar", lastRun)).To(Succeed())
+
+			files, err := ioutil.ReadDir(tempDir)
+			Expect(err).To(BeNil())
+			Expect(files).To(HaveLen(1))
+			Expect(files[0].Name()).To(Equal("last_run.json"))
+			Expect(files[0].Mode().Perm()).To(Equal(os.FileMode(0660)))
+		})
+
+		It("Returns the zero time for unknown users", func() {
+			lastRuns := NewFileLastRunStore(path.Join(tempDir, "last_run.json"))
+
+			actual, err := lastRuns.GetLastRun("foo")
+			Expect(err).To(BeNil())
+			Expect(actual.IsZero()).To(BeTrue())
+		})
+
+		It("Keeps the last run of each user across instances", func() {
+			filePath := path.Join(tempDir, "state", "last_run.json")
+			Expect(NewFileLastRunStore(filePath).SetLastRun("foo", lastRun)).To(Succeed())
+			Expect(NewFileLastRunStore(filePath).SetLastRun("bar", lastRun.Add(time.Hour))).To(Succeed())
+
+			lastRuns := NewFileLastRunStore(filePath)
+
+			actual, err := lastRuns.GetLastRun("foo")
+			Expect(err).To(BeNil())
+			Expect(actual.Equal(lastRun)).To(BeTrue())
+
+			actual, err = lastRuns.GetLastRun("bar")
+			Expect(err).To(BeNil())
+			Expect(actual.Equal(lastRun.Add(time.Hour))).To(BeTrue())
+		})
+	})
+})
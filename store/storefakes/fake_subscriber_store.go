@@ -0,0 +1,239 @@
+// This file was generated by counterfeiter
+package storefakes
+
+import (
+	"sync"
+
+	"github.com/andreasf/spotify-weekly-releases/store"
+)
+
+type FakeSubscriberStore struct {
+	GetStub        func(userId string) (store.Subscriber, error)
+	getMutex       sync.RWMutex
+	getArgsForCall []struct {
+		userId string
+	}
+	getReturns struct {
+		result1 store.Subscriber
+		result2 error
+	}
+	ListStub        func() ([]store.Subscriber, error)
+	listMutex       sync.RWMutex
+	listArgsForCall []struct{}
+	listReturns     struct {
+		result1 []store.Subscriber
+		result2 error
+	}
+	SaveStub        func(subscriber store.Subscriber) error
+	saveMutex       sync.RWMutex
+	saveArgsForCall []struct {
+		subscriber store.Subscriber
+	}
+	saveReturns struct {
+		result1 error
+	}
+	UpdateStub        func(userId string, update func(subscriber *store.Subscriber) error) error
+	updateMutex       sync.RWMutex
+	updateArgsForCall []struct {
+		userId string
+		update func(subscriber *store.Subscriber) error
+	}
+	updateReturns struct {
+		result1 error
+	}
+	DeleteStub        func(userId string) error
+	deleteMutex       sync.RWMutex
+	deleteArgsForCall []struct {
+		userId string
+	}
+	deleteReturns struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeSubscriberStore) Get(userId string) (store.Subscriber, error) {
+	fake.getMutex.Lock()
+	fake.getArgsForCall = append(fake.getArgsForCall, struct {
+		userId string
+	}{userId})
+	fake.recordInvocation("Get", []interface{}{userId})
+	fake.getMutex.Unlock()
+	if fake.GetStub != nil {
+		return fake.GetStub(userId)
+	}
+	return fake.getReturns.result1, fake.getReturns.result2
+}
+
+func (fake *FakeSubscriberStore) GetCallCount() int {
+	fake.getMutex.RLock()
+	defer fake.getMutex.RUnlock()
+	return len(fake.getArgsForCall)
+}
+
+func (fake *FakeSubscriberStore) GetArgsForCall(i int) string {
+	fake.getMutex.RLock()
+	defer fake.getMutex.RUnlock()
+	return fake.getArgsForCall[i].userId
+}
+
+func (fake *FakeSubscriberStore) GetReturns(result1 store.Subscriber, result2 error) {
+	fake.GetStub = nil
+	fake.getReturns = struct {
+		result1 store.Subscriber
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSubscriberStore) List() ([]store.Subscriber, error) {
+	fake.listMutex.Lock()
+	fake.listArgsForCall = append(fake.listArgsForCall, struct{}{})
+	fake.recordInvocation("List", []interface{}{})
+	fake.listMutex.Unlock()
+	if fake.ListStub != nil {
+		return fake.ListStub()
+	}
+	return fake.listReturns.result1, fake.listReturns.result2
+}
+
+func (fake *FakeSubscriberStore) ListCallCount() int {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	return len(fake.listArgsForCall)
+}
+
+func (fake *FakeSubscriberStore) ListReturns(result1 []store.Subscriber, result2 error) {
+	fake.ListStub = nil
+	fake.listReturns = struct {
+		result1 []store.Subscriber
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSubscriberStore) Save(subscriber store.Subscriber) error {
+	fake.saveMutex.Lock()
+	fake.saveArgsForCall = append(fake.saveArgsForCall, struct {
+		subscriber store.Subscriber
+	}{subscriber})
+	fake.recordInvocation("Save", []interface{}{subscriber})
+	fake.saveMutex.Unlock()
+	if fake.SaveStub != nil {
+		return fake.SaveStub(subscriber)
+	}
+	return fake.saveReturns.result1
+}
+
+func (fake *FakeSubscriberStore) SaveCallCount() int {
+	fake.saveMutex.RLock()
+	defer fake.saveMutex.RUnlock()
+	return len(fake.saveArgsForCall)
+}
+
+func (fake *FakeSubscriberStore) SaveArgsForCall(i int) store.Subscriber {
+	fake.saveMutex.RLock()
+	defer fake.saveMutex.RUnlock()
+	return fake.saveArgsForCall[i].subscriber
+}
+
+func (fake *FakeSubscriberStore) SaveReturns(result1 error) {
+	fake.SaveStub = nil
+	fake.saveReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSubscriberStore) Update(userId string, update func(subscriber *store.Subscriber) error) error {
+	fake.updateMutex.Lock()
+	fake.updateArgsForCall = append(fake.updateArgsForCall, struct {
+		userId string
+		update func(subscriber *store.Subscriber) error
+	}{userId, update})
+	fake.recordInvocation("Update", []interface{}{userId, update})
+	fake.updateMutex.Unlock()
+	if fake.UpdateStub != nil {
+		return fake.UpdateStub(userId, update)
+	}
+	return fake.updateReturns.result1
+}
+
+func (fake *FakeSubscriberStore) UpdateCallCount() int {
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	return len(fake.updateArgsForCall)
+}
+
+func (fake *FakeSubscriberStore) UpdateArgsForCall(i int) (string, func(subscriber *store.Subscriber) error) {
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	return fake.updateArgsForCall[i].userId, fake.updateArgsForCall[i].update
+}
+
+func (fake *FakeSubscriberStore) UpdateReturns(result1 error) {
+	fake.UpdateStub = nil
+	fake.updateReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSubscriberStore) Delete(userId string) error {
+	fake.deleteMutex.Lock()
+	fake.deleteArgsForCall = append(fake.deleteArgsForCall, struct {
+		userId string
+	}{userId})
+	fake.recordInvocation("Delete", []interface{}{userId})
+	fake.deleteMutex.Unlock()
+	if fake.DeleteStub != nil {
+		return fake.DeleteStub(userId)
+	}
+	return fake.deleteReturns.result1
+}
+
+func (fake *FakeSubscriberStore) DeleteCallCount() int {
+	fake.deleteMutex.RLock()
+	defer fake.deleteMutex.RUnlock()
+	return len(fake.deleteArgsForCall)
+}
+
+func (fake *FakeSubscriberStore) DeleteArgsForCall(i int) string {
+	fake.deleteMutex.RLock()
+	defer fake.deleteMutex.RUnlock()
+	return fake.deleteArgsForCall[i].userId
+}
+
+func (fake *FakeSubscriberStore) DeleteReturns(result1 error) {
+	fake.DeleteStub = nil
+	fake.deleteReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSubscriberStore) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getMutex.RLock()
+	defer fake.getMutex.RUnlock()
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	fake.saveMutex.RLock()
+	defer fake.saveMutex.RUnlock()
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	fake.deleteMutex.RLock()
+	defer fake.deleteMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeSubscriberStore) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ store.SubscriberStore = new(FakeSubscriberStore)
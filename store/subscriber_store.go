@@ -0,0 +1,202 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/andreasf/spotify-weekly-releases/auth"
+	"github.com/andreasf/spotify-weekly-releases/platform"
+	"github.com/andreasf/spotify-weekly-releases/services"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrNotFound = errors.New("store: subscriber not found")
+
+var validUserId = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+type Subscriber struct {
+	Id               string     `json:"id"`
+	Token            auth.Token `json:"token"`
+	SubscribedAt     time.Time  `json:"subscribed_at"`
+	LastRunAt        time.Time  `json:"last_run_at"`
+	LastSuccessAt    time.Time  `json:"last_success_at"`
+	LastPlaylistId   string     `json:"last_playlist_id"`
+	LastPlaylistName string     `json:"last_playlist_name"`
+	LastTrackCount   int        `json:"last_track_count"`
+	LastError        string     `json:"last_error"`
+
+	// PersistentPlaylist selects updating a single playlist on every run
+	// instead of creating a new, dated one.
+	PersistentPlaylist bool `json:"persistent_playlist"`
+
+	// AlbumGroups selects the kinds of releases included in the playlist,
+	// empty for the defaults.
+	AlbumGroups []string `json:"album_groups"`
+
+	// LastReport is the report of the last run, successful or not.
+	LastReport *services.RunReport `json:"last_report,omitempty"`
+}
+
+//go:generate counterfeiter . SubscriberStore
+type SubscriberStore interface {
+	Get(userId string) (Subscriber, error)
+	List() ([]Subscriber, error)
+	Save(subscriber Subscriber) error
+	// Update reads a subscriber, applies update and saves the result, without
+	// other changes coming in between. Nothing is saved if update returns an
+	// error. Returns ErrNotFound for unknown subscribers.
+	Update(userId string, update func(subscriber *Subscriber) error) error
+	Delete(userId string) error
+}
+
+// FileSubscriberStore keeps one JSON file per subscriber below baseDir.
+type FileSubscriberStore struct {
+	baseDir string
+	mutex   sync.RWMutex
+}
+
+func NewFileSubscriberStore(baseDir string) *FileSubscriberStore {
+	return &FileSubscriberStore{
+		baseDir: baseDir,
+	}
+}
+
+func (self *FileSubscriberStore) Get(userId string) (Subscriber, error) {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+
+	return self.read(userId)
+}
+
+func (self *FileSubscriberStore) List() ([]Subscriber, error) {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+
+	files, err := ioutil.ReadDir(self.baseDir)
+	if os.IsNotExist(err) {
+		return []Subscriber{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	userIds := make([]string, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		userIds = append(userIds, strings.TrimSuffix(file.Name(), ".json"))
+	}
+	sort.Strings(userIds)
+
+	subscribers := make([]Subscriber, 0, len(userIds))
+	for _, userId := range userIds {
+		subscriber, err := self.read(userId)
+		if err != nil {
+			return nil, err
+		}
+		subscribers = append(subscribers, subscriber)
+	}
+
+	return subscribers, nil
+}
+
+func (self *FileSubscriberStore) Save(subscriber Subscriber) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return self.write(subscriber)
+}
+
+func (self *FileSubscriberStore) Update(userId string, update func(subscriber *Subscriber) error) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	subscriber, err := self.read(userId)
+	if err != nil {
+		return err
+	}
+
+	err = update(&subscriber)
+	if err != nil {
+		return err
+	}
+
+	subscriber.Id = userId
+	return self.write(subscriber)
+}
+
+func (self *FileSubscriberStore) Delete(userId string) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	filePath, err := self.getPath(userId)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(filePath)
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+
+	return err
+}
+
+func (self *FileSubscriberStore) read(userId string) (Subscriber, error) {
+	filePath, err := self.getPath(userId)
+	if err != nil {
+		return Subscriber{}, err
+	}
+
+	contents, err := ioutil.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return Subscriber{}, ErrNotFound
+	}
+	if err != nil {
+		return Subscriber{}, err
+	}
+
+	subscriber := Subscriber{}
+	err = json.Unmarshal(contents, &subscriber)
+	if err != nil {
+		return Subscriber{}, err
+	}
+
+	return subscriber, nil
+}
+
+// write replaces the subscriber's file through a temporary file, so that a
+// crash cannot leave a truncated file with the tokens lost.
+func (self *FileSubscriberStore) write(subscriber Subscriber) error {
+	filePath, err := self.getPath(subscriber.Id)
+	if err != nil {
+		return err
+	}
+
+	contents, err := json.Marshal(&subscriber)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(self.baseDir, 0770)
+	if err != nil {
+		return err
+	}
+
+	return platform.WriteFileAtomically(filePath, contents, 0660)
+}
+
+func (self *FileSubscriberStore) getPath(userId string) (string, error) {
+	if !validUserId.MatchString(userId) || strings.Trim(userId, ".") == "" {
+		return "", errors.New("store: invalid user id " + userId)
+	}
+
+	return path.Join(self.baseDir, userId+".json"), nil
+}
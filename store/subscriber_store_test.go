@@ -0,0 +1,180 @@
+package store_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/store"
+
+	"errors"
+	"github.com/andreasf/spotify-weekly-releases/auth"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+var _ = Describe("FileSubscriberStore", func() {
+	var tempDir string
+	var subscribers *FileSubscriberStore
+	var foo Subscriber
+
+	BeforeEach(func() {
+		var tempErr error
+		tempDir, tempErr = ioutil.TempDir("", "test")
+		Expect(tempErr).To(BeNil())
+
+		subscribers = NewFileSubscriberStore(tempDir)
+		foo = Subscriber{
+			Id: "foo",
+			Token: auth.Token{
+				AccessToken:  "access-token",
+				RefreshToken: "refresh-token",
+				Expiry:       time.Date(2017, 1, 1, 13, 0, 0, 0, time.UTC),
+			},
+			SubscribedAt: time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC),
+		}
+	})
+
+	AfterEach(func() {
+		err := os.RemoveAll(tempDir)
+		Expect(err).To(BeNil())
+	})
+
+	It("Can retrieve saved subscribers", func() {
+		err := subscribers.Save(foo)
+		Expect(err).To(BeNil())
+
+		subscriber, err := subscribers.Get("foo")
+		Expect(err).To(BeNil())
+		Expect(subscriber).To(Equal(foo))
+	})
+
+	It("Returns ErrNotFound for unknown subscribers", func() {
+		_, err := subscribers.Get("bar")
+		Expect(err).To(Equal(ErrNotFound))
+	})
+
+	It("Lists all subscribers ordered by id", func() {
+		bar := Subscriber{Id: "bar"}
+		Expect(subscribers.Save(foo)).To(Succeed())
+		Expect(subscribers.Save(bar)).To(Succeed())
+
+		list, err := subscribers.List()
+		Expect(err).To(BeNil())
+		Expect(list).To(HaveLen(2))
+		Expect(list[0].Id).To(Equal("bar"))
+		Expect(list[1].Id).To(Equal("foo"))
+	})
+
+	It("Returns an empty list if nothing has been saved yet", func() {
+		subscribers = NewFileSubscriberStore(tempDir + "/missing")
+
+		list, err := subscribers.List()
+		Expect(err).To(BeNil())
+		Expect(list).To(BeEmpty())
+	})
+
+	It("Can delete subscribers", func() {
+		Expect(subscribers.Save(foo)).To(Succeed())
+		Expect(subscribers.Delete("foo")).To(Succeed())
+
+		_, err := subscribers.Get("foo")
+		Expect(err).To(Equal(ErrNotFound))
+	})
+
+	It("Rejects user ids that are not safe file names", func() {
+		err := subscribers.Save(Subscriber{Id: "../foo"})
+		Expect(err).ToNot(BeNil())
+	})
+
+	Describe("Update", func() {
+		It("Saves the changes made to the subscriber", func() {
+			Expect(subscribers.Save(foo)).To(Succeed())
+
+			err := subscribers.Update("foo", func(subscriber *Subscriber) error {
+				subscriber.PersistentPlaylist = true
+				return nil
+			})
+			Expect(err).To(BeNil())
+
+			subscriber, err := subscribers.Get("foo")
+			Expect(err).To(BeNil())
+			Expect(subscriber.PersistentPlaylist).To(BeTrue())
+			Expect(subscriber.Token).To(Equal(foo.Token))
+		})
+
+		It("Does not save anything if the update fails", func() {
+			Expect(subscribers.Save(foo)).To(Succeed())
+
+			err := subscribers.Update("foo", func(subscriber *Subscriber) error {
+				subscriber.PersistentPlaylist = true
+				return errors.New("nope")
+			})
+			Expect(err).To(MatchError("nope"))
+
+			subscriber, err := subscribers.Get("foo")
+			Expect(err).To(BeNil())
+			Expect(subscriber.PersistentPlaylist).To(BeFalse())
+		})
+
+		It("Returns ErrNotFound for unknown subscribers", func() {
+			err := subscribers.Update("bar", func(subscriber *Subscriber) error {
+				return nil
+			})
+			Expect(err).To(Equal(ErrNotFound))
+
+			_, err = subscribers.Get("bar")
+			Expect(err).To(Equal(ErrNotFound))
+		})
+
+		It("Does not lose concurrent updates", func() {
+			Expect(subscribers.Save(foo)).To(Succeed())
+
+			wg := sync.WaitGroup{}
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					Expect(subscribers.Update("foo", func(subscriber *Subscriber) error {
+						subscriber.LastTrackCount++
+						return nil
+					})).To(Succeed())
+				}()
+			}
+			wg.Wait()
+
+			subscriber, err := subscribers.Get("foo")
+			Expect(err).To(BeNil())
+			Expect(subscriber.LastTrackCount).To(Equal(20))
+		})
+	})
+
+	It("Does not leave temporary files behind", func() {
+		Expect(subscribers.Save(foo)).To(Succeed())
+		Expect(subscribers.Save(foo)).To(Succeed())
+
+		files, err := ioutil.ReadDir(tempDir)
+		Expect(err).To(BeNil())
+		Expect(files).To(HaveLen(1))
+		Expect(files[0].Name()).To(Equal("foo.json"))
+	})
+
+	Describe("SubscriberTokenStore", func() {
+		It("Loads and saves the tokens of one subscriber", func() {
+			Expect(subscribers.Save(foo)).To(Succeed())
+			tokens := NewSubscriberTokenStore(subscribers, "foo")
+
+			token, err := tokens.Load()
+			Expect(err).To(BeNil())
+			Expect(token).To(Equal(foo.Token))
+
+			newToken := auth.Token{AccessToken: "new-access-token", RefreshToken: "refresh-token"}
+			Expect(tokens.Save(newToken)).To(Succeed())
+
+			subscriber, err := subscribers.Get("foo")
+			Expect(err).To(BeNil())
+			Expect(subscriber.Token).To(Equal(newToken))
+			Expect(subscriber.SubscribedAt).To(Equal(foo.SubscribedAt))
+		})
+	})
+})
@@ -0,0 +1,33 @@
+package store
+
+import "github.com/andreasf/spotify-weekly-releases/auth"
+
+// SubscriberTokenStore is an auth.TokenStore that keeps the tokens of a
+// single subscriber in the SubscriberStore.
+type SubscriberTokenStore struct {
+	subscribers SubscriberStore
+	userId      string
+}
+
+func NewSubscriberTokenStore(subscribers SubscriberStore, userId string) *SubscriberTokenStore {
+	return &SubscriberTokenStore{
+		subscribers: subscribers,
+		userId:      userId,
+	}
+}
+
+func (self *SubscriberTokenStore) Load() (auth.Token, error) {
+	subscriber, err := self.subscribers.Get(self.userId)
+	if err != nil {
+		return auth.Token{}, err
+	}
+
+	return subscriber.Token, nil
+}
+
+func (self *SubscriberTokenStore) Save(token auth.Token) error {
+	return self.subscribers.Update(self.userId, func(subscriber *Subscriber) error {
+		subscriber.Token = token
+		return nil
+	})
+}
@@ -0,0 +1,334 @@
+package web
+
+import (
+	"context"
+	"github.com/andreasf/spotify-weekly-releases/api"
+	"github.com/andreasf/spotify-weekly-releases/auth"
+	"github.com/andreasf/spotify-weekly-releases/model"
+	"github.com/andreasf/spotify-weekly-releases/platform"
+	"github.com/andreasf/spotify-weekly-releases/scheduler"
+	"github.com/andreasf/spotify-weekly-releases/store"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const SESSION_COOKIE string = "session"
+
+// Logins that are not completed within this time are discarded.
+const LOGIN_TIMEOUT time.Duration = 10 * time.Minute
+
+type pendingLogin struct {
+	codeVerifier string
+	startedAt    time.Time
+}
+
+type Server struct {
+	ctx           context.Context
+	tokenClient   *auth.TokenClient
+	subscribers   store.SubscriberStore
+	newService    scheduler.ServiceFactory
+	scheduler     *scheduler.Scheduler
+	timeWrapper   platform.Time
+	mutex         sync.Mutex
+	pendingLogins map[string]pendingLogin
+	sessions      map[string]string
+}
+
+// NewServer creates the web app. The playlist runs it starts continue after
+// the response and are cancelled when ctx is done, e.g. on shutdown.
+func NewServer(ctx context.Context, tokenClient *auth.TokenClient, subscribers store.SubscriberStore, newService scheduler.ServiceFactory, scheduler *scheduler.Scheduler, timeWrapper platform.Time) *Server {
+	return &Server{
+		ctx:           ctx,
+		tokenClient:   tokenClient,
+		subscribers:   subscribers,
+		newService:    newService,
+		scheduler:     scheduler,
+		timeWrapper:   timeWrapper,
+		pendingLogins: make(map[string]pendingLogin),
+		sessions:      make(map[string]string),
+	}
+}
+
+func (self *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", self.handleIndex)
+	mux.HandleFunc("/login", self.handleLogin)
+	mux.HandleFunc("/callback", self.handleCallback)
+	mux.HandleFunc("/status", self.handleStatus)
+	mux.HandleFunc("/generate", self.handleGenerate)
+	mux.HandleFunc("/settings", self.handleSettings)
+	mux.HandleFunc("/unsubscribe", self.handleUnsubscribe)
+	return mux
+}
+
+func (self *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if self.sessionUserId(r) != "" {
+		http.Redirect(w, r, "/status", http.StatusFound)
+		return
+	}
+
+	render(w, indexTemplate, nil)
+}
+
+func (self *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	state, err := auth.NewState()
+	if err != nil {
+		internalError(w, "handleLogin: error creating state: %v", err)
+		return
+	}
+
+	codeVerifier, err := auth.NewCodeVerifier()
+	if err != nil {
+		internalError(w, "handleLogin: error creating code verifier: %v", err)
+		return
+	}
+
+	now := self.timeWrapper.Now()
+
+	self.mutex.Lock()
+	for pendingState, login := range self.pendingLogins {
+		if now.Sub(login.startedAt) > LOGIN_TIMEOUT {
+			delete(self.pendingLogins, pendingState)
+		}
+	}
+	self.pendingLogins[state] = pendingLogin{
+		codeVerifier: codeVerifier,
+		startedAt:    now,
+	}
+	self.mutex.Unlock()
+
+	http.Redirect(w, r, self.tokenClient.AuthorizationUrl(state, auth.CodeChallenge(codeVerifier)), http.StatusFound)
+}
+
+func (self *Server) handleCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	self.mutex.Lock()
+	login, found := self.pendingLogins[query.Get("state")]
+	delete(self.pendingLogins, query.Get("state"))
+	self.mutex.Unlock()
+
+	if !found {
+		http.Error(w, "Unknown or expired login, please try again.", http.StatusBadRequest)
+		return
+	}
+
+	if query.Get("error") != "" {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	token, err := self.tokenClient.ExchangeCode(r.Context(), query.Get("code"), login.codeVerifier)
+	if err != nil {
+		internalError(w, "handleCallback: %v", err)
+		return
+	}
+
+	profile, err := self.newService(api.NewStaticTokenSource(token.AccessToken), nil).GetUserProfile(r.Context())
+	if err != nil {
+		internalError(w, "handleCallback: %v", err)
+		return
+	}
+
+	firstRun := false
+	err = self.subscribers.Update(profile.Id, func(subscriber *store.Subscriber) error {
+		subscriber.Token = token
+		firstRun = subscriber.LastRunAt.IsZero()
+		return nil
+	})
+	if err == store.ErrNotFound {
+		firstRun = true
+		err = self.subscribers.Save(store.Subscriber{
+			Id:           profile.Id,
+			Token:        token,
+			SubscribedAt: self.timeWrapper.Now(),
+		})
+	}
+	if err != nil {
+		internalError(w, "handleCallback: error saving subscriber: %v", err)
+		return
+	}
+
+	err = self.startSession(w, profile.Id)
+	if err != nil {
+		internalError(w, "handleCallback: error creating session: %v", err)
+		return
+	}
+
+	// the run continues after the response, so it must not use the request's context
+	if firstRun {
+		self.scheduler.StartSubscriber(self.ctx, profile.Id)
+	}
+
+	http.Redirect(w, r, "/status", http.StatusFound)
+}
+
+func (self *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	userId := self.sessionUserId(r)
+	if userId == "" {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	subscriber, err := self.subscribers.Get(userId)
+	if err == store.ErrNotFound {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+	if err != nil {
+		internalError(w, "handleStatus: error retrieving subscriber: %v", err)
+		return
+	}
+
+	render(w, statusTemplate, statusPage{
+		Subscriber:  subscriber,
+		Running:     self.scheduler.IsRunning(userId),
+		AlbumGroups: albumGroupOptions(subscriber.AlbumGroups),
+	})
+}
+
+func (self *Server) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userId := self.sessionUserId(r)
+	if userId == "" {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	self.scheduler.StartSubscriber(self.ctx, userId)
+	http.Redirect(w, r, "/status", http.StatusFound)
+}
+
+func (self *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userId := self.sessionUserId(r)
+	if userId == "" {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	persistentPlaylist := r.PostFormValue("persistent_playlist") != ""
+
+	var albumGroups []string
+	if len(r.PostForm["album_groups"]) > 0 {
+		var err error
+		albumGroups, err = model.ParseAlbumGroups(strings.Join(r.PostForm["album_groups"], ","))
+		if err != nil {
+			http.Error(w, "Invalid album groups", http.StatusBadRequest)
+			return
+		}
+	}
+
+	err := self.subscribers.Update(userId, func(subscriber *store.Subscriber) error {
+		subscriber.PersistentPlaylist = persistentPlaylist
+		subscriber.AlbumGroups = albumGroups
+		return nil
+	})
+	if err == store.ErrNotFound {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+	if err != nil {
+		internalError(w, "handleSettings: error saving subscriber: %v", err)
+		return
+	}
+
+	http.Redirect(w, r, "/status", http.StatusFound)
+}
+
+func (self *Server) handleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userId := self.sessionUserId(r)
+	if userId != "" {
+		err := self.subscribers.Delete(userId)
+		if err != nil && err != store.ErrNotFound {
+			internalError(w, "handleUnsubscribe: error deleting subscriber: %v", err)
+			return
+		}
+
+		self.endSession(w, r)
+	}
+
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+func (self *Server) startSession(w http.ResponseWriter, userId string) error {
+	sessionId, err := auth.NewState()
+	if err != nil {
+		return err
+	}
+
+	self.mutex.Lock()
+	self.sessions[sessionId] = userId
+	self.mutex.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SESSION_COOKIE,
+		Value:    sessionId,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nil
+}
+
+func (self *Server) endSession(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(SESSION_COOKIE)
+	if err == nil {
+		self.mutex.Lock()
+		delete(self.sessions, cookie.Value)
+		self.mutex.Unlock()
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:   SESSION_COOKIE,
+		Path:   "/",
+		MaxAge: -1,
+	})
+}
+
+func (self *Server) sessionUserId(r *http.Request) string {
+	cookie, err := r.Cookie(SESSION_COOKIE)
+	if err != nil {
+		return ""
+	}
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.sessions[cookie.Value]
+}
+
+func render(w http.ResponseWriter, tmpl *template.Template, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := tmpl.Execute(w, data)
+	if err != nil {
+		log.Printf("render: error executing template: %v", err)
+	}
+}
+
+func internalError(w http.ResponseWriter, format string, err error) {
+	log.Printf(format, err)
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
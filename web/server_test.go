@@ -0,0 +1,272 @@
+package web_test
+
+import (
+	. "github.com/andreasf/spotify-weekly-releases/web"
+
+	"context"
+	"errors"
+	"github.com/andreasf/spotify-weekly-releases/api"
+	"github.com/andreasf/spotify-weekly-releases/auth"
+	"github.com/andreasf/spotify-weekly-releases/model"
+	"github.com/andreasf/spotify-weekly-releases/platform/platformfakes"
+	"github.com/andreasf/spotify-weekly-releases/scheduler"
+	"github.com/andreasf/spotify-weekly-releases/services"
+	"github.com/andreasf/spotify-weekly-releases/services/servicesfakes"
+	"github.com/andreasf/spotify-weekly-releases/store"
+	"github.com/andreasf/spotify-weekly-releases/test_resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+var _ = Describe("Server", func() {
+	var tokenServer *ghttp.Server
+	var webServer *httptest.Server
+	var browser *http.Client
+	var tempDir string
+	var subscribers *store.FileSubscriberStore
+	var service *servicesfakes.FakeSpotifyService
+	var tokenSources []api.TokenSource
+	var tokenSourcesMutex sync.Mutex
+	var now time.Time
+	var ctx context.Context
+	var cancel context.CancelFunc
+
+	BeforeEach(func() {
+		var err error
+		ctx, cancel = context.WithCancel(context.Background())
+		tempDir, err = ioutil.TempDir("", "test")
+		Expect(err).To(BeNil())
+		subscribers = store.NewFileSubscriberStore(tempDir)
+
+		tokenServer = ghttp.NewServer()
+		tokenServer.AllowUnhandledRequests = false
+
+		timeWrapper := &platformfakes.FakeTime{}
+		now = time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+		timeWrapper.NowReturns(now)
+
+		service = &servicesfakes.FakeSpotifyService{}
+		service.GetUserProfileReturns(model.UserProfile{Id: "user-id", Country: "market-id"}, nil)
+		service.CreateWeeklyPlaylistReturns(services.RunReport{
+			PlaylistId:   "playlist-id",
+			PlaylistName: "Weekly Releases - 2017-01-01",
+			TracksAdded:  42,
+		}, nil)
+		tokenSources = []api.TokenSource{}
+
+		tokenClient := auth.NewTokenClient(auth.Config{
+			ClientId:     "client-id",
+			AuthorizeUrl: tokenServer.URL() + "/authorize",
+			TokenUrl:     tokenServer.URL() + "/api/token",
+			RedirectUrl:  "http://weekly-releases/callback",
+		}, timeWrapper)
+
+		newService := func(tokens api.TokenSource, albumGroups []string) services.SpotifyService {
+			tokenSourcesMutex.Lock()
+			defer tokenSourcesMutex.Unlock()
+			tokenSources = append(tokenSources, tokens)
+			return service
+		}
+
+		schedule, err := scheduler.ParseSchedule("0 6 * * 5")
+		Expect(err).To(BeNil())
+		playlistScheduler := scheduler.NewScheduler(schedule, subscribers, tokenClient, newService, timeWrapper)
+
+		server := NewServer(ctx, tokenClient, subscribers, newService, playlistScheduler, timeWrapper)
+		webServer = httptest.NewServer(server.Handler())
+
+		jar, err := cookiejar.New(nil)
+		Expect(err).To(BeNil())
+		browser = &http.Client{
+			Jar: jar,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	})
+
+	AfterEach(func() {
+		cancel()
+		webServer.Close()
+		tokenServer.Close()
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	login := func() {
+		resp, err := browser.Get(webServer.URL + "/login")
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusFound))
+
+		authUrl, err := url.Parse(resp.Header.Get("Location"))
+		Expect(err).To(BeNil())
+
+		tokenServer.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/api/token"),
+				ghttp.RespondWith(200, test_resources.LoadResource("../test_resources/token_response.json")),
+			),
+		)
+
+		resp, err = browser.Get(webServer.URL + "/callback?code=the-code&state=" + url.QueryEscape(authUrl.Query().Get("state")))
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusFound))
+		Expect(resp.Header.Get("Location")).To(Equal("/status"))
+	}
+
+	getBody := func(path string) string {
+		resp, err := browser.Get(webServer.URL + path)
+		Expect(err).To(BeNil())
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		Expect(err).To(BeNil())
+		return string(body)
+	}
+
+	It("Redirects to the authorization server on login", func() {
+		resp, err := browser.Get(webServer.URL + "/login")
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusFound))
+
+		authUrl, err := url.Parse(resp.Header.Get("Location"))
+		Expect(err).To(BeNil())
+		Expect(authUrl.Path).To(Equal("/authorize"))
+		Expect(authUrl.Query().Get("client_id")).To(Equal("client-id"))
+		Expect(authUrl.Query().Get("redirect_uri")).To(Equal("http://weekly-releases/callback"))
+		Expect(authUrl.Query().Get("state")).ToNot(BeEmpty())
+		Expect(authUrl.Query().Get("code_challenge")).ToNot(BeEmpty())
+	})
+
+	It("Rejects callbacks with an unknown state", func() {
+		resp, err := browser.Get(webServer.URL + "/callback?code=the-code&state=unknown")
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(tokenServer.ReceivedRequests()).To(HaveLen(0))
+	})
+
+	It("Stores the subscriber with the refresh token after login", func() {
+		login()
+
+		subscriber, err := subscribers.Get("user-id")
+		Expect(err).To(BeNil())
+		Expect(subscriber.SubscribedAt).To(Equal(now))
+		Expect(subscriber.Token.RefreshToken).To(Equal("new-refresh-token"))
+
+		tokenSourcesMutex.Lock()
+		defer tokenSourcesMutex.Unlock()
+		accessToken, err := tokenSources[0].AccessToken(context.Background())
+		Expect(err).To(BeNil())
+		Expect(accessToken).To(Equal("new-access-token"))
+	})
+
+	It("Creates the first playlist after subscribing", func() {
+		login()
+
+		Eventually(func() string {
+			subscriber, err := subscribers.Get("user-id")
+			Expect(err).To(BeNil())
+			return subscriber.LastPlaylistId
+		}).Should(Equal("playlist-id"))
+
+		Expect(service.CreateWeeklyPlaylistCallCount()).To(Equal(1))
+		_, name := service.CreateWeeklyPlaylistArgsForCall(0)
+		Expect(name).To(Equal("Weekly Releases - 2017-01-01"))
+		Eventually(func() string { return getBody("/status") }).Should(ContainSubstring("Weekly Releases - 2017-01-01"))
+		Expect(getBody("/status")).To(ContainSubstring("42 tracks"))
+	})
+
+	It("Cancels the runs it started when its context is done", func() {
+		login()
+
+		Eventually(service.CreateWeeklyPlaylistCallCount).Should(Equal(1))
+		runCtx, _ := service.CreateWeeklyPlaylistArgsForCall(0)
+		Expect(runCtx.Err()).To(BeNil())
+
+		cancel()
+
+		Expect(runCtx.Err()).To(Equal(context.Canceled))
+	})
+
+	It("Shows the error of the last run on the status page", func() {
+		service.CreateWeeklyPlaylistReturns(services.RunReport{}, errors.New("something broke"))
+		login()
+
+		Eventually(func() string {
+			subscriber, err := subscribers.Get("user-id")
+			Expect(err).To(BeNil())
+			return subscriber.LastError
+		}).ShouldNot(BeEmpty())
+
+		Eventually(func() string { return getBody("/status") }).Should(ContainSubstring("something broke"))
+	})
+
+	It("Redirects to the index page without a session", func() {
+		resp, err := browser.Get(webServer.URL + "/status")
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusFound))
+		Expect(resp.Header.Get("Location")).To(Equal("/"))
+	})
+
+	It("Saves the persistent playlist setting", func() {
+		login()
+		Eventually(func() string { return getBody("/status") }).Should(ContainSubstring("Last playlist"))
+
+		resp, err := browser.PostForm(webServer.URL+"/settings", url.Values{"persistent_playlist": {"on"}})
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusFound))
+
+		subscriber, err := subscribers.Get("user-id")
+		Expect(err).To(BeNil())
+		Expect(subscriber.PersistentPlaylist).To(BeTrue())
+		Expect(getBody("/status")).To(ContainSubstring("checked"))
+
+		resp, err = browser.PostForm(webServer.URL+"/settings", url.Values{})
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusFound))
+
+		subscriber, err = subscribers.Get("user-id")
+		Expect(err).To(BeNil())
+		Expect(subscriber.PersistentPlaylist).To(BeFalse())
+	})
+
+	It("Saves the selected album groups", func() {
+		login()
+		Eventually(func() string { return getBody("/status") }).Should(ContainSubstring("Last playlist"))
+
+		resp, err := browser.PostForm(webServer.URL+"/settings", url.Values{"album_groups": {"single", "appears_on"}})
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusFound))
+
+		subscriber, err := subscribers.Get("user-id")
+		Expect(err).To(BeNil())
+		Expect(subscriber.AlbumGroups).To(Equal([]string{"single", "appears_on"}))
+		Expect(getBody("/status")).To(ContainSubstring(`value="appears_on" checked`))
+
+		resp, err = browser.PostForm(webServer.URL+"/settings", url.Values{"album_groups": {"everything"}})
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+
+	It("Deletes the subscriber on unsubscribe", func() {
+		login()
+		Eventually(func() string { return getBody("/status") }).Should(ContainSubstring("Last playlist"))
+
+		resp, err := browser.PostForm(webServer.URL+"/unsubscribe", url.Values{})
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusFound))
+
+		Eventually(func() error {
+			_, err := subscribers.Get("user-id")
+			return err
+		}).Should(Equal(store.ErrNotFound))
+	})
+})
@@ -0,0 +1,97 @@
+package web
+
+import (
+	"github.com/andreasf/spotify-weekly-releases/model"
+	"github.com/andreasf/spotify-weekly-releases/store"
+	"html/template"
+)
+
+type statusPage struct {
+	Subscriber  store.Subscriber
+	Running     bool
+	AlbumGroups []albumGroupOption
+}
+
+type albumGroupOption struct {
+	Name     string
+	Label    string
+	Selected bool
+}
+
+var albumGroupLabels = map[string]string{
+	model.ALBUM_GROUP_ALBUM:       "Albums",
+	model.ALBUM_GROUP_SINGLE:      "Singles and EPs",
+	model.ALBUM_GROUP_APPEARS_ON:  "Appearances on other artists' releases",
+	model.ALBUM_GROUP_COMPILATION: "Compilations",
+}
+
+func albumGroupOptions(selected []string) []albumGroupOption {
+	if len(selected) == 0 {
+		selected = model.DEFAULT_ALBUM_GROUPS
+	}
+
+	options := make([]albumGroupOption, 0, len(model.ALBUM_GROUPS))
+	for _, group := range model.ALBUM_GROUPS {
+		option := albumGroupOption{
+			Name:  group,
+			Label: albumGroupLabels[group],
+		}
+		for _, selectedGroup := range selected {
+			if group == selectedGroup {
+				option.Selected = true
+			}
+		}
+		options = append(options, option)
+	}
+
+	return options
+}
+
+const layoutHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Weekly Releases</title>
+</head>
+<body>
+<h1>Weekly Releases</h1>
+`
+
+const layoutFooter = `</body>
+</html>
+`
+
+var indexTemplate = template.Must(template.New("index").Parse(layoutHeader + `
+<p>Weekly Releases is a weekly playlist that you can subscribe to. It is created from new releases by artists that you follow on Spotify.</p>
+<p><a href="/login">Subscribe with Spotify</a></p>
+` + layoutFooter))
+
+var statusTemplate = template.Must(template.New("status").Parse(layoutHeader + `
+<p>Subscribed as <strong>{{.Subscriber.Id}}</strong> since {{.Subscriber.SubscribedAt.Format "2006-01-02"}}.</p>
+{{if .Running}}
+<p>Your playlist is being created. Reload this page in a few minutes.</p>
+{{end}}
+{{if .Subscriber.LastPlaylistId}}
+<p>Last playlist: <a href="https://open.spotify.com/playlist/{{.Subscriber.LastPlaylistId}}">{{.Subscriber.LastPlaylistName}}</a> ({{.Subscriber.LastTrackCount}} tracks)</p>
+{{else if not .Running}}
+<p>No playlist has been created yet.</p>
+{{end}}
+{{if not .Subscriber.LastRunAt.IsZero}}
+<p>Last run: {{.Subscriber.LastRunAt.Format "2006-01-02 15:04 MST"}}</p>
+{{end}}
+{{if not .Subscriber.LastSuccessAt.IsZero}}
+<p>Last successful run: {{.Subscriber.LastSuccessAt.Format "2006-01-02 15:04 MST"}}</p>
+{{end}}
+{{if .Subscriber.LastError}}
+<p>The last run failed: {{.Subscriber.LastError}}</p>
+{{end}}
+<form method="post" action="/settings">
+<label><input type="checkbox" name="persistent_playlist" value="on"{{if .Subscriber.PersistentPlaylist}} checked{{end}}> Update a single playlist instead of creating a new one every week</label>
+{{range .AlbumGroups}}
+<label><input type="checkbox" name="album_groups" value="{{.Name}}"{{if .Selected}} checked{{end}}> {{.Label}}</label>
+{{end}}
+<button type="submit">Save</button>
+</form>
+<form method="post" action="/generate"><button type="submit">Create playlist now</button></form>
+<form method="post" action="/unsubscribe"><button type="submit">Unsubscribe</button></form>
+` + layoutFooter))